@@ -0,0 +1,28 @@
+package drivers
+
+import (
+	"github.com/xo/dburl"
+)
+
+// SetApplicationName sets the application name reported to the database on
+// the database URL (ie, application_name for PostgreSQL), when the driver
+// supports it (see Driver.SetApplicationName) and the URL does not already
+// set it.
+func SetApplicationName(u *dburl.URL, name string) {
+	if d, ok := drivers[u.Driver]; ok && d.SetApplicationName != nil && name != "" {
+		d.SetApplicationName(u, name)
+	}
+}
+
+// ApplicationNameParameter is a utility func that wraps setting the
+// application name as the query parameter param, when not already set.
+func ApplicationNameParameter(param string) func(*dburl.URL, string) {
+	return func(u *dburl.URL, name string) {
+		q := u.Query()
+		if q.Has(param) {
+			return
+		}
+		q.Set(param, name)
+		u.RawQuery = q.Encode()
+	}
+}
@@ -0,0 +1,300 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/text"
+)
+
+// Checkpoint is the state of a keyed copy, recorded after each copied batch
+// so that an interrupted copy can be resumed.
+type Checkpoint struct {
+	// Src is the source database, either a named connection or a database
+	// URL without its password.
+	Src string `json:"src"`
+	// Dest is the destination database, either a named connection or a
+	// database URL without its password.
+	Dest string `json:"dest"`
+	// Query is the source query.
+	Query string `json:"query"`
+	// Table is the destination table.
+	Table string `json:"table"`
+	// Key are the key columns the query results are ordered by.
+	Key []string `json:"key"`
+	// Last are the key values of the last copied row.
+	Last []CheckpointValue `json:"last,omitempty"`
+	// Count is the number of rows copied.
+	Count int64 `json:"count"`
+}
+
+// CheckpointValue is a key value recorded in a checkpoint, with its type, so
+// that it is bound with the same type when the copy is resumed.
+type CheckpointValue struct {
+	// Type is the type of the value (bool, int, uint, float, string, or
+	// time).
+	Type string `json:"type"`
+	// Value is the JSON encoded value.
+	Value json.RawMessage `json:"value"`
+}
+
+// newCheckpointValue returns the checkpoint value for the scanned value v.
+// Byte slices and values of other types are recorded as strings.
+func newCheckpointValue(v interface{}) (CheckpointValue, error) {
+	var typ string
+	switch x := v.(type) {
+	case bool:
+		typ = "bool"
+	case int, int8, int16, int32, int64:
+		typ = "int"
+	case uint, uint8, uint16, uint32, uint64:
+		typ = "uint"
+	case float32, float64:
+		typ = "float"
+	case string:
+		typ = "string"
+	case []byte:
+		typ, v = "string", string(x)
+	case time.Time:
+		typ = "time"
+	default:
+		typ, v = "string", fmt.Sprint(x)
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return CheckpointValue{}, err
+	}
+	return CheckpointValue{Type: typ, Value: buf}, nil
+}
+
+// value returns the value to bind as a query parameter.
+func (v CheckpointValue) value() (interface{}, error) {
+	var x interface{}
+	switch v.Type {
+	case "bool":
+		x = new(bool)
+	case "int":
+		x = new(int64)
+	case "uint":
+		x = new(uint64)
+	case "float":
+		x = new(float64)
+	case "string":
+		x = new(string)
+	case "time":
+		x = new(time.Time)
+	default:
+		return nil, fmt.Errorf(text.InvalidCheckpointValue, v.Type)
+	}
+	if err := json.Unmarshal(v.Value, x); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(x).Elem().Interface(), nil
+}
+
+// checkpointValues returns the values to bind for the checkpoint values, or
+// nil when there are none.
+func checkpointValues(vals []CheckpointValue) ([]interface{}, error) {
+	if vals == nil {
+		return nil, nil
+	}
+	v := make([]interface{}, len(vals))
+	for i, val := range vals {
+		var err error
+		if v[i], err = val.value(); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// LoadCheckpoint loads a checkpoint from the file name.
+func LoadCheckpoint(name string) (*Checkpoint, error) {
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	c := new(Checkpoint)
+	if err := json.Unmarshal(buf, c); err != nil {
+		return nil, fmt.Errorf(text.InvalidCheckpoint, name, err)
+	}
+	if c.Src == "" || c.Dest == "" || c.Query == "" || c.Table == "" || len(c.Key) == 0 || (c.Last != nil && len(c.Last) != len(c.Key)) {
+		return nil, fmt.Errorf(text.InvalidCheckpoint, name, text.ErrMissingRequiredArgument)
+	}
+	if _, err := checkpointValues(c.Last); err != nil {
+		return nil, fmt.Errorf(text.InvalidCheckpoint, name, err)
+	}
+	return c, nil
+}
+
+// save atomically saves the checkpoint to the file name. As the checkpoint
+// contains the query and key values, the file is only readable by the user.
+func (c *Checkpoint) save(name string) error {
+	buf, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// CopyCheckpoint copies the results of the checkpoint's query from the source
+// database src to the destination database dest in batches of rows ordered by
+// the key columns, which must not contain NULL values. After each batch is
+// copied, the key values of the last copied row are saved to the checkpoint
+// file name, so that an interrupted copy can be resumed by calling
+// CopyCheckpoint with the loaded checkpoint. The checkpoint file is removed
+// when the copy completes.
+//
+// Rows are copied at least once: as the checkpoint is saved after its batch
+// is copied, a copy interrupted between the two copies the batch again when
+// resumed. A unique key on the destination table's key columns makes a
+// duplicated batch fail, instead of being silently copied twice.
+//
+// Batches are selected with a keyset (ie, WHERE key > last) and an offset
+// query on the source database, and each batch is copied with the destination
+// driver's copy.
+func CopyCheckpoint(ctx context.Context, name string, c *Checkpoint, src, dest *dburl.URL, batch int, stdout, stderr func() io.Writer) (int64, error) {
+	d, ok := drivers[dest.Driver]
+	switch {
+	case !ok:
+		return 0, WrapErr(dest.Driver, text.ErrDriverNotAvailable)
+	case d.Copy == nil:
+		return 0, fmt.Errorf(text.NotSupportedByDriver, "copy", dest.Driver)
+	}
+	srcDb, err := Open(ctx, src, stdout, stderr)
+	if err != nil {
+		return 0, err
+	}
+	defer srcDb.Close()
+	destDb, err := Open(ctx, dest, stdout, stderr)
+	if err != nil {
+		return 0, err
+	}
+	defer destDb.Close()
+	from := " FROM (" + endRE.ReplaceAllString(c.Query, "") + ") q"
+	order := " ORDER BY " + strings.Join(c.Key, ", ")
+	// rows with NULL keys are never selected by the keyset, and would be
+	// silently skipped
+	nulls := make([]string, len(c.Key))
+	for i, key := range c.Key {
+		nulls[i] = key + " IS NULL"
+	}
+	switch err := srcDb.QueryRowContext(ctx, limitQuery(src, "SELECT 1"+from+" WHERE "+strings.Join(nulls, " OR "), 1, 0)).Scan(new(int)); {
+	case err == nil:
+		return 0, fmt.Errorf(text.CheckpointNullKey, strings.Join(c.Key, ", "))
+	case err != sql.ErrNoRows:
+		return 0, err
+	}
+	// save before the first batch, so that the copy can always be resumed
+	if err := c.save(name); err != nil {
+		return 0, err
+	}
+	vals, err := checkpointValues(c.Last)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for {
+		where, args := "", []interface{}(nil)
+		if vals != nil {
+			var cond string
+			cond, args = keysetAfter(src, c.Key, vals, args)
+			where = " WHERE (" + cond + ")"
+		}
+		// determine the key values of the last row of the batch
+		last, err := keysetRow(ctx, srcDb, limitQuery(src, "SELECT "+strings.Join(c.Key, ", ")+from+where+order, 1, batch-1), c.Key, args)
+		if err != nil {
+			return n, err
+		}
+		sqlstr := "SELECT *" + from + where
+		if last != nil {
+			if where == "" {
+				sqlstr += " WHERE "
+			} else {
+				sqlstr += " AND "
+			}
+			var cond string
+			cond, args = keysetAfter(src, c.Key, last, args)
+			sqlstr += "NOT (" + cond + ")"
+		}
+		rows, err := srcDb.QueryContext(ctx, sqlstr+order, args...)
+		if err != nil {
+			return n, err
+		}
+		count, err := d.Copy(ctx, destDb, rows, c.Table)
+		rows.Close()
+		if err != nil {
+			return n, err
+		}
+		n += count
+		if last == nil {
+			// all remaining rows were copied
+			return n, os.Remove(name)
+		}
+		c.Last = make([]CheckpointValue, len(last))
+		for i, v := range last {
+			if c.Last[i], err = newCheckpointValue(v); err != nil {
+				return n, err
+			}
+		}
+		vals, c.Count = last, c.Count+count
+		if err := c.save(name); err != nil {
+			return n, err
+		}
+	}
+}
+
+// keysetRow returns the values of the key columns of the single row returned
+// by the query with the bound args. Returns nil when the query returns no
+// rows.
+func keysetRow(ctx context.Context, db *sql.DB, sqlstr string, key []string, args []interface{}) ([]interface{}, error) {
+	vals := make([]interface{}, len(key))
+	refs := make([]interface{}, len(key))
+	for i := range vals {
+		refs[i] = &vals[i]
+	}
+	switch err := db.QueryRowContext(ctx, sqlstr, args...).Scan(refs...); {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	for i, v := range vals {
+		if v == nil {
+			return nil, fmt.Errorf(text.CheckpointNullKey, key[i])
+		}
+	}
+	return vals, nil
+}
+
+// keysetAfter returns the condition selecting the rows ordered after the key
+// values, ie: k1 > v1 OR (k1 = v1 AND k2 > v2) OR .... The values are bound
+// as query parameters using the placeholders of the driver of the database
+// URL, and are appended to args.
+func keysetAfter(u *dburl.URL, key []string, vals, args []interface{}) (string, []interface{}) {
+	param := func(v interface{}) string {
+		args = append(args, v)
+		return Placeholder(u, len(args))
+	}
+	conds := make([]string, len(key))
+	for i := range key {
+		var eq []string
+		for j := 0; j < i; j++ {
+			eq = append(eq, key[j]+" = "+param(vals[j]))
+		}
+		conds[i] = "(" + strings.Join(append(eq, key[i]+" > "+param(vals[i])), " AND ") + ")"
+	}
+	return strings.Join(conds, " OR "), args
+}
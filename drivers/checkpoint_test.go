@@ -0,0 +1,52 @@
+package drivers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xo/dburl"
+)
+
+func TestCheckpointValues(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	scanned := []interface{}{true, int64(42), 4.2, "a'b", []byte("c'd"), ts}
+	exp := []interface{}{true, int64(42), 4.2, "a'b", "c'd", ts}
+	vals := make([]CheckpointValue, len(scanned))
+	for i, v := range scanned {
+		var err error
+		if vals[i], err = newCheckpointValue(v); err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+	}
+	buf, err := json.Marshal(vals)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var decoded []CheckpointValue
+	if err := json.Unmarshal(buf, &decoded); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	v, err := checkpointValues(decoded)
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case !reflect.DeepEqual(v, exp):
+		t.Errorf("expected %#v, got: %#v", exp, v)
+	}
+	if _, err := checkpointValues([]CheckpointValue{{Type: "blob"}}); err == nil {
+		t.Errorf("expected error for unknown value type")
+	}
+}
+
+func TestKeysetAfter(t *testing.T) {
+	u := &dburl.URL{Driver: "unknown"}
+	cond, args := keysetAfter(u, []string{"a", "b"}, []interface{}{1, "x'y"}, []interface{}{0})
+	if exp := "(a > ?) OR (a = ? AND b > ?)"; cond != exp {
+		t.Errorf("expected %q, got: %q", exp, cond)
+	}
+	if exp := []interface{}{0, 1, 1, "x'y"}; !reflect.DeepEqual(args, exp) {
+		t.Errorf("expected %v, got: %v", exp, args)
+	}
+}
@@ -37,5 +37,30 @@ func init() {
 		},
 		Copy:              drivers.CopyWithInsert(func(int) string { return "?" }),
 		NewMetadataReader: NewMetadataReader,
+		NearestNeighbors: drivers.NearestNeighborsByDistance(func(column, vec string) string {
+			return "L2Distance(" + column + ", " + vec + ")"
+		}),
+		SetOption: setOption,
 	})
 }
+
+// setOption returns the statement setting the session option name to value.
+func setOption(name, value string) (string, error) {
+	switch name {
+	case "search_path":
+		schemas, err := drivers.SearchPath(value, "`\"")
+		if err != nil {
+			return "", err
+		}
+		return "USE " + schemas[0], nil
+	case "time_zone":
+		return "SET session_timezone = " + drivers.QuoteLiteral(value), nil
+	case "statement_timeout":
+		timeout, err := drivers.StatementTimeout(value)
+		if err != nil {
+			return "", err
+		}
+		return "SET max_execution_time = " + strconv.FormatInt(int64(timeout.Seconds()), 10), nil
+	}
+	return "SET " + name + " = " + drivers.QuoteLiteral(value), nil
+}
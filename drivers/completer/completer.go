@@ -484,10 +484,10 @@ func (c completer) complete(previousWords []string, text []rune) [][]rune {
 		return c.completeWithCatalogs(text)
 	}
 	if TailMatches(MATCH_CASE, previousWords, `\pset`) {
-		return CompleteFromList(text, `border`, `columns`, `expanded`, `fieldsep`, `fieldsep_zero`,
+		return CompleteFromList(text, `auto_expanded`, `auto_json`, `auto_pager`, `auto_value`, `border`, `columns`, `expanded`, `fieldsep`, `fieldsep_zero`,
 			`footer`, `format`, `linestyle`, `null`, `numericlocale`, `pager`, `pager_min_lines`,
 			`recordsep`, `recordsep_zero`, `tableattr`, `title`, `title`, `tuples_only`,
-			`unicode_border_linestyle`, `unicode_column_linestyle`, `unicode_header_linestyle`)
+			`unicode_border_linestyle`, `unicode_column_linestyle`, `unicode_header_linestyle`, `vector_preview`)
 	}
 	if TailMatches(MATCH_CASE, previousWords, `\pset`, `expanded`) {
 		return CompleteFromList(text, "auto", "on", "off")
@@ -499,7 +499,7 @@ func (c completer) complete(previousWords []string, text []rune) [][]rune {
 		return CompleteFromList(text, "on", "off")
 	}
 	if TailMatches(MATCH_CASE, previousWords, `\pset`, `format`) {
-		return CompleteFromList(text, "unaligned", "aligned", "wrapped", "html", "asciidoc", "latex", "latex-longtable", "troff-ms", "csv", "json", "vertical")
+		return CompleteFromList(text, "unaligned", "aligned", "wrapped", "html", "asciidoc", "latex", "latex-longtable", "troff-ms", "csv", "json", "vertical", "xlsx", "parquet", "arrow")
 	}
 	if TailMatches(MATCH_CASE, previousWords, `\pset`, `linestyle`) {
 		return CompleteFromList(text, "ascii", "old-ascii", "unicode")
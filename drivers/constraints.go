@@ -0,0 +1,153 @@
+package drivers
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/text"
+)
+
+// ForeignKey is a foreign key constraint.
+type ForeignKey struct {
+	// Name is the constraint name.
+	Name string
+	// Table is the (referencing) table.
+	Table string
+	// Columns are the table's columns.
+	Columns []string
+	// RefTable is the referenced table.
+	RefTable string
+	// RefColumns are the referenced table's columns.
+	RefColumns []string
+	// Validated is whether the database enforces the constraint, and has
+	// validated the existing rows (false when NOT VALID, disabled, or not
+	// trusted).
+	Validated bool
+}
+
+// ForeignKeys returns the foreign keys of the table, or of all tables in the
+// current schema when table is empty, for the driver of the database URL,
+// using the driver's ForeignKeys. Column and table names are quoted as needed.
+func ForeignKeys(ctx context.Context, u *dburl.URL, db DB, table string) ([]ForeignKey, error) {
+	d, ok := drivers[u.Driver]
+	if !ok || d.ForeignKeys == nil {
+		return nil, fmt.Errorf(text.NotSupportedByDriver, `\checkfk`, u.Driver)
+	}
+	sqlstr, err := d.ForeignKeys(table)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, sqlstr)
+	if err != nil {
+		return nil, WrapErr(u.Driver, err)
+	}
+	defer rows.Close()
+	var fks []ForeignKey
+	for rows.Next() {
+		var name, table, refTable, column, refColumn string
+		var validated int
+		if err := rows.Scan(&name, &table, &refTable, &column, &refColumn, &validated); err != nil {
+			return nil, WrapErr(u.Driver, err)
+		}
+		if n := len(fks); n == 0 || fks[n-1].Name != name || fks[n-1].Table != table {
+			fks = append(fks, ForeignKey{
+				Name:      name,
+				Table:     table,
+				RefTable:  refTable,
+				Validated: validated == 1,
+			})
+		}
+		fk := &fks[len(fks)-1]
+		fk.Columns, fk.RefColumns = append(fk.Columns, column), append(fk.RefColumns, refColumn)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, WrapErr(u.Driver, err)
+	}
+	if len(fks) == 0 {
+		return nil, fmt.Errorf(text.NoForeignKeys, cmp.Or(table, "the current schema"))
+	}
+	return fks, nil
+}
+
+// orphaned returns the condition matching rows (aliased c) of the foreign
+// key's table without a referenced row (aliased p). Rows with NULL values in
+// any of the foreign key's columns are not orphaned (MATCH SIMPLE).
+func (fk ForeignKey) orphaned() string {
+	var notNull, join []string
+	for i, column := range fk.Columns {
+		notNull = append(notNull, "c."+column+" IS NOT NULL")
+		join = append(join, "p."+fk.RefColumns[i]+" = c."+column)
+	}
+	return "(" + strings.Join(notNull, " AND ") + " AND NOT EXISTS (SELECT 1 FROM " + fk.RefTable + " p WHERE " + strings.Join(join, " AND ") + "))"
+}
+
+// OrphansQuery returns the query counting the orphaned rows (rows without a
+// referenced row) of each of the foreign keys.
+func OrphansQuery(fks []ForeignKey) string {
+	branches := make([]string, len(fks))
+	for i, fk := range fks {
+		validated := "no"
+		if fk.Validated {
+			validated = "yes"
+		}
+		branches[i] = `SELECT ` + QuoteLiteral(fk.Name) + ` AS "Constraint", ` +
+			QuoteLiteral(fk.Table+"("+strings.Join(fk.Columns, ", ")+")") + ` AS "Table", ` +
+			QuoteLiteral(fk.RefTable+"("+strings.Join(fk.RefColumns, ", ")+")") + ` AS "References", ` +
+			QuoteLiteral(validated) + ` AS "Validated", ` +
+			`COUNT(*) AS "Orphans" ` +
+			`FROM ` + fk.Table + ` c WHERE ` + fk.orphaned()
+	}
+	return strings.Join(branches, " UNION ALL ")
+}
+
+// OrphanRowsQuery returns the query selecting up to n rows of the table
+// orphaned by any of the table's foreign keys, for the driver of the database
+// URL.
+func OrphanRowsQuery(u *dburl.URL, table string, fks []ForeignKey, n int) string {
+	var conds []string
+	for _, fk := range fks {
+		conds = append(conds, fk.orphaned())
+	}
+	return limitQuery(u, "SELECT c.* FROM "+table+" c WHERE "+strings.Join(conds, " OR "), n, 0)
+}
+
+// NullRowsQuery returns the query selecting up to n rows of the table with
+// NULL values in any of the columns, for the driver of the database URL.
+func NullRowsQuery(u *dburl.URL, table string, columns []string, n int) string {
+	conds := make([]string, len(columns))
+	for i, column := range columns {
+		conds[i] = column + " IS NULL"
+	}
+	return limitQuery(u, "SELECT * FROM "+table+" WHERE "+strings.Join(conds, " OR "), n, 0)
+}
+
+// DuplicatesQuery returns the query selecting up to n duplicated values of
+// the columns of the table (values violating a unique constraint on the
+// columns), with their counts, for the driver of the database URL.
+func DuplicatesQuery(u *dburl.URL, table string, columns []string, n int) string {
+	notNull := make([]string, len(columns))
+	for i, column := range columns {
+		notNull[i] = column + " IS NOT NULL"
+	}
+	list := strings.Join(columns, ", ")
+	return limitQuery(u, "SELECT "+list+`, COUNT(*) AS "Count" FROM `+table+
+		" WHERE "+strings.Join(notNull, " AND ")+
+		" GROUP BY "+list+" HAVING COUNT(*) > 1 ORDER BY COUNT(*) DESC", n, 0)
+}
+
+// limitQuery limits the rows selected by the query sqlstr to n, skipping
+// the first offset rows, for the driver of the database URL, using the
+// driver's LimitQuery. By default, LIMIT n OFFSET offset is appended.
+func limitQuery(u *dburl.URL, sqlstr string, n, offset int) string {
+	if d, ok := drivers[u.Driver]; ok && d.LimitQuery != nil {
+		return d.LimitQuery(sqlstr, n, offset)
+	}
+	if offset != 0 {
+		return sqlstr + " LIMIT " + strconv.Itoa(n) + " OFFSET " + strconv.Itoa(offset)
+	}
+	return sqlstr + " LIMIT " + strconv.Itoa(n)
+}
@@ -0,0 +1,246 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/xo/usql/text"
+)
+
+// CopyOptions are the options of a copy between databases (see
+// WithCopyOptions).
+type CopyOptions struct {
+	// Batch is the number of rows inserted by each statement, for drivers
+	// copying with inserts (see CopyWithInsert).
+	Batch int
+	// Workers is the number of concurrent insert workers, for drivers copying
+	// with inserts.
+	Workers int
+	// Casts are the types the copied values are coerced to, by source column
+	// name or source database type name (ie, NUMBER). The name * applies to
+	// all other columns. See CopyTypes.
+	Casts map[string]string
+	// Progress is called with the number of rows copied so far.
+	Progress func(int64)
+	// copied is the number of rows copied.
+	copied atomic.Int64
+}
+
+// CopyTypes are the types copied values can be coerced to.
+var CopyTypes = []string{"bool", "bytes", "float", "int", "text", "timestamp"}
+
+// ParseCopyCasts parses copy coercion rules (ie, created_at=timestamp,NUMBER=float).
+func ParseCopyCasts(s string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, rule := range strings.Split(s, ",") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+		name, typ, ok := strings.Cut(rule, "=")
+		name, typ = strings.TrimSpace(name), strings.ToLower(strings.TrimSpace(typ))
+		if !ok || name == "" || !slices.Contains(CopyTypes, typ) {
+			return nil, fmt.Errorf(text.InvalidCopyCast, rule, strings.Join(CopyTypes, ", "))
+		}
+		m[name] = typ
+	}
+	return m, nil
+}
+
+// copyOptionsKey is the context key for copy options.
+type copyOptionsKey struct{}
+
+// WithCopyOptions returns a context with the copy options used by a driver's
+// copy.
+func WithCopyOptions(ctx context.Context, opts *CopyOptions) context.Context {
+	return context.WithValue(ctx, copyOptionsKey{}, opts)
+}
+
+// CopyOptionsFrom returns the copy options of the context, or the default copy
+// options (one row per statement, one worker) when not set.
+func CopyOptionsFrom(ctx context.Context) *CopyOptions {
+	if opts, ok := ctx.Value(copyOptionsKey{}).(*CopyOptions); ok && opts != nil {
+		return opts
+	}
+	return new(CopyOptions)
+}
+
+// Coercer returns a func coercing the values of a row of the source columns
+// in place, according to the copy options' casts. Returns nil when no values
+// are coerced.
+func (opts *CopyOptions) Coercer(cols []*sql.ColumnType) func([]interface{}) {
+	if len(opts.Casts) == 0 {
+		return nil
+	}
+	types := make([]string, len(cols))
+	var ok bool
+	for i, col := range cols {
+		for _, name := range []string{col.Name(), strings.ToUpper(col.DatabaseTypeName()), "*"} {
+			if typ := opts.Casts[name]; typ != "" {
+				types[i], ok = typ, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+	return func(row []interface{}) {
+		for i, typ := range types {
+			if typ != "" && i < len(row) {
+				row[i] = coerceValue(row[i], typ)
+			}
+		}
+	}
+}
+
+// Copied adds n to the number of copied rows, calling the progress func with
+// the number of rows copied so far. Safe for concurrent use.
+func (opts *CopyOptions) Copied(n int64) {
+	count := opts.copied.Add(n)
+	if opts.Progress != nil {
+		opts.Progress(count)
+	}
+}
+
+// coerceValue coerces a scanned value to typ. Values that cannot be coerced
+// are returned as is.
+func coerceValue(v interface{}, typ string) interface{} {
+	// nullable types (ie, sql.NullString)
+	if valuer, ok := v.(driver.Valuer); ok {
+		val, err := valuer.Value()
+		if err != nil {
+			return v
+		}
+		v = val
+	}
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		if typ == "bytes" {
+			return x
+		}
+		v = string(x)
+	case sql.RawBytes:
+		v = string(x)
+	}
+	s, isString := v.(string)
+	z := strings.TrimSpace(s)
+	switch typ {
+	case "text":
+		switch x := v.(type) {
+		case string:
+			return x
+		case time.Time:
+			return x.Format(time.RFC3339Nano)
+		}
+		return fmt.Sprint(v)
+	case "bytes":
+		return []byte(fmt.Sprint(v))
+	case "int":
+		switch x := v.(type) {
+		case int64:
+			return x
+		case float64:
+			if i, ok := floatInt(x); ok {
+				return i
+			}
+		case bool:
+			if x {
+				return int64(1)
+			}
+			return int64(0)
+		}
+		if i, err := strconv.ParseInt(z, 10, 64); isString && err == nil {
+			return i
+		}
+		// numeric strings without a fractional part (ie, Oracle NUMBER 12.0)
+		if f, err := strconv.ParseFloat(z, 64); isString && err == nil {
+			if i, ok := floatInt(f); ok {
+				return i
+			}
+		}
+	case "float":
+		switch x := v.(type) {
+		case float64:
+			return x
+		case float32:
+			return float64(x)
+		case int64:
+			return float64(x)
+		}
+		if f, err := strconv.ParseFloat(z, 64); isString && err == nil {
+			return f
+		}
+	case "bool":
+		switch x := v.(type) {
+		case bool:
+			return x
+		case int64:
+			return x != 0
+		}
+		if b, ok := ParseBoolValue(z); isString && ok {
+			return b
+		}
+	case "timestamp":
+		if x, ok := v.(time.Time); ok {
+			return x
+		}
+		if t, ok := ParseTimeValue(z); isString && ok {
+			return t
+		}
+	}
+	return v
+}
+
+// floatInt returns f as an integer, when f has no fractional part and is
+// within the range of an int64.
+func floatInt(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// ParseBoolValue parses the boolean value keywords (ie, t, yes, on) of s.
+func ParseBoolValue(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "1", "t", "true", "y", "yes", "on":
+		return true, true
+	case "0", "f", "false", "n", "no", "off":
+		return false, true
+	}
+	return false, false
+}
+
+// TimeValueLayouts are the time layouts tried when parsing a timestamp value
+// (see ParseTimeValue).
+var TimeValueLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// ParseTimeValue parses s using the first matching layout of
+// TimeValueLayouts.
+func ParseTimeValue(s string) (time.Time, bool) {
+	for _, layout := range TimeValueLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -10,7 +10,9 @@ import (
 	"io"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
@@ -62,6 +64,11 @@ type Driver struct {
 	// UseColumnTypes will cause database's ColumnTypes func to be used for
 	// types.
 	UseColumnTypes bool
+	// MaxInList is the maximum number of values allowed in a IN list, or 0
+	// when there is no limit.
+	MaxInList int
+	// Placeholder will be used by Placeholder if defined.
+	Placeholder func(int) string
 	// ForceParams will be used to force parameters if defined.
 	ForceParams func(*dburl.URL)
 	// Open will be used by Open if defined.
@@ -107,6 +114,36 @@ type Driver struct {
 	NewCompleter func(db DB, opts ...completer.Option) readline.AutoCompleter
 	// Copy rows into the database table
 	Copy func(ctx context.Context, db *sql.DB, rows *sql.Rows, table string) (int64, error)
+	// NearestNeighbors will be used by NearestNeighbors if defined.
+	NearestNeighbors func(table, column, vec string, k int) string
+	// Listen will be used by Listen if defined.
+	Listen func(ctx context.Context, u *dburl.URL, db *sql.DB, channel string, f func(string)) error
+	// ReadOnly will be used by ReadOnly if defined.
+	ReadOnly func(ctx context.Context, db DB) (bool, error)
+	// SetOption will be used by SetOption if defined, returning the
+	// statement setting the session option, or an empty string when the
+	// option is not supported.
+	SetOption func(name, value string) (string, error)
+	// SetApplicationName will be used by SetApplicationName if defined.
+	SetApplicationName func(u *dburl.URL, name string)
+	// Impersonate will be used by Impersonate if defined.
+	Impersonate func(u *dburl.URL, name string) (*Impersonation, error)
+	// Partitions will be used by Partitions if defined.
+	Partitions func(table string) (string, error)
+	// PartitionDDL will be used by PartitionDDL if defined. Returns an empty
+	// string when the action is not supported.
+	PartitionDDL func(action, table, name, from, to string) (string, error)
+	// Sequences will be used by Sequences if defined.
+	Sequences func(pattern string, showSystem bool) (string, error)
+	// FixSequences will be used by FixSequences if defined.
+	FixSequences func(ctx context.Context, db DB, table string) ([]string, error)
+	// ForeignKeys will be used by ForeignKeys if defined. Returns the query
+	// selecting the name, table, referenced table, column, referenced column,
+	// and validated (1 or 0) of the foreign key columns of the table.
+	ForeignKeys func(table string) (string, error)
+	// LimitQuery will be used to limit the rows selected by a query to n,
+	// skipping the first offset rows, if defined.
+	LimitQuery func(sqlstr string, n, offset int) string
 }
 
 // drivers are registered drivers.
@@ -146,6 +183,24 @@ func LowerColumnNames(u *dburl.URL) bool {
 	return false
 }
 
+// MaxInList returns the maximum number of values allowed in a IN list for a
+// driver, or 0 when there is no limit.
+func MaxInList(u *dburl.URL) int {
+	if d, ok := drivers[u.Driver]; ok {
+		return d.MaxInList
+	}
+	return 0
+}
+
+// Placeholder returns the placeholder for the n-th (starting at 1) query
+// parameter for a driver, or ? when the driver does not define one.
+func Placeholder(u *dburl.URL, n int) string {
+	if d, ok := drivers[u.Driver]; ok && d.Placeholder != nil {
+		return d.Placeholder(n)
+	}
+	return "?"
+}
+
 // UseColumnTypes returns whether or not a driver should uses column types.
 func UseColumnTypes(u *dburl.URL) bool {
 	if d, ok := drivers[u.Driver]; ok {
@@ -526,6 +581,73 @@ func Copy(ctx context.Context, u *dburl.URL, stdout, stderr func() io.Writer, ro
 	return d.Copy(ctx, db, rows, table)
 }
 
+// NearestNeighbors returns a query selecting the k nearest neighbors of the
+// vector vec (ie, [1,2,3]) in a table's vector column, ordered by distance.
+func NearestNeighbors(u *dburl.URL, table, column, vec string, k int) (string, error) {
+	d, ok := drivers[u.Driver]
+	if !ok {
+		return "", WrapErr(u.Driver, text.ErrDriverNotAvailable)
+	}
+	if d.NearestNeighbors == nil {
+		return "", fmt.Errorf(text.NotSupportedByDriver, `\knn`, u.Driver)
+	}
+	return d.NearestNeighbors(table, column, vec, k), nil
+}
+
+// ParseVector parses s as a JSON array of numbers (ie, an embedding),
+// returning it formatted as a vector literal (ie, [1,2,3]).
+func ParseVector(s string) (string, bool) {
+	var vec []float64
+	if err := json.Unmarshal([]byte(s), &vec); err != nil || len(vec) == 0 {
+		return "", false
+	}
+	return FormatVector(vec), true
+}
+
+// FormatVector formats vec as a vector literal (ie, [1,2,3]).
+func FormatVector(vec []float64) string {
+	v := make([]string, len(vec))
+	for i, f := range vec {
+		v[i] = strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return "[" + strings.Join(v, ",") + "]"
+}
+
+// Listen listens for notifications on the channel, calling f with the payload
+// of each notification received, until ctx is done.
+func Listen(ctx context.Context, u *dburl.URL, db *sql.DB, channel string, f func(string)) error {
+	d, ok := drivers[u.Driver]
+	if !ok {
+		return WrapErr(u.Driver, text.ErrDriverNotAvailable)
+	}
+	if d.Listen == nil {
+		return fmt.Errorf(text.NotSupportedByDriver, `SCHEMA_CACHE_LISTEN`, u.Driver)
+	}
+	return WrapErr(u.Driver, d.Listen(ctx, u, db, channel, f))
+}
+
+// ReadOnly returns whether the database only accepts read-only transactions
+// (ie, is a standby or replica).
+func ReadOnly(ctx context.Context, u *dburl.URL, db DB) (bool, error) {
+	d, ok := drivers[u.Driver]
+	if !ok {
+		return false, WrapErr(u.Driver, text.ErrDriverNotAvailable)
+	}
+	if d.ReadOnly == nil {
+		return false, fmt.Errorf(text.NotSupportedByDriver, `target_session_attrs`, u.Driver)
+	}
+	ro, err := d.ReadOnly(ctx, db)
+	return ro, WrapErr(u.Driver, err)
+}
+
+// NearestNeighborsByDistance builds a typical nearest neighbors handler
+// ordering by the distance expression returned by f.
+func NearestNeighborsByDistance(f func(column, vec string) string) func(string, string, string, int) string {
+	return func(table, column, vec string, k int) string {
+		return fmt.Sprintf("SELECT *, %s AS distance FROM %s ORDER BY distance LIMIT %d", f(column, vec), table, k)
+	}
+}
+
 // CopyWithInsert builds a typical copy handler based on insert.
 func CopyWithInsert(placeholder func(int) string) func(ctx context.Context, db *sql.DB, rows *sql.Rows, table string) (int64, error) {
 	if placeholder == nil {
@@ -536,14 +658,30 @@ func CopyWithInsert(placeholder func(int) string) func(ctx context.Context, db *
 	}
 }
 
+// FlexibleCopyWithInsert copies the rows into the table using inserts with
+// the placeholder, within a transaction when withTransaction is true.
+//
+// The copy options of the context (see WithCopyOptions) determine the number
+// of rows inserted by each statement, and the number of concurrent insert
+// workers, each inserting with its own connection (and transaction). When
+// using transactions, the transactions are only committed when all rows have
+// been inserted.
+//
+// With more than one worker, the copy is not atomic: each worker's
+// transaction is committed separately, so a failed commit leaves the rows of
+// the workers already committed in the table.
 func FlexibleCopyWithInsert(ctx context.Context, db *sql.DB, rows *sql.Rows, table string, placeholder func(int) string, withTransaction bool) (int64, error) {
 	columns, err := rows.Columns()
 	if err != nil {
 		return 0, fmt.Errorf("failed to fetch source rows columns: %w", err)
 	}
-	clen := len(columns)
-	query := table
-	if !strings.HasPrefix(strings.ToLower(query), "insert into") {
+	opts := CopyOptionsFrom(ctx)
+	clen, batch, workers := len(columns), max(opts.Batch, 1), max(opts.Workers, 1)
+	var insert func(int) string
+	if strings.HasPrefix(strings.ToLower(table), "insert into") {
+		// a custom insert can only insert a single row
+		batch, insert = 1, func(int) string { return table }
+	} else {
 		leftParen := strings.IndexRune(table, '(')
 		if leftParen == -1 {
 			colRows, err := db.QueryContext(ctx, "SELECT * FROM "+table+" WHERE 1=0")
@@ -557,69 +695,163 @@ func FlexibleCopyWithInsert(ctx context.Context, db *sql.DB, rows *sql.Rows, tab
 			}
 			table += "(" + strings.Join(columns, ", ") + ")"
 		}
-		// TODO if the db supports multiple rows per insert, create batches of 100 rows
-		placeholders := make([]string, clen)
-		for i := 0; i < clen; i++ {
-			placeholders[i] = placeholder(i + 1)
-		}
-		query = "INSERT INTO " + table + " VALUES (" + strings.Join(placeholders, ", ") + ")"
-	}
-	var stmt *sql.Stmt
-	var tx *sql.Tx
-	if withTransaction {
-		tx, err = db.BeginTx(ctx, nil)
-		if err != nil {
-			return 0, fmt.Errorf("failed to begin transaction: %w", err)
+		insert = func(n int) string {
+			values := make([]string, n)
+			placeholders := make([]string, clen)
+			for i := range n {
+				for j := range clen {
+					placeholders[j] = placeholder(i*clen + j + 1)
+				}
+				values[i] = "(" + strings.Join(placeholders, ", ") + ")"
+			}
+			return "INSERT INTO " + table + " VALUES " + strings.Join(values, ", ")
 		}
-		stmt, err = tx.PrepareContext(ctx, query)
-	} else {
-		stmt, err = db.PrepareContext(ctx, query)
 	}
-	if err != nil {
-		return 0, fmt.Errorf("failed to prepare insert query: %w", err)
-	}
-	defer stmt.Close()
 	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
 		return 0, fmt.Errorf("failed to fetch source column types: %w", err)
 	}
+	coerce := opts.Coercer(columnTypes)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	// start workers
+	batches := make(chan []interface{}, workers)
+	txs := make([]*sql.Tx, workers)
+	counts := make([]int64, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if txs[w], counts[w], err = copyInsertWorker(ctx, db, batches, insert, batch*clen, clen, withTransaction, opts.Copied); err != nil {
+				cancel(err)
+			}
+		}()
+	}
+	// read rows into batches
 	values := make([]interface{}, clen)
 	valueRefs := make([]reflect.Value, clen)
-	actuals := make([]interface{}, clen)
 	for i := 0; i < len(columnTypes); i++ {
 		valueRefs[i] = reflect.New(columnTypes[i].ScanType())
 		values[i] = valueRefs[i].Interface()
 	}
+	err = func() error {
+		defer close(batches)
+		args := make([]interface{}, 0, batch*clen)
+		for rows.Next() {
+			if err := rows.Scan(values...); err != nil {
+				return fmt.Errorf("failed to scan row: %w", err)
+			}
+			// We can't use values... in Exec(), because some drivers don't
+			// accept pointer to an argument instead of the arg itself.
+			actuals := make([]interface{}, clen)
+			for i := range values {
+				actuals[i] = valueRefs[i].Elem().Interface()
+			}
+			if coerce != nil {
+				coerce(actuals)
+			}
+			if args = append(args, actuals...); len(args) == batch*clen {
+				select {
+				case batches <- args:
+				case <-ctx.Done():
+					return nil
+				}
+				args = make([]interface{}, 0, batch*clen)
+			}
+		}
+		if len(args) != 0 {
+			select {
+			case batches <- args:
+			case <-ctx.Done():
+			}
+		}
+		return rows.Err()
+	}()
+	wg.Wait()
 	var n int64
-	for rows.Next() {
-		err = rows.Scan(values...)
-		if err != nil {
-			return n, fmt.Errorf("failed to scan row: %w", err)
+	for _, c := range counts {
+		n += c
+	}
+	if err == nil {
+		err = context.Cause(ctx)
+	}
+	if err != nil {
+		for _, tx := range txs {
+			if tx != nil {
+				_ = tx.Rollback()
+			}
+		}
+		return n, err
+	}
+	var committed int64
+	for i, tx := range txs {
+		if tx == nil {
+			continue
+		}
+		if err := tx.Commit(); err != nil {
+			for _, tx := range txs[i+1:] {
+				if tx != nil {
+					_ = tx.Rollback()
+				}
+			}
+			if committed != 0 {
+				return committed, fmt.Errorf("failed to commit transaction, %d rows were already committed by other workers: %w", committed, err)
+			}
+			return 0, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		committed += counts[i]
+	}
+	return n, nil
+}
+
+// copyInsertWorker inserts the batches of row values, using the insert
+// statement for the number of rows, preparing the statement for full batches
+// of size values once. Returns the worker's transaction (when withTransaction
+// is true) to be committed or rolled back, and the number of rows inserted.
+func copyInsertWorker(ctx context.Context, db *sql.DB, batches <-chan []interface{}, insert func(int) string, size, clen int, withTransaction bool, count func(int64)) (*sql.Tx, int64, error) {
+	var tx *sql.Tx
+	prepare := db.PrepareContext
+	if withTransaction {
+		var err error
+		if tx, err = db.BeginTx(ctx, nil); err != nil {
+			return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		prepare = tx.PrepareContext
+	}
+	var full *sql.Stmt
+	defer func() {
+		if full != nil {
+			full.Close()
 		}
-		//We can't use values... in Exec() below, because some drivers
-		//don't accept pointer to an argument instead of the arg itself.
-		for i := range values {
-			actuals[i] = valueRefs[i].Elem().Interface()
+	}()
+	var n int64
+	for args := range batches {
+		stmt := full
+		if len(args) != size || full == nil {
+			var err error
+			if stmt, err = prepare(ctx, insert(len(args)/clen)); err != nil {
+				return tx, n, fmt.Errorf("failed to prepare insert query: %w", err)
+			}
+			if len(args) == size {
+				full = stmt
+			} else {
+				defer stmt.Close()
+			}
 		}
-		res, err := stmt.ExecContext(ctx, actuals...)
+		res, err := stmt.ExecContext(ctx, args...)
 		if err != nil {
-			return n, fmt.Errorf("failed to exec insert: %w", err)
+			return tx, n, fmt.Errorf("failed to exec insert: %w", err)
 		}
 		rn, err := res.RowsAffected()
 		if err != nil {
-			return n, fmt.Errorf("failed to check rows affected: %w", err)
+			return tx, n, fmt.Errorf("failed to check rows affected: %w", err)
 		}
 		n += rn
+		count(rn)
 	}
-	// TODO if using batches, flush the last batch,
-	// TODO prepare another statement and count remaining rows
-	if tx != nil {
-		err = tx.Commit()
-		if err != nil {
-			return n, fmt.Errorf("failed to commit transaction: %w", err)
-		}
-	}
-	return n, rows.Err()
+	return tx, n, nil
 }
 
 func init() {
@@ -47,6 +47,16 @@ func (e *Error) Error() string {
 	return e.Driver + ": " + chop(e.Err.Error(), e.Driver)
 }
 
+// Code returns the error code reported by the driver for the wrapped error
+// (ie, the SQLSTATE), if any.
+func (e *Error) Code() string {
+	if d, ok := drivers[e.Driver]; ok && d.Err != nil {
+		code, _ := d.Err(e.Err)
+		return code
+	}
+	return ""
+}
+
 // Unwrap returns the original error.
 func (e *Error) Unwrap() error {
 	return e.Err
@@ -8,10 +8,14 @@ package godror
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 
 	_ "github.com/godror/godror" // DRIVER
+	"github.com/xo/dburl"
+	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/drivers/oracle/orshared"
+	"github.com/xo/usql/text"
 )
 
 func init() {
@@ -56,5 +60,19 @@ func init() {
 			}
 			return false
 		},
+		// impersonate with proxy authentication as user[name]
+		func(u *dburl.URL, name string) (*drivers.Impersonation, error) {
+			if u.User == nil || u.User.Username() == "" {
+				return nil, fmt.Errorf(text.ImpersonateRequiresUser, u.Driver)
+			}
+			v := u.URL
+			user := u.User.Username() + "[" + name + "]"
+			if pass, ok := u.User.Password(); ok {
+				v.User = url.UserPassword(user, pass)
+			} else {
+				v.User = url.User(user)
+			}
+			return &drivers.Impersonation{URL: v.String()}, nil
+		},
 	)
 }
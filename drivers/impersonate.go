@@ -0,0 +1,37 @@
+package drivers
+
+import (
+	"fmt"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/text"
+)
+
+// Impersonation is how a principal is impersonated on a database.
+type Impersonation struct {
+	// Set is the statement impersonating the principal.
+	Set string
+	// Reset is the statement reverting the impersonation.
+	Reset string
+	// URL is the database URL to reconnect with, when the principal is
+	// impersonated using proxy authentication.
+	URL string
+}
+
+// Impersonate returns how the principal name is impersonated for the driver
+// of the database URL, using the driver's Impersonate:
+//
+//	postgres  - SET ROLE name, reverted with RESET ROLE
+//	mysql     - SET ROLE name, reverted with SET ROLE DEFAULT
+//	sqlserver - EXECUTE AS USER = 'name', reverted with REVERT
+//	oracle    - proxy authentication, reconnecting as user[name] (godror), or
+//	            with the PROXY CLIENT NAME option (go-ora)
+func Impersonate(u *dburl.URL, name string) (*Impersonation, error) {
+	if name == "" {
+		return nil, text.ErrMissingRequiredArgument
+	}
+	if d, ok := drivers[u.Driver]; ok && d.Impersonate != nil {
+		return d.Impersonate(u, name)
+	}
+	return nil, fmt.Errorf(text.NotSupportedByDriver, `\su`, u.Driver)
+}
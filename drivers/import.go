@@ -0,0 +1,745 @@
+package drivers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/text"
+)
+
+// ImportFormats are the formats of files imported with Import.
+var ImportFormats = []string{"csv", "tsv", "json", "parquet"}
+
+// ImportOptions are the options of an import of a file into a table.
+type ImportOptions struct {
+	// Format is the format of the file (see ImportFormats). Detected from the
+	// file's extension when empty.
+	Format string
+	// Delimiter is the field delimiter of csv files. Sniffed from the file
+	// when 0.
+	Delimiter rune
+	// NoHeader is whether the first row of csv files is data, instead of the
+	// column names.
+	NoHeader bool
+	// Create is whether to create the table, with the inferred column types.
+	Create bool
+}
+
+// ImportFormat returns the import format of the file name, from its
+// extension.
+func ImportFormat(name string) (string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv", ".txt":
+		return "csv", nil
+	case ".tsv", ".tab":
+		return "tsv", nil
+	case ".json", ".jsonl", ".ndjson":
+		return "json", nil
+	case ".parquet", ".pq":
+		return "parquet", nil
+	}
+	return "", fmt.Errorf(text.UnknownImportFormat, name)
+}
+
+// ImportTable returns the default table name for the imported file name (ie,
+// the file's base name without its extension), quoted for the driver of the
+// database URL.
+func ImportTable(u *dburl.URL, name string) string {
+	s := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	if s = importIdentRE.ReplaceAllString(s, "_"); s == "" || s[0] >= '0' && s[0] <= '9' {
+		s = "t_" + s
+	}
+	return importIdent(u, strings.ToLower(s))
+}
+
+// importIdentRE matches the characters replaced in table names.
+var importIdentRE = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// Import imports the file name into the table on the database URL, returning
+// the number of imported rows. The rows are copied using the driver's copy
+// (see Copy), with the copy options of the context (see WithCopyOptions).
+//
+// Column types are inferred from the first rows of csv and json files (see
+// CopyTypes), with columns of json arrays of numbers (ie, embeddings)
+// imported as vectors, as are the lists of numbers of parquet files.
+// Delimiters of csv files are sniffed, and files encoded as UTF-16 (with a
+// byte order mark) or Latin-1 are decoded. Json files are either an array of
+// objects or newline delimited objects.
+func Import(ctx context.Context, u *dburl.URL, stdout, stderr func() io.Writer, name, table string, opts ImportOptions) (int64, error) {
+	d, ok := drivers[u.Driver]
+	if !ok {
+		return 0, WrapErr(u.Driver, text.ErrDriverNotAvailable)
+	}
+	if d.Copy == nil {
+		return 0, fmt.Errorf(text.NotSupportedByDriver, `\import`, u.Driver)
+	}
+	r, err := openImport(name, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer r.close()
+	db, err := Open(ctx, u, stdout, stderr)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	if opts.Create {
+		if _, err := db.ExecContext(ctx, ImportDDL(u, table, r.columns, r.kinds)); err != nil {
+			return 0, WrapErr(u.Driver, err)
+		}
+	}
+	src := sql.OpenDB(importConnector{r})
+	defer src.Close()
+	rows, err := src.QueryContext(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	columns := make([]string, len(r.columns))
+	for i, column := range r.columns {
+		columns[i] = importIdent(u, column)
+	}
+	return d.Copy(ctx, db, rows, table+"("+strings.Join(columns, ", ")+")")
+}
+
+// ImportDDL returns the CREATE TABLE statement for the imported columns of
+// the kinds (see CopyTypes), for the driver of the database URL.
+func ImportDDL(u *dburl.URL, table string, columns, kinds []string) string {
+	defs := make([]string, len(columns))
+	for i, column := range columns {
+		defs[i] = importIdent(u, column) + " " + importType(u, kinds[i])
+	}
+	return "CREATE TABLE " + table + " (" + strings.Join(defs, ", ") + ")"
+}
+
+// importType returns the column type of the kind (see CopyTypes, and vector
+// for embeddings) for the driver of the database URL. Embeddings are imported
+// as pgvector vectors on PostgreSQL, and otherwise as text (ie, for
+// sqlite-vss).
+func importType(u *dburl.URL, kind string) string {
+	if kind == "vector" {
+		switch u.Driver {
+		case "postgres", "pgx":
+			// pgvector
+			return "vector"
+		}
+		kind = "text"
+	}
+	switch u.Driver {
+	case "sqlserver":
+		return map[string]string{
+			"bool": "BIT", "bytes": "VARBINARY(MAX)", "float": "FLOAT",
+			"int": "BIGINT", "text": "NVARCHAR(MAX)", "timestamp": "DATETIME2",
+		}[kind]
+	case "godror", "oracle":
+		return map[string]string{
+			"bool": "NUMBER(1)", "bytes": "BLOB", "float": "BINARY_DOUBLE",
+			"int": "NUMBER(19)", "text": "VARCHAR2(4000)", "timestamp": "TIMESTAMP",
+		}[kind]
+	case "mysql", "mymysql":
+		return map[string]string{
+			"bool": "BOOLEAN", "bytes": "LONGBLOB", "float": "DOUBLE",
+			"int": "BIGINT", "text": "TEXT", "timestamp": "DATETIME(6)",
+		}[kind]
+	case "sqlite3", "moderncsqlite":
+		return map[string]string{
+			"bool": "BOOLEAN", "bytes": "BLOB", "float": "REAL",
+			"int": "INTEGER", "text": "TEXT", "timestamp": "TIMESTAMP",
+		}[kind]
+	case "postgres", "pgx":
+		return map[string]string{
+			"bool": "BOOLEAN", "bytes": "BYTEA", "float": "DOUBLE PRECISION",
+			"int": "BIGINT", "text": "TEXT", "timestamp": "TIMESTAMP",
+		}[kind]
+	}
+	return map[string]string{
+		"bool": "BOOLEAN", "bytes": "BLOB", "float": "DOUBLE PRECISION",
+		"int": "BIGINT", "text": "TEXT", "timestamp": "TIMESTAMP",
+	}[kind]
+}
+
+// importIdent quotes the generated identifier name for the driver of the
+// database URL. Generated identifiers are always quoted, as file headers are
+// often reserved words (ie, order, user, date).
+func importIdent(u *dburl.URL, name string) string {
+	switch u.Driver {
+	case "mysql", "mymysql", "clickhouse":
+		return QuoteIdent(name, '`')
+	}
+	return QuoteIdent(name, '"')
+}
+
+// importSample is the number of rows read to infer column types.
+const importSample = 1000
+
+// importReader reads the rows of an imported file.
+type importReader struct {
+	// columns are the column names.
+	columns []string
+	// kinds are the column types (see CopyTypes).
+	kinds []string
+	// next reads the next row, returning io.EOF after the last row.
+	next func() ([]interface{}, error)
+	// close closes the file.
+	close func() error
+}
+
+// openImport opens the file name for import.
+func openImport(name string, opts ImportOptions) (*importReader, error) {
+	format := opts.Format
+	if format == "" {
+		var err error
+		if format, err = ImportFormat(name); err != nil {
+			return nil, err
+		}
+	}
+	if format == "parquet" {
+		return openParquet(name)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReaderSize(decodeReader(f), 64*1024)
+	var columns []string
+	var next func() ([]interface{}, error)
+	switch format {
+	case "csv", "tsv":
+		delim := opts.Delimiter
+		switch {
+		case delim == 0 && format == "tsv":
+			delim = '\t'
+		case delim == 0:
+			delim = sniffDelimiter(br)
+		}
+		columns, next, err = readCSV(br, delim, opts.NoHeader)
+	case "json":
+		columns, next, err = readJSON(br)
+	default:
+		err = fmt.Errorf(text.InvalidImportFormat, format, strings.Join(ImportFormats, ", "))
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	r, err := inferImport(columns, next)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	r.close = f.Close
+	return r, nil
+}
+
+// inferImport returns an import reader for the columns of the rows read by
+// next, with the column types inferred from the first rows. Values are nil or
+// strings, and are coerced to the inferred column types. Reading a value that
+// cannot be coerced to its column's inferred type (ie, 1.5 in an int column)
+// fails, instead of silently importing a different value.
+func inferImport(columns []string, next func() ([]interface{}, error)) (*importReader, error) {
+	var sample [][]interface{}
+	for len(sample) < importSample {
+		row, err := next()
+		switch {
+		case errors.Is(err, io.EOF):
+		case err != nil:
+			return nil, err
+		default:
+			sample = append(sample, row)
+			continue
+		}
+		break
+	}
+	kinds := make([]string, len(columns))
+	for i := range columns {
+		mask, seen := kindAll, false
+		for _, row := range sample {
+			if s, ok := row[i].(string); ok {
+				mask, seen = mask&inferKinds(s), true
+			}
+		}
+		switch {
+		case !seen:
+			kinds[i] = "text"
+		case mask&kindInt != 0:
+			kinds[i] = "int"
+		case mask&kindFloat != 0:
+			kinds[i] = "float"
+		case mask&kindBool != 0:
+			kinds[i] = "bool"
+		case mask&kindTimestamp != 0:
+			kinds[i] = "timestamp"
+		case mask&kindVector != 0:
+			kinds[i] = "vector"
+		default:
+			kinds[i] = "text"
+		}
+	}
+	var n int
+	return &importReader{
+		columns: columns,
+		kinds:   kinds,
+		next: func() ([]interface{}, error) {
+			var row []interface{}
+			if len(sample) != 0 {
+				row, sample = sample[0], sample[1:]
+			} else {
+				var err error
+				if row, err = next(); err != nil {
+					return nil, err
+				}
+			}
+			n++
+			for i, v := range row {
+				if v == nil {
+					continue
+				}
+				var ok bool
+				if row[i], ok = importValue(v, kinds[i]); !ok {
+					return nil, fmt.Errorf(text.ImportValueMismatch, n, columns[i], v, kinds[i], importSample)
+				}
+			}
+			return row, nil
+		},
+	}, nil
+}
+
+// importValue coerces the string value v of an imported file to the kind,
+// returning false when v cannot be coerced. Embeddings (the vector kind) are
+// formatted as vector literals (ie, [1,2,3]).
+func importValue(v interface{}, kind string) (interface{}, bool) {
+	switch kind {
+	case "text":
+		return v, true
+	case "vector":
+		s, _ := v.(string)
+		return ParseVector(s)
+	}
+	v = coerceValue(v, kind)
+	_, isString := v.(string)
+	return v, !isString
+}
+
+// kind bits.
+const (
+	kindInt = 1 << iota
+	kindFloat
+	kindBool
+	kindTimestamp
+	kindVector
+	kindAll = kindInt | kindFloat | kindBool | kindTimestamp | kindVector
+)
+
+// inferKinds returns the kinds the value s can be coerced to.
+func inferKinds(s string) int {
+	var mask int
+	s = strings.TrimSpace(s)
+	// numbers with leading zeros (ie, zip codes) are text, as are NaN and Inf
+	t := strings.TrimLeft(s, "+-")
+	number := t != "" && (t[0] >= '0' && t[0] <= '9' || t[0] == '.') &&
+		!(len(t) > 1 && t[0] == '0' && t[1] >= '0' && t[1] <= '9')
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil && number {
+		mask |= kindInt
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil && number {
+		mask |= kindFloat
+	}
+	switch strings.ToLower(s) {
+	case "true", "false":
+		mask |= kindBool
+	}
+	if _, ok := coerceValue(s, "timestamp").(time.Time); ok {
+		mask |= kindTimestamp
+	}
+	// json arrays of numbers (ie, embeddings)
+	if _, ok := ParseVector(s); ok && strings.HasPrefix(s, "[") {
+		mask |= kindVector
+	}
+	return mask
+}
+
+// readCSV reads the columns and returns the func reading the rows of a csv
+// file with the field delimiter. Empty fields are nil.
+func readCSV(r io.Reader, delim rune, noHeader bool) ([]string, func() ([]interface{}, error), error) {
+	cr := csv.NewReader(r)
+	cr.Comma, cr.LazyQuotes, cr.FieldsPerRecord = delim, true, -1
+	first, err := cr.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := make([]string, len(first))
+	for i, name := range first {
+		if name = strings.TrimSpace(name); noHeader || name == "" {
+			name = "column" + strconv.Itoa(i+1)
+		}
+		columns[i] = name
+	}
+	row := func(record []string) []interface{} {
+		row := make([]interface{}, len(columns))
+		for i := 0; i < len(record) && i < len(row); i++ {
+			if record[i] != "" {
+				row[i] = record[i]
+			}
+		}
+		return row
+	}
+	return columns, func() ([]interface{}, error) {
+		if noHeader && first != nil {
+			record := first
+			first = nil
+			return row(record), nil
+		}
+		record, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		return row(record), nil
+	}, nil
+}
+
+// sniffDelimiter returns the field delimiter (comma, tab, semicolon, or pipe)
+// occurring the same, and most, times outside of quotes on each of the first
+// lines buffered in r. Defaults to comma.
+func sniffDelimiter(r *bufio.Reader) rune {
+	buf, _ := r.Peek(16 * 1024)
+	lines := strings.Split(strings.ReplaceAll(string(buf), "\r\n", "\n"), "\n")
+	if len(lines) > 1 {
+		// drop the last, possibly partial, line
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > 20 {
+		lines = lines[:20]
+	}
+	delim, best := ',', 0
+	for _, c := range []rune{',', '\t', ';', '|'} {
+		n := -1
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			count, quoted := 0, false
+			for _, r := range line {
+				switch {
+				case r == '"':
+					quoted = !quoted
+				case r == c && !quoted:
+					count++
+				}
+			}
+			if n != -1 && count != n {
+				n = 0
+				break
+			}
+			n = count
+		}
+		if n > best {
+			delim, best = c, n
+		}
+	}
+	return delim
+}
+
+// readJSON reads the columns and returns the func reading the rows of a json
+// file, containing either an array of objects or newline delimited objects.
+// The columns are the keys of the first objects, in order. Values are nil or
+// strings, with nested objects and arrays as json.
+func readJSON(r *bufio.Reader) ([]string, func() ([]interface{}, error), error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	// an array of objects
+	if b, err := peekNonSpace(r); err == nil && b == '[' {
+		if _, err := dec.Token(); err != nil {
+			return nil, nil, err
+		}
+	}
+	next := func() ([]string, map[string]interface{}, error) {
+		if !dec.More() {
+			return nil, nil, io.EOF
+		}
+		var obj json.RawMessage
+		if err := dec.Decode(&obj); err != nil {
+			return nil, nil, err
+		}
+		return decodeObject(obj)
+	}
+	var columns []string
+	index := make(map[string]bool)
+	var sample []map[string]interface{}
+	for len(sample) < importSample {
+		keys, m, err := next()
+		switch {
+		case errors.Is(err, io.EOF):
+		case err != nil:
+			return nil, nil, err
+		default:
+			for _, key := range keys {
+				if !index[key] {
+					columns, index[key] = append(columns, key), true
+				}
+			}
+			sample = append(sample, m)
+			continue
+		}
+		break
+	}
+	if len(columns) == 0 {
+		return nil, nil, text.ErrNoImportColumns
+	}
+	row := func(m map[string]interface{}) []interface{} {
+		row := make([]interface{}, len(columns))
+		for i, column := range columns {
+			row[i] = m[column]
+		}
+		return row
+	}
+	return columns, func() ([]interface{}, error) {
+		if len(sample) != 0 {
+			m := sample[0]
+			sample = sample[1:]
+			return row(m), nil
+		}
+		_, m, err := next()
+		if err != nil {
+			return nil, err
+		}
+		return row(m), nil
+	}, nil
+}
+
+// decodeObject decodes a json object, returning its keys in order and its
+// values as nil or strings.
+func decodeObject(obj json.RawMessage) ([]string, map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(obj))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil {
+		return nil, nil, err
+	} else if tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf(text.InvalidImportObject, string(obj))
+	}
+	var keys []string
+	m := make(map[string]interface{})
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := tok.(string)
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		switch x := v.(type) {
+		case nil:
+		case string:
+			m[key] = x
+		case json.Number:
+			m[key] = x.String()
+		case bool:
+			m[key] = strconv.FormatBool(x)
+		default:
+			buf, err := json.Marshal(x)
+			if err != nil {
+				return nil, nil, err
+			}
+			m[key] = string(buf)
+		}
+		keys = append(keys, key)
+	}
+	return keys, m, nil
+}
+
+// peekNonSpace returns the first non-whitespace byte buffered in r.
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for n := 1; ; n++ {
+		buf, err := r.Peek(n)
+		if err != nil {
+			return 0, err
+		}
+		if b := buf[n-1]; b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, nil
+		}
+	}
+}
+
+// decodeReader returns a reader decoding r to UTF-8. Strips UTF-8 byte order
+// marks, decodes UTF-16 with a byte order mark, and decodes Latin-1 when the
+// start of r is not valid UTF-8.
+func decodeReader(r io.Reader) io.Reader {
+	br := bufio.NewReaderSize(r, 64*1024)
+	bom, _ := br.Peek(3)
+	switch {
+	case bytes.HasPrefix(bom, []byte{0xef, 0xbb, 0xbf}):
+		_, _ = br.Discard(3)
+		return br
+	case bytes.HasPrefix(bom, []byte{0xff, 0xfe}):
+		_, _ = br.Discard(2)
+		return &transcoder{r: br, next: utf16Rune(binary.LittleEndian)}
+	case bytes.HasPrefix(bom, []byte{0xfe, 0xff}):
+		_, _ = br.Discard(2)
+		return &transcoder{r: br, next: utf16Rune(binary.BigEndian)}
+	}
+	buf, _ := br.Peek(64 * 1024)
+	// ignore a partial rune at the end of the buffer
+	for i := len(buf) - 1; i >= 0 && i > len(buf)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(buf[i]) {
+			if !utf8.FullRune(buf[i:]) {
+				buf = buf[:i]
+			}
+			break
+		}
+	}
+	if utf8.Valid(buf) {
+		return br
+	}
+	return &transcoder{r: br, next: func(r *bufio.Reader) (rune, error) {
+		b, err := r.ReadByte()
+		return rune(b), err
+	}}
+}
+
+// utf16Rune returns a func reading a UTF-16 rune with the byte order.
+func utf16Rune(order binary.ByteOrder) func(*bufio.Reader) (rune, error) {
+	var buf [2]byte
+	read := func(r *bufio.Reader) (rune, error) {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, io.EOF
+		}
+		return rune(order.Uint16(buf[:])), nil
+	}
+	return func(r *bufio.Reader) (rune, error) {
+		c, err := read(r)
+		if err != nil || !utf16.IsSurrogate(c) {
+			return c, err
+		}
+		d, err := read(r)
+		if err != nil {
+			return utf8.RuneError, nil
+		}
+		return utf16.DecodeRune(c, d), nil
+	}
+}
+
+// transcoder is a reader encoding the runes read by next as UTF-8.
+type transcoder struct {
+	r    *bufio.Reader
+	next func(*bufio.Reader) (rune, error)
+	buf  []byte
+}
+
+// Read satisfies the io.Reader interface.
+func (t *transcoder) Read(p []byte) (int, error) {
+	for len(t.buf) < len(p) {
+		c, err := t.next(t.r)
+		if err != nil {
+			if len(t.buf) == 0 {
+				return 0, err
+			}
+			break
+		}
+		t.buf = utf8.AppendRune(t.buf, c)
+	}
+	n := copy(p, t.buf)
+	t.buf = t.buf[:copy(t.buf, t.buf[n:])]
+	return n, nil
+}
+
+// importConnector is a database connector returning the rows of an imported
+// file as the result of any query, to copy the rows with a driver's copy.
+type importConnector struct {
+	r *importReader
+}
+
+// Connect satisfies the driver.Connector interface.
+func (c importConnector) Connect(context.Context) (driver.Conn, error) {
+	return importConn(c), nil
+}
+
+// Driver satisfies the driver.Connector interface.
+func (c importConnector) Driver() driver.Driver {
+	return c
+}
+
+// Open satisfies the driver.Driver interface.
+func (c importConnector) Open(string) (driver.Conn, error) {
+	return importConn(c), nil
+}
+
+// importConn is a connection of an import connector.
+type importConn struct {
+	r *importReader
+}
+
+// QueryContext satisfies the driver.QueryerContext interface.
+func (c importConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return importRows(c), nil
+}
+
+// Prepare satisfies the driver.Conn interface.
+func (c importConn) Prepare(string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+// Close satisfies the driver.Conn interface.
+func (c importConn) Close() error {
+	return nil
+}
+
+// Begin satisfies the driver.Conn interface.
+func (c importConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+// importRows are the rows of an imported file.
+type importRows struct {
+	r *importReader
+}
+
+// Columns satisfies the driver.Rows interface.
+func (r importRows) Columns() []string {
+	return r.r.columns
+}
+
+// ColumnTypeDatabaseTypeName satisfies the
+// driver.RowsColumnTypeDatabaseTypeName interface, returning the column's
+// kind (ie, INT), for matching copy casts.
+func (r importRows) ColumnTypeDatabaseTypeName(i int) string {
+	return strings.ToUpper(r.r.kinds[i])
+}
+
+// Close satisfies the driver.Rows interface.
+func (r importRows) Close() error {
+	return nil
+}
+
+// Next satisfies the driver.Rows interface.
+func (r importRows) Next(dest []driver.Value) error {
+	row, err := r.r.next()
+	if err != nil {
+		return err
+	}
+	for i := range dest {
+		dest[i] = nil
+		if i < len(row) {
+			dest[i] = row[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package drivers
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInferKinds(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp int
+	}{
+		{"", 0},
+		{"abc", 0},
+		{"42", kindInt | kindFloat},
+		{" -42 ", kindInt | kindFloat},
+		{"4.2", kindFloat},
+		{".5", kindFloat},
+		{"1e3", kindFloat},
+		{"0", kindInt | kindFloat},
+		{"0.5", kindFloat},
+		{"01234", 0},
+		{"NaN", 0},
+		{"Inf", 0},
+		{"true", kindBool},
+		{"FALSE", kindBool},
+		{"yes", 0},
+		{"2026-01-02", kindTimestamp},
+		{"2026-01-02T03:04:05Z", kindTimestamp},
+		{"[1,2.5,3]", kindVector},
+		{"[]", 0},
+		{`["a"]`, 0},
+	}
+	for i, test := range tests {
+		if mask := inferKinds(test.s); mask != test.exp {
+			t.Errorf("test %d %q expected %b, got: %b", i, test.s, test.exp, mask)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		s        string
+		delim    rune
+		noHeader bool
+		columns  []string
+		rows     [][]interface{}
+	}{
+		{"a,b\n1,2\n", ',', false, []string{"a", "b"}, [][]interface{}{{"1", "2"}}},
+		{"a,b\n1,\n,2\n", ',', false, []string{"a", "b"}, [][]interface{}{{"1", nil}, {nil, "2"}}},
+		{" a ,,c\n1,2,3\n", ',', false, []string{"a", "column2", "c"}, [][]interface{}{{"1", "2", "3"}}},
+		{"a,b\n1\n1,2,3\n", ',', false, []string{"a", "b"}, [][]interface{}{{"1", nil}, {"1", "2"}}},
+		{"1;2\n3;4\n", ';', true, []string{"column1", "column2"}, [][]interface{}{{"1", "2"}, {"3", "4"}}},
+		{"a\tb\n\"x\ty\"\tz\n", '\t', false, []string{"a", "b"}, [][]interface{}{{"x\ty", "z"}}},
+		{"a,b\n", ',', false, []string{"a", "b"}, nil},
+	}
+	for i, test := range tests {
+		columns, next, err := readCSV(strings.NewReader(test.s), test.delim, test.noHeader)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if !reflect.DeepEqual(columns, test.columns) {
+			t.Errorf("test %d expected columns %q, got: %q", i, test.columns, columns)
+		}
+		var rows [][]interface{}
+		for {
+			row, err := next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("test %d expected no error, got: %v", i, err)
+			}
+			rows = append(rows, row)
+		}
+		if !reflect.DeepEqual(rows, test.rows) {
+			t.Errorf("test %d expected rows %v, got: %v", i, test.rows, rows)
+		}
+	}
+}
+
+func TestSniffDelimiter(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp rune
+	}{
+		{"", ','},
+		{"a\n", ','},
+		{"a,b,c\n1,2,3\n", ','},
+		{"a\tb\tc\n1\t2\t3\n", '\t'},
+		{"a;b\r\n1;2\r\n", ';'},
+		{"a|b|c\n1|2|3\n", '|'},
+		{"a;b,c\n1;2,3\n", ','},
+		{"a;b;c\n1;\"2;3\";4\n", ';'},
+		{"a,b;c\n1,2,3;4\n", ';'},
+		{"a;b\n1;2\n3;4;5", ';'},
+		{"a;b\n\n1;2\n", ';'},
+	}
+	for i, test := range tests {
+		if delim := sniffDelimiter(bufio.NewReader(strings.NewReader(test.s))); delim != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, delim)
+		}
+	}
+}
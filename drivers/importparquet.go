@@ -0,0 +1,160 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/apache/arrow/go/v17/arrow"
+	"github.com/apache/arrow/go/v17/arrow/array"
+	"github.com/apache/arrow/go/v17/arrow/memory"
+	"github.com/apache/arrow/go/v17/parquet/file"
+	"github.com/apache/arrow/go/v17/parquet/pqarrow"
+)
+
+// openParquet opens the parquet file name for import, with the column types
+// of the file's schema.
+func openParquet(name string) (*importReader, error) {
+	pf, err := file.OpenParquetFile(name, false)
+	if err != nil {
+		return nil, err
+	}
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{BatchSize: 1024}, memory.DefaultAllocator)
+	if err != nil {
+		pf.Close()
+		return nil, err
+	}
+	rr, err := fr.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		pf.Close()
+		return nil, err
+	}
+	schema := rr.Schema()
+	columns, kinds := make([]string, schema.NumFields()), make([]string, schema.NumFields())
+	for i, field := range schema.Fields() {
+		columns[i], kinds[i] = field.Name, parquetKind(field.Type)
+	}
+	var rec arrow.Record
+	var pos int
+	return &importReader{
+		columns: columns,
+		kinds:   kinds,
+		next: func() ([]interface{}, error) {
+			for rec == nil || pos >= int(rec.NumRows()) {
+				if !rr.Next() {
+					if err := rr.Err(); err != nil && !errors.Is(err, io.EOF) {
+						return nil, err
+					}
+					return nil, io.EOF
+				}
+				rec, pos = rr.Record(), 0
+			}
+			row := make([]interface{}, len(columns))
+			for i := range row {
+				row[i] = parquetValue(rec.Column(i), pos)
+			}
+			pos++
+			return row, nil
+		},
+		close: func() error {
+			rr.Release()
+			return pf.Close()
+		},
+	}, nil
+}
+
+// parquetKind returns the kind (see CopyTypes, and vector for lists of
+// numbers) of an arrow type.
+func parquetKind(typ arrow.DataType) string {
+	switch typ.ID() {
+	case arrow.BOOL:
+		return "bool"
+	case arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64,
+		arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64:
+		return "int"
+	case arrow.FLOAT16, arrow.FLOAT32, arrow.FLOAT64:
+		return "float"
+	case arrow.DATE32, arrow.DATE64, arrow.TIMESTAMP:
+		return "timestamp"
+	case arrow.BINARY, arrow.LARGE_BINARY, arrow.FIXED_SIZE_BINARY:
+		return "bytes"
+	case arrow.LIST, arrow.LARGE_LIST, arrow.FIXED_SIZE_LIST:
+		// lists of numbers (ie, embeddings)
+		if elem, ok := typ.(arrow.ListLikeType); ok {
+			if kind := parquetKind(elem.Elem()); kind == "int" || kind == "float" {
+				return "vector"
+			}
+		}
+	}
+	// strings, decimals, and nested types
+	return "text"
+}
+
+// parquetValue returns the value of an arrow array at i.
+func parquetValue(arr arrow.Array, i int) interface{} {
+	if arr.IsNull(i) {
+		return nil
+	}
+	switch a := arr.(type) {
+	case *array.Boolean:
+		return a.Value(i)
+	case *array.Int8:
+		return int64(a.Value(i))
+	case *array.Int16:
+		return int64(a.Value(i))
+	case *array.Int32:
+		return int64(a.Value(i))
+	case *array.Int64:
+		return a.Value(i)
+	case *array.Uint8:
+		return int64(a.Value(i))
+	case *array.Uint16:
+		return int64(a.Value(i))
+	case *array.Uint32:
+		return int64(a.Value(i))
+	case *array.Uint64:
+		return int64(a.Value(i))
+	case *array.Float16:
+		return float64(a.Value(i).Float32())
+	case *array.Float32:
+		return float64(a.Value(i))
+	case *array.Float64:
+		return a.Value(i)
+	case *array.String:
+		return a.Value(i)
+	case *array.LargeString:
+		return a.Value(i)
+	case *array.Binary:
+		return append([]byte(nil), a.Value(i)...)
+	case *array.LargeBinary:
+		return append([]byte(nil), a.Value(i)...)
+	case *array.FixedSizeBinary:
+		return append([]byte(nil), a.Value(i)...)
+	case *array.Date32:
+		return a.Value(i).ToTime()
+	case *array.Date64:
+		return a.Value(i).ToTime()
+	case *array.Timestamp:
+		typ := a.DataType().(*arrow.TimestampType)
+		return a.Value(i).ToTime(typ.Unit).In(time.UTC)
+	case array.ListLike:
+		if parquetKind(a.DataType()) == "vector" {
+			start, end := a.ValueOffsets(i)
+			vec := make([]float64, 0, end-start)
+			for j := start; j < end; j++ {
+				switch v := parquetValue(a.ListValues(), int(j)).(type) {
+				case int64:
+					vec = append(vec, float64(v))
+				case float64:
+					vec = append(vec, v)
+				default:
+					// null elements
+					return arr.ValueStr(i)
+				}
+			}
+			return FormatVector(vec)
+		}
+	}
+	return arr.ValueStr(i)
+}
@@ -0,0 +1,317 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+// Cache is a on-disk cache of database metadata, used to serve metadata for
+// completion and describe commands without introspecting the database.
+//
+// Cached metadata is returned until it is older than the cache's TTL. When
+// reading fresh metadata fails (for example, on a flaky connection), expired
+// metadata is returned instead, and is marked as stale.
+//
+// Metadata is cached per filter, and as such is only read for the schemas (and
+// objects) actually used. The cache file may be shared by multiple running
+// instances, and is merged with the metadata cached by other instances when
+// saved.
+type Cache struct {
+	path    string
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	pending map[string]bool
+	stale   time.Time
+}
+
+// cacheEntry is a cached metadata result set.
+type cacheEntry struct {
+	Time    time.Time       `json:"time"`
+	Schema  string          `json:"schema,omitempty"`
+	Results json.RawMessage `json:"results"`
+}
+
+// NewCache creates a metadata cache stored in the file path, loading any
+// previously cached metadata.
+func NewCache(path string, ttl time.Duration) *Cache {
+	c := &Cache{
+		path:    path,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+		pending: make(map[string]bool),
+	}
+	if buf, err := os.ReadFile(path); err == nil {
+		// ignore a corrupt cache, as it is rewritten on the next read
+		_ = json.Unmarshal(buf, &c.entries)
+	}
+	return c
+}
+
+// Refresh clears the cache, forcing metadata to be read from the database.
+func (c *Cache) Refresh() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries, c.stale = make(map[string]cacheEntry), time.Time{}
+	unlock, err := env.LockFile(c.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Invalidate removes the cached metadata for the schemas from the cache, so
+// that it is read again on next use. Cached metadata not specific to a schema
+// (or matching a schema pattern) is also removed. When no schemas are
+// specified, all cached metadata is removed.
+func (c *Cache) Invalidate(schemas ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	invalid := func(e cacheEntry) bool {
+		return len(schemas) == 0 || e.Schema == "" || strings.Contains(e.Schema, "%") || slices.Contains(schemas, e.Schema)
+	}
+	for k, e := range c.entries {
+		if invalid(e) {
+			delete(c.entries, k)
+		}
+	}
+	return c.save(invalid)
+}
+
+// Reader wraps the reader r, serving metadata from the cache.
+func (c *Cache) Reader(r Reader) Reader {
+	return c.reader(r, false)
+}
+
+// BackgroundReader wraps the reader r, serving metadata from the cache. Unlike
+// Reader, expired metadata is returned immediately, and is read again in the
+// background.
+func (c *Cache) BackgroundReader(r Reader) Reader {
+	return c.reader(r, true)
+}
+
+// reader wraps the reader r, serving metadata from the cache.
+func (c *Cache) reader(r Reader, background bool) Reader {
+	p := NewPluginReader(r).(*PluginReader)
+	p.catalogs = cacheFunc(c, "catalogs", background, p.catalogs, func(s *CatalogSet) []Result { return s.results }, NewCatalogSet)
+	p.schemas = cacheFunc(c, "schemas", background, p.schemas, func(s *SchemaSet) []Result { return s.results }, NewSchemaSet)
+	p.tables = cacheFunc(c, "tables", background, p.tables, func(s *TableSet) []Result { return s.results }, NewTableSet)
+	p.columns = cacheFunc(c, "columns", background, p.columns, func(s *ColumnSet) []Result { return s.results }, NewColumnSet)
+	p.columnStats = cacheFunc(c, "columnStats", background, p.columnStats, func(s *ColumnStatSet) []Result { return s.results }, NewColumnStatSet)
+	p.indexes = cacheFunc(c, "indexes", background, p.indexes, func(s *IndexSet) []Result { return s.results }, NewIndexSet)
+	p.indexColumns = cacheFunc(c, "indexColumns", background, p.indexColumns, func(s *IndexColumnSet) []Result { return s.results }, NewIndexColumnSet)
+	p.triggers = cacheFunc(c, "triggers", background, p.triggers, func(s *TriggerSet) []Result { return s.results }, NewTriggerSet)
+	p.constraints = cacheFunc(c, "constraints", background, p.constraints, func(s *ConstraintSet) []Result { return s.results }, NewConstraintSet)
+	p.constraintColumns = cacheFunc(c, "constraintColumns", background, p.constraintColumns, func(s *ConstraintColumnSet) []Result { return s.results }, NewConstraintColumnSet)
+	p.functions = cacheFunc(c, "functions", background, p.functions, func(s *FunctionSet) []Result { return s.results }, NewFunctionSet)
+	p.functionColumns = cacheFunc(c, "functionColumns", background, p.functionColumns, func(s *FunctionColumnSet) []Result { return s.results }, NewFunctionColumnSet)
+	p.sequences = cacheFunc(c, "sequences", background, p.sequences, func(s *SequenceSet) []Result { return s.results }, NewSequenceSet)
+	p.privilegeSummaries = cacheFunc(c, "privilegeSummaries", background, p.privilegeSummaries, func(s *PrivilegeSummarySet) []Result { return s.results }, NewPrivilegeSummarySet)
+	return p
+}
+
+// Writer wraps the writer w, serving metadata from the cache. Writers other
+// than the default writer are returned as is.
+//
+// After each command, a notice is written to the writer's output when stale
+// metadata was used.
+func (c *Cache) Writer(w Writer) Writer {
+	dw, ok := w.(*DefaultWriter)
+	if !ok {
+		return w
+	}
+	dw.r = c.Reader(dw.r)
+	return cachedWriter{Writer: dw, c: c, w: dw.w}
+}
+
+// cacheFunc wraps the read func of a reader, serving metadata from the cache.
+func cacheFunc[T any, S any](c *Cache, name string, background bool, read func(Filter) (S, error), results func(S) []Result, newSet func([]T) S) func(Filter) (S, error) {
+	if read == nil {
+		return nil
+	}
+	// store reads the metadata using the filter, storing it in the cache.
+	store := func(k string, f Filter) (S, error) {
+		s, err := read(f)
+		if err != nil {
+			return s, err
+		}
+		res := results(s)
+		v := make([]T, len(res))
+		for i, r := range res {
+			v[i] = *any(r).(*T)
+		}
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return s, nil
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.entries[k] = cacheEntry{Time: time.Now(), Schema: f.Schema, Results: buf}
+		// a cache that cannot be saved is kept in memory
+		_ = c.save(nil)
+		return s, nil
+	}
+	return func(f Filter) (S, error) {
+		var zero S
+		key, err := json.Marshal(f)
+		if err != nil {
+			return zero, err
+		}
+		k := name + ":" + string(key)
+		c.mu.Lock()
+		e, ok := c.entries[k]
+		c.mu.Unlock()
+		var v []T
+		if ok && json.Unmarshal(e.Results, &v) != nil {
+			ok = false
+		}
+		switch expired := ok && time.Since(e.Time) >= c.ttl; {
+		case ok && !expired:
+			return newSet(v), nil
+		case ok && background:
+			c.mu.Lock()
+			if !c.pending[k] {
+				c.pending[k] = true
+				go func() {
+					// failures are ignored, as the expired metadata remains cached
+					_, _ = store(k, f)
+					c.mu.Lock()
+					delete(c.pending, k)
+					c.mu.Unlock()
+				}()
+			}
+			c.mu.Unlock()
+			return newSet(v), nil
+		}
+		s, err := store(k, f)
+		switch {
+		case err != nil && ok:
+			c.mu.Lock()
+			if c.stale.IsZero() || e.Time.Before(c.stale) {
+				c.stale = e.Time
+			}
+			c.mu.Unlock()
+			return newSet(v), nil
+		case err != nil:
+			return zero, err
+		}
+		return s, nil
+	}
+}
+
+// save saves the cache to disk, first merging the newer metadata saved by
+// other running instances, except for the metadata matched by invalid. Must
+// be called with the lock held.
+func (c *Cache) save(invalid func(cacheEntry) bool) error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+	unlock, err := env.LockFile(c.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	var entries map[string]cacheEntry
+	if buf, err := os.ReadFile(c.path); err == nil {
+		// ignore a corrupt cache, as it is replaced
+		_ = json.Unmarshal(buf, &entries)
+	}
+	for k, e := range entries {
+		if cur, ok := c.entries[k]; (!ok || cur.Time.Before(e.Time)) && (invalid == nil || !invalid(e)) {
+			c.entries[k] = e
+		}
+	}
+	buf, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	// write and rename, so other instances never read a partial cache
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// notice writes a notice to w when stale metadata was used since the last
+// call.
+func (c *Cache) notice(w io.Writer) {
+	c.mu.Lock()
+	stale := c.stale
+	c.stale = time.Time{}
+	c.mu.Unlock()
+	if !stale.IsZero() {
+		fmt.Fprintf(w, text.SchemaCacheStale+"\n", stale.Format(time.DateTime))
+	}
+}
+
+// cachedWriter is a writer using a metadata cache, that writes a notice after
+// each command when stale metadata was used.
+type cachedWriter struct {
+	Writer
+	c *Cache
+	w io.Writer
+}
+
+// DescribeFunctions satisfies the [Writer] interface.
+func (w cachedWriter) DescribeFunctions(u *dburl.URL, funcTypes, pattern string, verbose, showSystem bool) error {
+	defer w.c.notice(w.w)
+	return w.Writer.DescribeFunctions(u, funcTypes, pattern, verbose, showSystem)
+}
+
+// DescribeTableDetails satisfies the [Writer] interface.
+func (w cachedWriter) DescribeTableDetails(u *dburl.URL, pattern string, verbose, showSystem bool) error {
+	defer w.c.notice(w.w)
+	return w.Writer.DescribeTableDetails(u, pattern, verbose, showSystem)
+}
+
+// ListAllDbs satisfies the [Writer] interface.
+func (w cachedWriter) ListAllDbs(u *dburl.URL, pattern string, verbose bool) error {
+	defer w.c.notice(w.w)
+	return w.Writer.ListAllDbs(u, pattern, verbose)
+}
+
+// ListTables satisfies the [Writer] interface.
+func (w cachedWriter) ListTables(u *dburl.URL, tableTypes, pattern string, verbose, showSystem bool) error {
+	defer w.c.notice(w.w)
+	return w.Writer.ListTables(u, tableTypes, pattern, verbose, showSystem)
+}
+
+// ListSchemas satisfies the [Writer] interface.
+func (w cachedWriter) ListSchemas(u *dburl.URL, pattern string, verbose, showSystem bool) error {
+	defer w.c.notice(w.w)
+	return w.Writer.ListSchemas(u, pattern, verbose, showSystem)
+}
+
+// ListIndexes satisfies the [Writer] interface.
+func (w cachedWriter) ListIndexes(u *dburl.URL, pattern string, verbose, showSystem bool) error {
+	defer w.c.notice(w.w)
+	return w.Writer.ListIndexes(u, pattern, verbose, showSystem)
+}
+
+// ShowStats satisfies the [Writer] interface.
+func (w cachedWriter) ShowStats(u *dburl.URL, statTypes, pattern string, verbose bool, k int) error {
+	defer w.c.notice(w.w)
+	return w.Writer.ShowStats(u, statTypes, pattern, verbose, k)
+}
+
+// ListPrivilegeSummaries satisfies the [Writer] interface.
+func (w cachedWriter) ListPrivilegeSummaries(u *dburl.URL, pattern string, showSystem bool) error {
+	defer w.c.notice(w.w)
+	return w.Writer.ListPrivilegeSummaries(u, pattern, showSystem)
+}
@@ -0,0 +1,113 @@
+package metadata
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type tableReader struct {
+	tables []Table
+	err    error
+	n      int
+}
+
+func (r *tableReader) Tables(Filter) (*TableSet, error) {
+	r.n++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return NewTableSet(r.tables), nil
+}
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	r := &tableReader{tables: []Table{{Schema: "public", Name: "a"}, {Schema: "public", Name: "b"}}}
+	tables := func(c *Cache) []string {
+		t.Helper()
+		res, err := c.Reader(r).(TableReader).Tables(Filter{Schema: "public"})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		var names []string
+		for res.Next() {
+			names = append(names, res.Get().Name)
+		}
+		return names
+	}
+	// read and cache
+	c := NewCache(path, time.Hour)
+	if names := tables(c); len(names) != 2 || r.n != 1 {
+		t.Fatalf("expected 2 tables after 1 read, got: %v after %d", names, r.n)
+	}
+	// served from the cache loaded from disk
+	c = NewCache(path, time.Hour)
+	if names := tables(c); len(names) != 2 || r.n != 1 {
+		t.Fatalf("expected 2 cached tables after 1 read, got: %v after %d", names, r.n)
+	}
+	// expired, and failing reads are served stale
+	c, r.err = NewCache(path, 0), errors.New("connection reset")
+	if names := tables(c); len(names) != 2 || r.n != 2 {
+		t.Fatalf("expected 2 stale tables after 2 reads, got: %v after %d", names, r.n)
+	}
+	if c.stale.IsZero() {
+		t.Errorf("expected stale to be set")
+	}
+	// refreshed cache is empty
+	if err := c.Refresh(); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := c.Reader(r).(TableReader).Tables(Filter{}); err == nil {
+		t.Errorf("expected error after refresh")
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	c := NewCache(filepath.Join(t.TempDir(), "cache.json"), time.Hour)
+	r := &tableReader{tables: []Table{{Schema: "a", Name: "t"}}}
+	tr := c.Reader(r).(TableReader)
+	for _, schema := range []string{"a", "b", ""} {
+		if _, err := tr.Tables(Filter{Schema: schema}); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if err := c.Invalidate("a"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	// only b is still cached
+	for _, schema := range []string{"a", "b", ""} {
+		if _, err := tr.Tables(Filter{Schema: schema}); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if r.n != 5 {
+		t.Errorf("expected 5 reads, got: %d", r.n)
+	}
+}
+
+func TestCacheMerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	r := &tableReader{tables: []Table{{Schema: "a", Name: "t"}}}
+	// two instances sharing the cache file
+	a, b := NewCache(path, time.Hour), NewCache(path, time.Hour)
+	if _, err := a.Reader(r).(TableReader).Tables(Filter{Schema: "a"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := b.Reader(r).(TableReader).Tables(Filter{Schema: "b"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if err := b.Invalidate("b"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	// only a is still cached
+	tr := NewCache(path, time.Hour).Reader(r).(TableReader)
+	for _, schema := range []string{"a", "b"} {
+		if _, err := tr.Tables(Filter{Schema: schema}); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if r.n != 3 {
+		t.Errorf("expected 3 reads, got: %d", r.n)
+	}
+}
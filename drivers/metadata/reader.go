@@ -187,6 +187,12 @@ type logger interface {
 	Println(...interface{})
 }
 
+// queryLogger is the interface for loggers logging a query along with its
+// args.
+type queryLogger interface {
+	LogQuery(string, []interface{})
+}
+
 func NewLoggingReader(db DB, opts ...ReaderOption) LoggingReader {
 	r := LoggingReader{
 		db: db,
@@ -253,7 +259,10 @@ func (r *LoggingReader) setTimeout(t time.Duration) {
 }
 
 func (r LoggingReader) Query(q string, v ...interface{}) (*sql.Rows, CloseFunc, error) {
-	if r.logger != nil {
+	switch l, ok := r.logger.(queryLogger); {
+	case ok:
+		l.LogQuery(q, v)
+	case r.logger != nil:
 		r.logger.Println(q)
 		r.logger.Println(v)
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/xo/dburl"
 	"github.com/xo/tblfmt"
 	"github.com/xo/usql/env"
+	"github.com/xo/usql/formats"
 	"github.com/xo/usql/text"
 )
 
@@ -145,7 +146,7 @@ func (w DefaultWriter) DescribeFunctions(u *dburl.URL, funcTypes, pattern string
 	})
 	params := env.Vars().Print()
 	params["title"] = "List of functions"
-	return tblfmt.EncodeAll(w.w, res, params)
+	return formats.EncodeAll(w.w, res, params)
 }
 
 func (w DefaultWriter) getFunctionColumns(c, s, f string) (string, error) {
@@ -504,7 +505,7 @@ func (w DefaultWriter) describeSequences(sp, tp string, verbose, showSystem bool
 		params := env.Vars().Print()
 		params["footer"] = "off"
 		params["title"] = fmt.Sprintf("Sequence \"%s.%s\"\n", s.Schema, s.Name)
-		err = tblfmt.EncodeAll(w.w, rows, params)
+		err = formats.EncodeAll(w.w, rows, params)
 		if err != nil {
 			return 0, err
 		}
@@ -561,7 +562,7 @@ func (w DefaultWriter) ListAllDbs(u *dburl.URL, pattern string, verbose bool) er
 
 	params := env.Vars().Print()
 	params["title"] = "List of databases"
-	return tblfmt.EncodeAll(w.w, res, params)
+	return formats.EncodeAll(w.w, res, params)
 }
 
 // ListTables matching pattern
@@ -613,7 +614,7 @@ func (w DefaultWriter) ListTables(u *dburl.URL, tableTypes, pattern string, verb
 
 	params := env.Vars().Print()
 	params["title"] = "List of relations"
-	return tblfmt.EncodeAll(w.w, res, params)
+	return formats.EncodeAll(w.w, res, params)
 }
 
 // ListSchemas matching pattern
@@ -637,7 +638,7 @@ func (w DefaultWriter) ListSchemas(u *dburl.URL, pattern string, verbose, showSy
 	}
 	params := env.Vars().Print()
 	params["title"] = "List of schemas"
-	return tblfmt.EncodeAll(w.w, res, params)
+	return formats.EncodeAll(w.w, res, params)
 }
 
 // ListIndexes matching pattern
@@ -685,7 +686,7 @@ func (w DefaultWriter) ListIndexes(u *dburl.URL, pattern string, verbose, showSy
 
 	params := env.Vars().Print()
 	params["title"] = "List of indexes"
-	return tblfmt.EncodeAll(w.w, res, params)
+	return formats.EncodeAll(w.w, res, params)
 }
 
 // ShowStats of columns for tables matching pattern
@@ -769,7 +770,7 @@ func (w DefaultWriter) ShowStats(u *dburl.URL, statTypes, pattern string, verbos
 
 	params := env.Vars().Print()
 	params["title"] = "Column stats"
-	return tblfmt.EncodeAll(w.w, res, params)
+	return formats.EncodeAll(w.w, res, params)
 }
 
 // ListPrivilegeSummaries matching pattern
@@ -818,7 +819,7 @@ func (w DefaultWriter) ListPrivilegeSummaries(u *dburl.URL, pattern string, show
 
 	params := env.Vars().Print()
 	params["title"] = "Access privileges"
-	return tblfmt.EncodeAll(w.w, res, params)
+	return formats.EncodeAll(w.w, res, params)
 }
 
 func parsePattern(pattern string) (string, string, error) {
@@ -40,6 +40,8 @@ func init() {
 		},
 		ConvertBytes:      sqshared.ConvertBytes,
 		NewMetadataReader: sqshared.NewMetadataReader,
+		NearestNeighbors:  sqshared.NearestNeighbors,
+		ForeignKeys:       sqshared.ForeignKeys,
 		Copy:              drivers.CopyWithInsert(func(int) string { return "?" }),
 	})
 }
@@ -0,0 +1,101 @@
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/xo/usql/text"
+)
+
+// SplitHosts splits a multi-host database URL (ie,
+// pg://user@h1,h2:5433,[::1]/db?target_session_attrs=read-write) into a
+// single host URL for each host, in order, along with the
+// target_session_attrs query parameter, which is removed from the URLs.
+//
+// URLs with a single host are returned as is.
+func SplitHosts(urlstr string) ([]string, string) {
+	i := strings.Index(urlstr, "://")
+	if i == -1 {
+		return []string{urlstr}, ""
+	}
+	scheme, rest := urlstr[:i+3], urlstr[i+3:]
+	end := strings.IndexAny(rest, "/?")
+	if end == -1 {
+		end = len(rest)
+	}
+	authority, path, query := rest[:end], rest[end:], ""
+	if j := strings.IndexByte(path, '?'); j != -1 {
+		path, query = path[:j], path[j+1:]
+	}
+	userinfo := ""
+	if j := strings.LastIndexByte(authority, '@'); j != -1 {
+		userinfo, authority = authority[:j+1], authority[j+1:]
+	}
+	hosts := strings.Split(authority, ",")
+	attrs := ""
+	if q, err := url.ParseQuery(query); err == nil && q.Has("target_session_attrs") {
+		attrs = q.Get("target_session_attrs")
+		q.Del("target_session_attrs")
+		query = q.Encode()
+	}
+	if len(hosts) == 1 && attrs == "" {
+		return []string{urlstr}, ""
+	}
+	if query != "" {
+		query = "?" + query
+	}
+	urls := make([]string, len(hosts))
+	for i, host := range hosts {
+		urls[i] = scheme + userinfo + strings.TrimSpace(host) + path + query
+	}
+	return urls, attrs
+}
+
+// lookupSRV looks up the DNS SRV records of a name.
+var lookupSRV = net.DefaultResolver.LookupSRV
+
+// ResolveSRV resolves the host of a database URL having a +srv scheme suffix
+// (ie, pg+srv://user@_postgresql._tcp.example.com/db) using the host's DNS SRV
+// records, returning a multi-host URL of the targets (ie,
+// pg://user@db1.example.com:5432,db2.example.com:5432/db). See SplitHosts.
+//
+// The targets are in the order returned by the resolver, which sorts them by
+// priority and randomizes them by weight, as per RFC 2782.
+//
+// URLs without the +srv suffix are returned as is.
+func ResolveSRV(ctx context.Context, urlstr string) (string, error) {
+	i := strings.Index(urlstr, "+srv://")
+	if i == -1 {
+		return urlstr, nil
+	}
+	scheme, rest := urlstr[:i]+"://", urlstr[i+7:]
+	end := strings.IndexAny(rest, "/?")
+	if end == -1 {
+		end = len(rest)
+	}
+	authority, rest := rest[:end], rest[end:]
+	userinfo := ""
+	if j := strings.LastIndexByte(authority, '@'); j != -1 {
+		userinfo, authority = authority[:j+1], authority[j+1:]
+	}
+	name := authority
+	if host, _, err := net.SplitHostPort(authority); err == nil {
+		name = host
+	}
+	_, addrs, err := lookupSRV(ctx, "", "", name)
+	switch {
+	case err != nil:
+		return "", err
+	case len(addrs) == 0:
+		return "", fmt.Errorf(text.NoSRVRecords, name)
+	}
+	hosts := make([]string, len(addrs))
+	for i, addr := range addrs {
+		hosts[i] = net.JoinHostPort(strings.TrimSuffix(addr.Target, "."), strconv.Itoa(int(addr.Port)))
+	}
+	return scheme + userinfo + strings.Join(hosts, ",") + rest, nil
+}
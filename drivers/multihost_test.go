@@ -0,0 +1,104 @@
+package drivers
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestSplitHosts(t *testing.T) {
+	tests := []struct {
+		s     string
+		exp   []string
+		attrs string
+	}{
+		{"test.db", []string{"test.db"}, ""},
+		{"pg://user@host/db", []string{"pg://user@host/db"}, ""},
+		{"pg://user@host/db?sslmode=disable", []string{"pg://user@host/db?sslmode=disable"}, ""},
+		{
+			"pg://user@h1,h2:5433/db?target_session_attrs=read-write",
+			[]string{"pg://user@h1/db", "pg://user@h2:5433/db"},
+			"read-write",
+		},
+		{
+			"pg://user:pass@h1,[::1]:5432/db?sslmode=disable",
+			[]string{"pg://user:pass@h1/db?sslmode=disable", "pg://user:pass@[::1]:5432/db?sslmode=disable"},
+			"",
+		},
+		{
+			"pg://host/db?target_session_attrs=any&sslmode=disable",
+			[]string{"pg://host/db?sslmode=disable"},
+			"any",
+		},
+		{"pg://h1, h2", []string{"pg://h1", "pg://h2"}, ""},
+		{"pg://h1,h2?connect_timeout=5", []string{"pg://h1?connect_timeout=5", "pg://h2?connect_timeout=5"}, ""},
+	}
+	for i, test := range tests {
+		urls, attrs := SplitHosts(test.s)
+		if !reflect.DeepEqual(urls, test.exp) {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, urls)
+		}
+		if attrs != test.attrs {
+			t.Errorf("test %d expected attrs %q, got: %q", i, test.attrs, attrs)
+		}
+	}
+}
+
+func TestResolveSRV(t *testing.T) {
+	tests := []string{
+		"test.db",
+		"pg://user@host/db",
+		"pg://user@h1,h2:5433/db?target_session_attrs=read-write",
+		"mysql://user@host:3306/db?srv=true",
+	}
+	for i, test := range tests {
+		s, err := ResolveSRV(context.Background(), test)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if s != test {
+			t.Errorf("test %d expected %q, got: %q", i, test, s)
+		}
+	}
+}
+
+func TestResolveSRVLookup(t *testing.T) {
+	lookup := lookupSRV
+	defer func() { lookupSRV = lookup }()
+	lookupSRV = func(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		switch name {
+		case "_postgresql._tcp.example.com":
+			return name, []*net.SRV{
+				{Target: "db2.example.com.", Port: 5433, Priority: 10, Weight: 10},
+				{Target: "db1.example.com.", Port: 5432, Priority: 10, Weight: 50},
+				{Target: "db3.example.com.", Port: 5434, Priority: 20, Weight: 100},
+			}, nil
+		case "_mysql._tcp.example.com":
+			return name, []*net.SRV{{Target: "my.example.com.", Port: 3306}}, nil
+		}
+		return name, nil, nil
+	}
+	tests := []struct {
+		s   string
+		exp string
+	}{
+		{
+			"pg+srv://user:pass@_postgresql._tcp.example.com/db?sslmode=verify-full",
+			"pg://user:pass@db2.example.com:5433,db1.example.com:5432,db3.example.com:5434/db?sslmode=verify-full",
+		},
+		{"mysql+srv://_mysql._tcp.example.com:1234", "mysql://my.example.com:3306"},
+	}
+	for i, test := range tests {
+		s, err := ResolveSRV(context.Background(), test.s)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		if s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+	if _, err := ResolveSRV(context.Background(), "pg+srv://_none._tcp.example.com/db"); err == nil {
+		t.Errorf("expected error for a name without SRV records")
+	}
+}
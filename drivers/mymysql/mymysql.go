@@ -38,5 +38,27 @@ func init() {
 		},
 		Copy:         drivers.CopyWithInsert(func(int) string { return "?" }),
 		NewCompleter: mymeta.NewCompleter,
+		SetOption:    setOption,
 	})
 }
+
+// setOption returns the statement setting the session option name to value.
+func setOption(name, value string) (string, error) {
+	switch name {
+	case "search_path":
+		schemas, err := drivers.SearchPath(value, "`")
+		if err != nil {
+			return "", err
+		}
+		return "USE " + schemas[0], nil
+	case "time_zone":
+		return "SET SESSION time_zone = " + drivers.QuoteLiteral(value), nil
+	case "statement_timeout":
+		timeout, err := drivers.StatementTimeout(value)
+		if err != nil {
+			return "", err
+		}
+		return "SET SESSION max_execution_time = " + strconv.FormatInt(timeout.Milliseconds(), 10), nil
+	}
+	return "SET SESSION " + name + " = " + drivers.QuoteLiteral(value), nil
+}
@@ -9,10 +9,13 @@
 package mysql
 
 import (
+	"context"
 	"io"
 	"strconv"
+	"strings"
 
 	"github.com/go-sql-driver/mysql" // DRIVER
+	"github.com/xo/dburl"
 	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/drivers/metadata"
 	mymeta "github.com/xo/usql/drivers/metadata/mysql"
@@ -47,5 +50,136 @@ func init() {
 		},
 		Copy:         drivers.CopyWithInsert(func(int) string { return "?" }),
 		NewCompleter: mymeta.NewCompleter,
+		ReadOnly: func(ctx context.Context, db drivers.DB) (bool, error) {
+			var ro bool
+			err := db.QueryRowContext(ctx, `SELECT @@global.read_only`).Scan(&ro)
+			return ro, err
+		},
+		SetOption:          setOption,
+		SetApplicationName: setApplicationName,
+		Impersonate: func(_ *dburl.URL, name string) (*drivers.Impersonation, error) {
+			return &drivers.Impersonation{
+				Set:   "SET ROLE " + drivers.QuoteIdent(name, '`'),
+				Reset: "SET ROLE DEFAULT",
+			}, nil
+		},
+		Partitions:   partitions,
+		PartitionDDL: partitionDDL,
+		Sequences:    sequences,
+		FixSequences: func(_ context.Context, _ drivers.DB, table string) ([]string, error) {
+			// the counter is reset to MAX(col) + 1 when set below it
+			return []string{"ALTER TABLE " + table + " AUTO_INCREMENT = 1"}, nil
+		},
+		ForeignKeys: foreignKeys,
 	}, "memsql", "vitess", "tidb")
 }
+
+// setOption returns the statement setting the session option name to value.
+func setOption(name, value string) (string, error) {
+	switch name {
+	case "search_path":
+		schemas, err := drivers.SearchPath(value, "`")
+		if err != nil {
+			return "", err
+		}
+		return "USE " + schemas[0], nil
+	case "time_zone":
+		return "SET SESSION time_zone = " + drivers.QuoteLiteral(value), nil
+	case "statement_timeout":
+		timeout, err := drivers.StatementTimeout(value)
+		if err != nil {
+			return "", err
+		}
+		return "SET SESSION max_execution_time = " + strconv.FormatInt(timeout.Milliseconds(), 10), nil
+	}
+	return "SET SESSION " + name + " = " + drivers.QuoteLiteral(value), nil
+}
+
+// setApplicationName sets the application name as the program_name
+// connection attribute, when not already set.
+func setApplicationName(u *dburl.URL, name string) {
+	q := u.Query()
+	s := q.Get("connectionAttributes")
+	if strings.Contains(s, "program_name:") {
+		return
+	}
+	// connection attributes are comma-separated key:value pairs
+	attr := "program_name:" + strings.NewReplacer(",", "", ":", "").Replace(name)
+	if s != "" {
+		attr = s + "," + attr
+	}
+	q.Set("connectionAttributes", attr)
+	u.RawQuery = q.Encode()
+}
+
+// partitions returns the query listing the partitions of a table.
+func partitions(table string) (string, error) {
+	schema, name := drivers.SplitTable(table)
+	s := "DATABASE()"
+	if schema != "" {
+		s = drivers.QuoteLiteral(schema)
+	}
+	return "SELECT PARTITION_NAME AS `partition`, PARTITION_DESCRIPTION AS bound, " +
+		"TABLE_ROWS AS `rows`, DATA_LENGTH + INDEX_LENGTH AS size " +
+		"FROM information_schema.PARTITIONS " +
+		"WHERE TABLE_SCHEMA = " + s + " AND TABLE_NAME = " + drivers.QuoteLiteral(name) + " AND PARTITION_NAME IS NOT NULL " +
+		"ORDER BY PARTITION_ORDINAL_POSITION", nil
+}
+
+// partitionDDL returns the DDL creating, attaching, or detaching the
+// partition name of a table. Partitions are attached and detached by
+// exchanging the partition with the table of the same name.
+func partitionDDL(action, table, name, _, to string) (string, error) {
+	switch action {
+	case "create":
+		_, name = drivers.SplitTable(name)
+		return "ALTER TABLE " + table + " ADD PARTITION (PARTITION " + name + " VALUES LESS THAN (" + drivers.BoundLiteral(to) + "))", nil
+	case "attach", "detach":
+		return "ALTER TABLE " + table + " EXCHANGE PARTITION " + name + " WITH TABLE " + name, nil
+	}
+	return "", nil
+}
+
+// sequences returns the query listing the auto increment columns matching
+// the pattern.
+func sequences(pattern string, showSystem bool) (string, error) {
+	schema, name := drivers.SplitTable(pattern)
+	sqlstr := "SELECT t.TABLE_SCHEMA AS `Schema`, t.TABLE_NAME AS `Name`, c.COLUMN_TYPE AS `Type`, " +
+		"t.AUTO_INCREMENT AS `Current`, @@auto_increment_increment AS `Increment`, NULL AS `Max`, " +
+		"CONCAT(t.TABLE_NAME, '.', c.COLUMN_NAME) AS `Owned by`, 'auto_increment' AS `Kind` " +
+		"FROM information_schema.TABLES t " +
+		"JOIN information_schema.COLUMNS c ON c.TABLE_SCHEMA = t.TABLE_SCHEMA AND c.TABLE_NAME = t.TABLE_NAME " +
+		"AND c.EXTRA LIKE '%auto_increment%' " +
+		"WHERE true" + drivers.LikeCond("t.TABLE_SCHEMA", schema) + drivers.LikeCond("t.TABLE_NAME", name)
+	if !showSystem {
+		sqlstr += " AND t.TABLE_SCHEMA NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys')"
+	}
+	return sqlstr + " ORDER BY 1, 2", nil
+}
+
+// foreignKeys returns the query selecting the foreign key columns of the
+// table, or of the tables in the current database when table is empty.
+func foreignKeys(table string) (string, error) {
+	sqlstr := "SELECT CONSTRAINT_NAME, " +
+		"CONCAT(" + quoteIdentExpr("TABLE_SCHEMA") + ", '.', " + quoteIdentExpr("TABLE_NAME") + "), " +
+		"CONCAT(" + quoteIdentExpr("REFERENCED_TABLE_SCHEMA") + ", '.', " + quoteIdentExpr("REFERENCED_TABLE_NAME") + "), " +
+		quoteIdentExpr("COLUMN_NAME") + ", " + quoteIdentExpr("REFERENCED_COLUMN_NAME") + ", 1 " +
+		"FROM information_schema.KEY_COLUMN_USAGE " +
+		"WHERE REFERENCED_TABLE_NAME IS NOT NULL AND TABLE_SCHEMA = "
+	schema, name := drivers.SplitTable(table)
+	if schema != "" {
+		sqlstr += drivers.QuoteLiteral(schema)
+	} else {
+		sqlstr += "DATABASE()"
+	}
+	if name != "" {
+		sqlstr += " AND TABLE_NAME = " + drivers.QuoteLiteral(name)
+	}
+	return sqlstr + " ORDER BY 2, 1, ORDINAL_POSITION", nil
+}
+
+// quoteIdentExpr returns the expression quoting the identifier value of the
+// expression expr with backquotes.
+func quoteIdentExpr(expr string) string {
+	return "CONCAT('`', REPLACE(" + expr + ", '`', '``'), '`')"
+}
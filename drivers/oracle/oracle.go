@@ -10,6 +10,8 @@ import (
 	"strings"
 
 	_ "github.com/sijms/go-ora/v2" // DRIVER
+	"github.com/xo/dburl"
+	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/drivers/oracle/orshared"
 )
 
@@ -50,5 +52,13 @@ func init() {
 			}
 			return strings.Contains(err.Error(), "empty password")
 		},
+		// impersonate with proxy authentication with the proxy client name
+		func(u *dburl.URL, name string) (*drivers.Impersonation, error) {
+			v := u.URL
+			q := v.Query()
+			q.Set("PROXY CLIENT NAME", name)
+			v.RawQuery = q.Encode()
+			return &drivers.Impersonation{URL: v.String()}, nil
+		},
 	)
 }
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/xo/dburl"
 	"github.com/xo/usql/drivers"
@@ -17,12 +19,16 @@ import (
 )
 
 // Register registers an oracle driver.
-func Register(name string, err func(error) (string, string), isPasswordErr func(error) bool) {
+func Register(name string, err func(error) (string, string), isPasswordErr func(error) bool, impersonate func(*dburl.URL, string) (*drivers.Impersonation, error)) {
 	endRE := regexp.MustCompile(`;?\s*$`)
 	endAnchorRE := regexp.MustCompile(`(?i)\send\s*;\s*$`)
 	drivers.Register(name, drivers.Driver{
 		AllowMultilineComments: true,
 		LowerColumnNames:       true,
+		MaxInList:              1000,
+		Placeholder: func(n int) string {
+			return fmt.Sprintf(":%d", n)
+		},
 		ForceParams: func(u *dburl.URL) {
 			// if the service name is not specified, use the environment
 			// variable if present
@@ -70,5 +76,142 @@ func Register(name string, err func(error) (string, string), isPasswordErr func(
 		Copy: drivers.CopyWithInsert(func(n int) string {
 			return fmt.Sprintf(":%d", n)
 		}),
+		SetOption:    setOption,
+		Impersonate:  impersonate,
+		Partitions:   partitions,
+		PartitionDDL: partitionDDL,
+		Sequences:    sequences,
+		FixSequences: fixSequences,
+		ForeignKeys:  foreignKeys,
+		LimitQuery: func(sqlstr string, n, offset int) string {
+			if offset != 0 {
+				sqlstr += " OFFSET " + strconv.Itoa(offset) + " ROWS"
+			}
+			return sqlstr + " FETCH NEXT " + strconv.Itoa(n) + " ROWS ONLY"
+		},
 	})
 }
+
+// setOption returns the statement setting the session option name to value.
+// Statement timeouts are not supported.
+func setOption(name, value string) (string, error) {
+	switch name {
+	case "search_path":
+		schemas, err := drivers.SearchPath(value, `"`)
+		if err != nil {
+			return "", err
+		}
+		return "ALTER SESSION SET CURRENT_SCHEMA = " + schemas[0], nil
+	case "time_zone":
+		return "ALTER SESSION SET TIME_ZONE = " + drivers.QuoteLiteral(value), nil
+	case "statement_timeout":
+		return "", nil
+	}
+	return "ALTER SESSION SET " + name + " = " + drivers.QuoteLiteral(value), nil
+}
+
+// partitions returns the query listing the partitions of a table.
+func partitions(table string) (string, error) {
+	schema, name := drivers.SplitTable(table)
+	s := "USER"
+	if schema != "" {
+		s = "UPPER(" + drivers.QuoteLiteral(schema) + ")"
+	}
+	return `SELECT p.PARTITION_NAME AS "partition", p.HIGH_VALUE AS "bound", ` +
+		`p.NUM_ROWS AS "rows", s.BYTES AS "size" ` +
+		`FROM ALL_TAB_PARTITIONS p LEFT JOIN USER_SEGMENTS s ` +
+		`ON p.TABLE_OWNER = USER AND s.SEGMENT_NAME = p.TABLE_NAME AND s.PARTITION_NAME = p.PARTITION_NAME ` +
+		`WHERE p.TABLE_OWNER = ` + s + ` AND p.TABLE_NAME = UPPER(` + drivers.QuoteLiteral(name) + `) ` +
+		`ORDER BY p.PARTITION_POSITION`, nil
+}
+
+// partitionDDL returns the DDL creating, attaching, or detaching the
+// partition name of a table. Partitions are attached and detached by
+// exchanging the partition with the table of the same name.
+func partitionDDL(action, table, name, _, to string) (string, error) {
+	switch action {
+	case "create":
+		_, name = drivers.SplitTable(name)
+		return "ALTER TABLE " + table + " ADD PARTITION " + name + " VALUES LESS THAN (" + boundLiteral(to) + ")", nil
+	case "attach", "detach":
+		return "ALTER TABLE " + table + " EXCHANGE PARTITION " + name + " WITH TABLE " + name, nil
+	}
+	return "", nil
+}
+
+// boundDateRE matches the dates of partition bounds.
+var boundDateRE = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
+// boundLiteral formats a partition bound as a SQL literal, as a DATE or
+// TIMESTAMP literal for dates and times.
+func boundLiteral(s string) string {
+	if _, err := time.Parse("2006-01-02", s); err == nil {
+		return "DATE " + drivers.QuoteLiteral(s)
+	}
+	if boundDateRE.MatchString(s) {
+		return "TIMESTAMP " + drivers.QuoteLiteral(s)
+	}
+	return drivers.BoundLiteral(s)
+}
+
+// sequences returns the query listing the sequences and identity columns
+// matching the pattern, by default of the current user.
+func sequences(pattern string, _ bool) (string, error) {
+	schema, name := drivers.SplitTable(pattern)
+	sqlstr := `SELECT s.SEQUENCE_OWNER AS "Schema", s.SEQUENCE_NAME AS "Name", 'NUMBER' AS "Type", ` +
+		`s.LAST_NUMBER AS "Current", s.INCREMENT_BY AS "Increment", s.MAX_VALUE AS "Max", ` +
+		`i.TABLE_NAME || '.' || i.COLUMN_NAME AS "Owned by", ` +
+		`CASE WHEN i.SEQUENCE_NAME IS NULL THEN 'sequence' ELSE 'identity' END AS "Kind" ` +
+		`FROM ALL_SEQUENCES s ` +
+		`LEFT JOIN ALL_TAB_IDENTITY_COLS i ON i.OWNER = s.SEQUENCE_OWNER AND i.SEQUENCE_NAME = s.SEQUENCE_NAME ` +
+		`WHERE 1 = 1` + drivers.LikeCond("s.SEQUENCE_OWNER", strings.ToUpper(schema)) + drivers.LikeCond("s.SEQUENCE_NAME", strings.ToUpper(name))
+	if schema == "" {
+		sqlstr += ` AND s.SEQUENCE_OWNER = USER`
+	}
+	return sqlstr + ` ORDER BY 1, 2`, nil
+}
+
+// fixSequences returns the statements restarting the identity columns of the
+// table after the maximum values of the columns.
+func fixSequences(ctx context.Context, db drivers.DB, table string) ([]string, error) {
+	schema, name := drivers.SplitTable(table)
+	owner := "USER"
+	if schema != "" {
+		owner = "UPPER(" + drivers.QuoteLiteral(schema) + ")"
+	}
+	return drivers.QueryStrings(ctx, db, `SELECT 'ALTER TABLE ' || `+drivers.QuoteLiteral(table)+` || ' MODIFY (' || COLUMN_NAME || ' GENERATED ' || GENERATION_TYPE || `+
+		`' AS IDENTITY (START WITH LIMIT VALUE))' `+
+		`FROM ALL_TAB_IDENTITY_COLS `+
+		`WHERE OWNER = `+owner+` AND TABLE_NAME = UPPER(`+drivers.QuoteLiteral(name)+`)`)
+}
+
+// foreignKeys returns the query selecting the foreign key columns of the
+// table, or of the tables of the current user when table is empty.
+func foreignKeys(table string) (string, error) {
+	sqlstr := `SELECT c.CONSTRAINT_NAME, ` +
+		quoteIdentExpr("c.OWNER") + ` || '.' || ` + quoteIdentExpr("c.TABLE_NAME") + `, ` +
+		quoteIdentExpr("r.OWNER") + ` || '.' || ` + quoteIdentExpr("r.TABLE_NAME") + `, ` +
+		quoteIdentExpr("cc.COLUMN_NAME") + `, ` + quoteIdentExpr("rc.COLUMN_NAME") + `, ` +
+		`CASE WHEN c.STATUS = 'ENABLED' AND c.VALIDATED = 'VALIDATED' THEN 1 ELSE 0 END ` +
+		`FROM ALL_CONSTRAINTS c ` +
+		`JOIN ALL_CONSTRAINTS r ON r.OWNER = c.R_OWNER AND r.CONSTRAINT_NAME = c.R_CONSTRAINT_NAME ` +
+		`JOIN ALL_CONS_COLUMNS cc ON cc.OWNER = c.OWNER AND cc.CONSTRAINT_NAME = c.CONSTRAINT_NAME ` +
+		`JOIN ALL_CONS_COLUMNS rc ON rc.OWNER = r.OWNER AND rc.CONSTRAINT_NAME = r.CONSTRAINT_NAME AND rc.POSITION = cc.POSITION ` +
+		`WHERE c.CONSTRAINT_TYPE = 'R' AND c.OWNER = `
+	schema, name := drivers.SplitTable(table)
+	if schema != "" {
+		sqlstr += `UPPER(` + drivers.QuoteLiteral(schema) + `)`
+	} else {
+		sqlstr += `USER`
+	}
+	if name != "" {
+		sqlstr += ` AND c.TABLE_NAME = UPPER(` + drivers.QuoteLiteral(name) + `)`
+	}
+	return sqlstr + ` ORDER BY 2, 1, cc.POSITION`, nil
+}
+
+// quoteIdentExpr returns the expression quoting the identifier value of the
+// expression expr with double quotes.
+func quoteIdentExpr(expr string) string {
+	return `'"' || REPLACE(` + expr + `, '"', '""') || '"'`
+}
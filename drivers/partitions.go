@@ -0,0 +1,110 @@
+package drivers
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/text"
+)
+
+// Partitions returns the query listing the partitions of a table, with their
+// bounds, estimated row counts, and sizes (in bytes), for the driver of the
+// database URL, using the driver's Partitions. Supports PostgreSQL
+// declarative partitioning, MySQL partitioning, and Oracle partitioning.
+func Partitions(u *dburl.URL, table string) (string, error) {
+	if d, ok := drivers[u.Driver]; ok && d.Partitions != nil {
+		return d.Partitions(table)
+	}
+	return "", fmt.Errorf(text.NotSupportedByDriver, `\partitions`, u.Driver)
+}
+
+// PartitionDDL returns the DDL creating, attaching, or detaching (the action)
+// the partition name of a table with the bounds from and to, for the driver
+// of the database URL, using the driver's PartitionDDL.
+//
+// With MySQL and Oracle, ranges are only bounded by to (VALUES LESS THAN),
+// partitions are created with the name without its schema, and partitions
+// are attached and detached by exchanging the partition with the table of
+// the same name (EXCHANGE PARTITION).
+func PartitionDDL(u *dburl.URL, action, table, name, from, to string) (string, error) {
+	d, ok := drivers[u.Driver]
+	if !ok || d.PartitionDDL == nil {
+		return "", fmt.Errorf(text.NotSupportedByDriver, `\partitions`, u.Driver)
+	}
+	s, err := d.PartitionDDL(action, table, name, from, to)
+	switch {
+	case err != nil:
+		return "", err
+	case s == "":
+		return "", fmt.Errorf(text.InvalidPartitionAction, action)
+	}
+	return s, nil
+}
+
+// PartitionPeriods are the periods of partitions created for the next period.
+var PartitionPeriods = []string{"day", "week", "month", "quarter", "year"}
+
+// NextPartition returns the DDL creating the partition of a table for the
+// period (day, week, month, quarter, or year) following the partition with the
+// latest upper bound of the bounds listed by the Partitions query.
+//
+// The partition is named after the table and the start of the period (ie,
+// orders_p2026_11).
+func NextPartition(u *dburl.URL, table string, bounds []string, period string) (string, error) {
+	var last time.Time
+	for _, bound := range bounds {
+		m := boundDateRE.FindAllString(bound, -1)
+		if len(m) == 0 {
+			continue
+		}
+		if t, err := time.Parse("2006-01-02", m[len(m)-1]); err == nil && t.After(last) {
+			last = t
+		}
+	}
+	if last.IsZero() {
+		return "", fmt.Errorf(text.NoPartitionBound, table)
+	}
+	var next time.Time
+	layout := "2006_01_02"
+	switch period {
+	case "day":
+		next = last.AddDate(0, 0, 1)
+	case "week":
+		next = last.AddDate(0, 0, 7)
+	case "month":
+		next, layout = last.AddDate(0, 1, 0), "2006_01"
+	case "quarter":
+		next, layout = last.AddDate(0, 3, 0), "2006_01"
+	case "year":
+		next, layout = last.AddDate(1, 0, 0), "2006"
+	default:
+		return "", fmt.Errorf(text.InvalidPartitionPeriod, period, strings.Join(PartitionPeriods, ", "))
+	}
+	name := table + "_p" + last.Format(layout)
+	return PartitionDDL(u, "create", table, name, last.Format("2006-01-02"), next.Format("2006-01-02"))
+}
+
+// boundDateRE matches the dates of partition bounds.
+var boundDateRE = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
+// BoundLiteral formats a partition bound as a SQL literal. Numbers, MINVALUE,
+// and MAXVALUE are not quoted.
+func BoundLiteral(s string) string {
+	if _, err := strconv.ParseFloat(s, 64); err == nil || slices.Contains([]string{"MINVALUE", "MAXVALUE"}, strings.ToUpper(s)) {
+		return s
+	}
+	return QuoteLiteral(s)
+}
+
+// SplitTable splits a table name into its schema (if any) and name.
+func SplitTable(table string) (string, string) {
+	if i := strings.LastIndex(table, "."); i != -1 {
+		return table[:i], table[i+1:]
+	}
+	return "", table
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/drivers/metadata"
 	pgmeta "github.com/xo/usql/drivers/metadata/postgres"
+	"github.com/xo/usql/drivers/postgres/pgshared"
 	"github.com/xo/usql/text"
 )
 
@@ -26,6 +27,9 @@ func init() {
 		AllowDollar:            true,
 		AllowMultilineComments: true,
 		LexerName:              "postgres",
+		Placeholder: func(n int) string {
+			return fmt.Sprintf("$%d", n)
+		},
 		Open: func(ctx context.Context, u *dburl.URL, stdout, stderr func() io.Writer) (func(string, string) (*sql.DB, error), error) {
 			return func(_, dsn string) (*sql.DB, error) {
 				config, err := pgx.ParseConfig(dsn)
@@ -61,6 +65,19 @@ func init() {
 			}
 			return "PostgreSQL " + ver, nil
 		},
+		ReadOnly: func(ctx context.Context, db drivers.DB) (bool, error) {
+			var ro bool
+			err := db.QueryRowContext(ctx, `SELECT pg_is_in_recovery() OR current_setting('transaction_read_only') = 'on'`).Scan(&ro)
+			return ro, err
+		},
+		SetOption:          pgshared.SetOption,
+		SetApplicationName: drivers.ApplicationNameParameter("application_name"),
+		Impersonate:        pgshared.Impersonate,
+		Partitions:         pgshared.Partitions,
+		PartitionDDL:       pgshared.PartitionDDL,
+		Sequences:          pgshared.Sequences,
+		FixSequences:       pgshared.FixSequences,
+		ForeignKeys:        pgshared.ForeignKeys,
 		ChangePassword: func(db drivers.DB, user, newpw, _ string) error {
 			_, err := db.Exec(`ALTER USER ` + user + ` PASSWORD '` + newpw + `'`)
 			return err
@@ -80,6 +97,31 @@ func init() {
 			return false
 		},
 		NewMetadataReader: pgmeta.NewReader(),
+		NearestNeighbors: drivers.NearestNeighborsByDistance(func(column, vec string) string {
+			// pgvector euclidean distance
+			return column + ` <-> '` + vec + `'`
+		}),
+		Listen: func(ctx context.Context, _ *dburl.URL, db *sql.DB, channel string, f func(string)) error {
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			return conn.Raw(func(dc interface{}) error {
+				c := dc.(*stdlib.Conn).Conn()
+				if _, err := c.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+					return err
+				}
+				defer c.Exec(context.Background(), "UNLISTEN *")
+				for {
+					n, err := c.WaitForNotification(ctx)
+					if err != nil {
+						return err
+					}
+					f(n.Payload)
+				}
+			})
+		},
 		NewMetadataWriter: func(db drivers.DB, w io.Writer, opts ...metadata.ReaderOption) metadata.Writer {
 			return metadata.NewDefaultWriter(pgmeta.NewReader()(db, opts...))(db, w)
 		},
@@ -111,9 +153,16 @@ func init() {
 			}
 			clen := len(columns)
 
+			columnTypes, err := rows.ColumnTypes()
+			if err != nil {
+				return 0, fmt.Errorf("failed to fetch source column types: %w", err)
+			}
+			opts := drivers.CopyOptionsFrom(ctx)
 			crows := &copyRows{
 				rows:   rows,
 				values: make([]interface{}, clen),
+				coerce: opts.Coercer(columnTypes),
+				opts:   opts,
 			}
 			for i := 0; i < clen; i++ {
 				crows.values[i] = new(interface{})
@@ -133,6 +182,8 @@ func init() {
 type copyRows struct {
 	rows   *sql.Rows
 	values []interface{}
+	coerce func([]interface{})
+	opts   *drivers.CopyOptions
 }
 
 func (r *copyRows) Next() bool {
@@ -145,6 +196,10 @@ func (r *copyRows) Values() ([]interface{}, error) {
 	for i, v := range r.values {
 		actuals[i] = *(v.(*interface{}))
 	}
+	if r.coerce != nil {
+		r.coerce(actuals)
+	}
+	r.opts.Copied(1)
 	return actuals, err
 }
 
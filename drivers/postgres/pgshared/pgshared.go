@@ -0,0 +1,115 @@
+// Package pgshared contains a shared driver implementation for PostgreSQL.
+// Used by the Postgres and PGX drivers.
+package pgshared
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/drivers"
+)
+
+// Impersonate returns how the principal name is impersonated, with SET ROLE.
+func Impersonate(_ *dburl.URL, name string) (*drivers.Impersonation, error) {
+	return &drivers.Impersonation{
+		Set:   "SET ROLE " + drivers.QuoteIdent(name, '"'),
+		Reset: "RESET ROLE",
+	}, nil
+}
+
+// SetOption returns the statement setting the session option name to value.
+func SetOption(name, value string) (string, error) {
+	switch name {
+	case "search_path":
+		schemas, err := drivers.SearchPath(value, `"`)
+		if err != nil {
+			return "", err
+		}
+		return "SET search_path TO " + strings.Join(schemas, ", "), nil
+	case "time_zone":
+		return "SET TIME ZONE " + drivers.QuoteLiteral(value), nil
+	case "statement_timeout":
+		timeout, err := drivers.StatementTimeout(value)
+		if err != nil {
+			return "", err
+		}
+		return "SET statement_timeout = " + strconv.FormatInt(timeout.Milliseconds(), 10), nil
+	}
+	return "SET " + name + " = " + drivers.QuoteLiteral(value), nil
+}
+
+// Partitions returns the query listing the partitions of a table.
+func Partitions(table string) (string, error) {
+	return `SELECT c.relname AS partition, pg_get_expr(c.relpartbound, c.oid) AS bound, ` +
+		`GREATEST(c.reltuples, 0)::bigint AS rows, pg_total_relation_size(c.oid) AS size ` +
+		`FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid ` +
+		`WHERE i.inhparent = ` + drivers.QuoteLiteral(table) + `::regclass ` +
+		`ORDER BY c.relname`, nil
+}
+
+// PartitionDDL returns the DDL creating, attaching, or detaching the
+// partition name of a table.
+func PartitionDDL(action, table, name, from, to string) (string, error) {
+	bounds := "FOR VALUES FROM (" + drivers.BoundLiteral(from) + ") TO (" + drivers.BoundLiteral(to) + ")"
+	switch action {
+	case "create":
+		return "CREATE TABLE " + name + " PARTITION OF " + table + " " + bounds, nil
+	case "attach":
+		return "ALTER TABLE " + table + " ATTACH PARTITION " + name + " " + bounds, nil
+	case "detach":
+		return "ALTER TABLE " + table + " DETACH PARTITION " + name, nil
+	}
+	return "", nil
+}
+
+// Sequences returns the query listing the sequences and identity columns
+// matching the pattern.
+func Sequences(pattern string, showSystem bool) (string, error) {
+	schema, name := drivers.SplitTable(pattern)
+	sqlstr := `SELECT s.schemaname AS "Schema", s.sequencename AS "Name", s.data_type AS "Type", ` +
+		`s.last_value AS "Current", s.increment_by AS "Increment", s.max_value AS "Max", ` +
+		`d.refobjid::regclass || '.' || quote_ident(a.attname) AS "Owned by", ` +
+		`CASE d.deptype WHEN 'i' THEN 'identity' WHEN 'a' THEN 'owned' ELSE 'sequence' END AS "Kind" ` +
+		`FROM pg_sequences s ` +
+		`JOIN pg_namespace n ON n.nspname = s.schemaname ` +
+		`JOIN pg_class c ON c.relnamespace = n.oid AND c.relname = s.sequencename ` +
+		`LEFT JOIN pg_depend d ON d.classid = 'pg_class'::regclass AND d.objid = c.oid ` +
+		`AND d.refclassid = 'pg_class'::regclass AND d.deptype IN ('a', 'i') ` +
+		`LEFT JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid ` +
+		`WHERE true` + drivers.LikeCond("s.schemaname", schema) + drivers.LikeCond("s.sequencename", name)
+	if !showSystem {
+		sqlstr += ` AND s.schemaname NOT IN ('pg_catalog', 'information_schema')`
+	}
+	return sqlstr + ` ORDER BY 1, 2`, nil
+}
+
+// FixSequences returns the statements setting the sequences of the serial and
+// identity columns of the table to the maximum values of the columns.
+func FixSequences(ctx context.Context, db drivers.DB, table string) ([]string, error) {
+	return drivers.QueryStrings(ctx, db, `SELECT 'SELECT setval(' || quote_literal(pg_get_serial_sequence($1::text, attname)) || ', MAX(' || quote_ident(attname) || ')) `+
+		`FROM ' || $1::text || ' HAVING MAX(' || quote_ident(attname) || ') IS NOT NULL' `+
+		`FROM pg_attribute `+
+		`WHERE attrelid = $1::text::regclass AND attnum > 0 AND NOT attisdropped `+
+		`AND pg_get_serial_sequence($1::text, attname) IS NOT NULL `+
+		`ORDER BY attnum`, table)
+}
+
+// ForeignKeys returns the query selecting the foreign key columns of the
+// table, or of the tables in the current schemas when table is empty.
+func ForeignKeys(table string) (string, error) {
+	sqlstr := `SELECT c.conname, c.conrelid::regclass::text, c.confrelid::regclass::text, ` +
+		`quote_ident(a.attname), quote_ident(af.attname), CASE WHEN c.convalidated THEN 1 ELSE 0 END ` +
+		`FROM pg_constraint c ` +
+		`CROSS JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, fattnum, n) ` +
+		`JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum ` +
+		`JOIN pg_attribute af ON af.attrelid = c.confrelid AND af.attnum = k.fattnum ` +
+		`WHERE c.contype = 'f' AND `
+	if table != "" {
+		sqlstr += `c.conrelid = ` + drivers.QuoteLiteral(table) + `::regclass `
+	} else {
+		sqlstr += `c.connamespace IN (SELECT oid FROM pg_namespace WHERE nspname = ANY(current_schemas(false))) `
+	}
+	return sqlstr + `ORDER BY 2, 1, k.n`, nil
+}
@@ -14,12 +14,14 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/lib/pq" // DRIVER
 	"github.com/xo/dburl"
 	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/drivers/metadata"
 	pgmeta "github.com/xo/usql/drivers/metadata/postgres"
+	"github.com/xo/usql/drivers/postgres/pgshared"
 	"github.com/xo/usql/env"
 	"github.com/xo/usql/text"
 )
@@ -51,6 +53,9 @@ func init() {
 		AllowDollar:            true,
 		AllowMultilineComments: true,
 		LexerName:              "postgres",
+		Placeholder: func(n int) string {
+			return fmt.Sprintf("$%d", n)
+		},
 		ForceParams: func(u *dburl.URL) {
 			if u.Scheme == "cockroachdb" {
 				drivers.ForceQueryParameters([]string{"sslmode", "disable"})(u)
@@ -89,6 +94,19 @@ func init() {
 			}
 			return "PostgreSQL " + ver, nil
 		},
+		ReadOnly: func(ctx context.Context, db drivers.DB) (bool, error) {
+			var ro bool
+			err := db.QueryRowContext(ctx, `SELECT pg_is_in_recovery() OR current_setting('transaction_read_only') = 'on'`).Scan(&ro)
+			return ro, err
+		},
+		SetOption:          pgshared.SetOption,
+		SetApplicationName: drivers.ApplicationNameParameter("application_name"),
+		Impersonate:        pgshared.Impersonate,
+		Partitions:         pgshared.Partitions,
+		PartitionDDL:       pgshared.PartitionDDL,
+		Sequences:          pgshared.Sequences,
+		FixSequences:       pgshared.FixSequences,
+		ForeignKeys:        pgshared.ForeignKeys,
 		ChangePassword: func(db drivers.DB, user, newpw, _ string) error {
 			_, err := db.Exec(`ALTER USER ` + user + ` PASSWORD '` + newpw + `'`)
 			return err
@@ -106,6 +124,28 @@ func init() {
 			return false
 		},
 		NewMetadataReader: pgmeta.NewReader(),
+		NearestNeighbors: drivers.NearestNeighborsByDistance(func(column, vec string) string {
+			// pgvector euclidean distance
+			return column + ` <-> '` + vec + `'`
+		}),
+		Listen: func(ctx context.Context, u *dburl.URL, _ *sql.DB, channel string, f func(string)) error {
+			l := pq.NewListener(u.DSN, time.Second, time.Minute, nil)
+			defer l.Close()
+			if err := l.Listen(channel); err != nil {
+				return err
+			}
+			for {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case n := <-l.Notify:
+					// nil is sent after the listener reconnects
+					if n != nil {
+						f(n.Extra)
+					}
+				}
+			}
+		},
 		NewMetadataWriter: func(db drivers.DB, w io.Writer, opts ...metadata.ReaderOption) metadata.Writer {
 			return metadata.NewDefaultWriter(pgmeta.NewReader()(db, opts...))(db, w)
 		},
@@ -157,6 +197,12 @@ func init() {
 			for i := 0; i < clen; i++ {
 				values[i] = new(interface{})
 			}
+			columnTypes, err := rows.ColumnTypes()
+			if err != nil {
+				return 0, fmt.Errorf("failed to fetch source column types: %w", err)
+			}
+			opts := drivers.CopyOptionsFrom(ctx)
+			coerce := opts.Coercer(columnTypes)
 
 			var n int64
 			for rows.Next() {
@@ -164,10 +210,19 @@ func init() {
 				if err != nil {
 					return n, fmt.Errorf("failed to scan row: %w", err)
 				}
-				_, err := stmt.ExecContext(ctx, values...)
+				args := values
+				if coerce != nil {
+					args = make([]interface{}, clen)
+					for i, v := range values {
+						args[i] = *(v.(*interface{}))
+					}
+					coerce(args)
+				}
+				_, err := stmt.ExecContext(ctx, args...)
 				if err != nil {
 					return n, fmt.Errorf("failed to exec copy: %w", err)
 				}
+				opts.Copied(1)
 			}
 			res, err := stmt.ExecContext(ctx)
 			if err != nil {
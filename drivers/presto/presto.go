@@ -5,6 +5,7 @@ package presto
 
 import (
 	"context"
+	"strconv"
 
 	_ "github.com/prestodb/presto-go-client/presto" // DRIVER
 	"github.com/xo/usql/drivers"
@@ -25,5 +26,27 @@ func init() {
 			}
 			return "Presto " + ver, nil
 		},
+		SetOption: setOption,
 	})
 }
+
+// setOption returns the statement setting the session option name to value.
+func setOption(name, value string) (string, error) {
+	switch name {
+	case "search_path":
+		schemas, err := drivers.SearchPath(value, `"`)
+		if err != nil {
+			return "", err
+		}
+		return "USE " + schemas[0], nil
+	case "time_zone":
+		return "SET TIME ZONE " + drivers.QuoteLiteral(value), nil
+	case "statement_timeout":
+		timeout, err := drivers.StatementTimeout(value)
+		if err != nil {
+			return "", err
+		}
+		return "SET SESSION query_max_execution_time = " + drivers.QuoteLiteral(strconv.FormatInt(int64(timeout.Seconds()), 10)+"s"), nil
+	}
+	return "SET SESSION " + name + " = " + drivers.QuoteLiteral(value), nil
+}
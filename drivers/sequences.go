@@ -0,0 +1,72 @@
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/text"
+)
+
+// Sequences returns the query listing the sequences (and identity and auto
+// increment columns) matching the pattern, with their current values and the
+// columns owning them, for the driver of the database URL, using the driver's
+// Sequences.
+func Sequences(u *dburl.URL, pattern string, showSystem bool) (string, error) {
+	if d, ok := drivers[u.Driver]; ok && d.Sequences != nil {
+		return d.Sequences(pattern, showSystem)
+	}
+	return "", fmt.Errorf(text.NotSupportedByDriver, `\ds+`, u.Driver)
+}
+
+// FixSequences returns the statements realigning the sequences and identity
+// (or auto increment) counters of the table with the maximum values of their
+// columns (ie, after a bulk load), for the driver of the database URL, using
+// the driver's FixSequences:
+//
+//	postgres  - SELECT setval(seq, MAX(col)) for each serial or identity column
+//	mysql     - ALTER TABLE table AUTO_INCREMENT = 1, reset to MAX(col) + 1
+//	sqlserver - DBCC CHECKIDENT (table, RESEED)
+//	oracle    - ALTER TABLE table MODIFY (col ... AS IDENTITY (START WITH LIMIT VALUE))
+func FixSequences(ctx context.Context, u *dburl.URL, db DB, table string) ([]string, error) {
+	d, ok := drivers[u.Driver]
+	if !ok || d.FixSequences == nil {
+		return nil, fmt.Errorf(text.NotSupportedByDriver, `\fixseq`, u.Driver)
+	}
+	stmts, err := d.FixSequences(ctx, db, table)
+	switch {
+	case err != nil:
+		return nil, WrapErr(u.Driver, err)
+	case len(stmts) == 0:
+		return nil, fmt.Errorf(text.NoSequences, table)
+	}
+	return stmts, nil
+}
+
+// LikeCond returns the AND condition matching the column with the pattern,
+// with * and ? wildcards, or an empty string when the pattern is empty.
+func LikeCond(column, pattern string) string {
+	if pattern == "" {
+		return ""
+	}
+	return " AND " + column + " LIKE " + QuoteLiteral(strings.NewReplacer("*", "%", "?", "_").Replace(pattern))
+}
+
+// QueryStrings returns the first column of the rows of the query.
+func QueryStrings(ctx context.Context, db DB, sqlstr string, args ...interface{}) ([]string, error) {
+	rows, err := db.QueryContext(ctx, sqlstr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var v []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		v = append(v, s)
+	}
+	return v, rows.Err()
+}
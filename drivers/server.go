@@ -0,0 +1,64 @@
+package drivers
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/xo/dburl"
+)
+
+// ServerInfo is information about the server and session of a database
+// connection, as shown when connecting.
+type ServerInfo struct {
+	// Version is the server product and version.
+	Version string
+	// Database is the current database.
+	Database string
+	// Schema is the current schema.
+	Schema string
+	// Encoding is the database encoding (or collation, for SQL Server).
+	Encoding string
+	// TLS is the URL parameter requiring TLS (ie, sslmode=require), or empty
+	// when TLS is not required.
+	TLS string
+	// Role is primary or read-only, or empty when unknown.
+	Role string
+}
+
+// Server returns information about the server and session of the database
+// connection for the driver of the database URL. Information that cannot be
+// determined for the driver is left empty.
+func Server(ctx context.Context, u *dburl.URL, db DB) ServerInfo {
+	var info ServerInfo
+	info.Version, _ = Version(ctx, u, db)
+	if mode, ok := TLSMode(u); ok {
+		info.TLS = mode
+	}
+	if ro, err := ReadOnly(ctx, u, db); err == nil {
+		info.Role = "primary"
+		if ro {
+			info.Role = "read-only"
+		}
+	}
+	var sqlstr string
+	switch u.Driver {
+	case "postgres", "pgx":
+		sqlstr = `SELECT current_database(), current_schema(), current_setting('server_encoding')`
+	case "mysql":
+		sqlstr = `SELECT DATABASE(), DATABASE(), @@character_set_database`
+	case "sqlserver":
+		sqlstr = `SELECT DB_NAME(), SCHEMA_NAME(), CAST(DATABASEPROPERTYEX(DB_NAME(), 'Collation') AS NVARCHAR(128))`
+	case "godror", "oracle":
+		sqlstr = `SELECT SYS_CONTEXT('USERENV', 'DB_NAME'), SYS_CONTEXT('USERENV', 'CURRENT_SCHEMA'), ` +
+			`(SELECT VALUE FROM NLS_DATABASE_PARAMETERS WHERE PARAMETER = 'NLS_CHARACTERSET') FROM DUAL`
+	case "sqlite3", "moderncsqlite":
+		sqlstr = `SELECT 'main', 'main', encoding FROM pragma_encoding`
+	default:
+		return info
+	}
+	var database, schema, encoding sql.NullString
+	if err := db.QueryRowContext(ctx, sqlstr).Scan(&database, &schema, &encoding); err == nil {
+		info.Database, info.Schema, info.Encoding = database.String, schema.String, encoding.String
+	}
+	return info
+}
@@ -0,0 +1,121 @@
+package drivers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/text"
+)
+
+// optionAliases are the aliases of the common session options.
+var optionAliases = map[string]string{
+	"current_schema": "search_path",
+	"schema":         "search_path",
+	"timeout":        "statement_timeout",
+	"timezone":       "time_zone",
+}
+
+// OptionName returns the normalized name of a session option, resolving the
+// aliases of the common session options (ie, timezone for time_zone).
+func OptionName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if s, ok := optionAliases[name]; ok {
+		return s
+	}
+	return name
+}
+
+// SetOption returns the statement setting the session option name to value
+// for the driver of the database URL, using the driver's SetOption.
+//
+// The common session options are translated to the driver's dialect:
+//
+//	search_path       - the schema search path (ie, app,"$user",public), as plain or quoted identifiers (see SearchPath)
+//	time_zone         - the session time zone (ie, UTC)
+//	statement_timeout - the statement timeout, as a duration (ie, 30s) or milliseconds (see StatementTimeout)
+//
+// Other options are set using the driver's generic session statement (ie, SET
+// name = 'value'), when the driver has one.
+func SetOption(u *dburl.URL, name, value string) (string, error) {
+	name = OptionName(name)
+	if !optionNameRE.MatchString(name) {
+		return "", fmt.Errorf(text.InvalidOption, name)
+	}
+	var s string
+	if d, ok := drivers[u.Driver]; ok && d.SetOption != nil {
+		var err error
+		if s, err = d.SetOption(name, value); err != nil {
+			return "", err
+		}
+	}
+	if s == "" {
+		return "", fmt.Errorf(text.NotSupportedByDriver, `\setopt `+name, u.Driver)
+	}
+	return s, nil
+}
+
+// SearchPath splits the comma separated schemas of a search_path session
+// option value, validating each schema as a plain identifier, or an
+// identifier quoted with one of the quote characters (ie, "$user",public), so
+// that the schemas can be passed through as identifiers.
+func SearchPath(value, quotes string) ([]string, error) {
+	var schemas []string
+	for schema := range strings.SplitSeq(value, ",") {
+		schema = strings.TrimSpace(schema)
+		if !schemaRE.MatchString(schema) && !quotedIdent(schema, quotes) {
+			return nil, fmt.Errorf(text.InvalidOptionValue, schema, "search_path")
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, nil
+}
+
+// quotedIdent returns whether s is an identifier quoted with one of the quote
+// characters, with any closing quote characters within it doubled.
+func quotedIdent(s, quotes string) bool {
+	if len(s) < 3 || !strings.ContainsRune(quotes, rune(s[0])) {
+		return false
+	}
+	end := s[0]
+	if end == '[' {
+		end = ']'
+	}
+	if s[len(s)-1] != end {
+		return false
+	}
+	q := string(end)
+	return !strings.Contains(strings.ReplaceAll(s[1:len(s)-1], q+q, ""), q)
+}
+
+// StatementTimeout parses a statement_timeout session option value, as a
+// duration (ie, 30s) or as milliseconds.
+func StatementTimeout(value string) (time.Duration, error) {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil && i >= 0 {
+		return time.Duration(i) * time.Millisecond, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf(text.InvalidStatementTimeout, value)
+}
+
+// QuoteIdent quotes s as a SQL identifier using the quote character.
+func QuoteIdent(s string, quote rune) string {
+	q := string(quote)
+	return q + strings.ReplaceAll(s, q, q+q) + q
+}
+
+// QuoteLiteral quotes s as a SQL string literal.
+func QuoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// optionNameRE matches a session option name.
+var optionNameRE = regexp.MustCompile(`^[a-z_][a-z0-9_.]*$`)
+
+// schemaRE matches a plain (unquoted) schema identifier.
+var schemaRE = regexp.MustCompile(`^[\pL_][\pL\pN_$]*$`)
@@ -8,11 +8,11 @@ import (
 	"strconv"
 
 	"github.com/snowflakedb/gosnowflake" // DRIVER
-	"github.com/xo/tblfmt"
 	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/drivers/metadata"
 	infos "github.com/xo/usql/drivers/metadata/informationschema"
 	"github.com/xo/usql/env"
+	"github.com/xo/usql/formats"
 )
 
 func init() {
@@ -44,9 +44,31 @@ func init() {
 			}
 			return metadata.NewDefaultWriter(newReader(db, opts...), writerOpts...)(db, w)
 		},
+		SetOption: setOption,
 	})
 }
 
+// setOption returns the statement setting the session option name to value.
+func setOption(name, value string) (string, error) {
+	switch name {
+	case "search_path":
+		schemas, err := drivers.SearchPath(value, `"`)
+		if err != nil {
+			return "", err
+		}
+		return "USE SCHEMA " + schemas[0], nil
+	case "time_zone":
+		return "ALTER SESSION SET TIMEZONE = " + drivers.QuoteLiteral(value), nil
+	case "statement_timeout":
+		timeout, err := drivers.StatementTimeout(value)
+		if err != nil {
+			return "", err
+		}
+		return "ALTER SESSION SET STATEMENT_TIMEOUT_IN_SECONDS = " + strconv.FormatInt(int64(timeout.Seconds()), 10), nil
+	}
+	return "ALTER SESSION SET " + name + " = " + drivers.QuoteLiteral(value), nil
+}
+
 func listAllDbs(db drivers.DB, w io.Writer, _ string, _ bool) error {
 	rows, err := db.Query("SHOW databases")
 	if err != nil {
@@ -55,5 +77,5 @@ func listAllDbs(db drivers.DB, w io.Writer, _ string, _ bool) error {
 	defer rows.Close()
 	params := env.Vars().Print()
 	params["title"] = "List of databases"
-	return tblfmt.EncodeAll(w, rows, params)
+	return formats.EncodeAll(w, rows, params)
 }
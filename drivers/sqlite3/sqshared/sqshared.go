@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/xo/usql/drivers"
 )
 
 // ConvertBytes is the byte formatter func for sqlite3 databases.
@@ -24,6 +26,12 @@ func ConvertBytes(buf []byte, tfmt string) (string, error) {
 	return s, nil
 }
 
+// NearestNeighbors returns a query selecting the k nearest neighbors of the
+// vector vec in a sqlite-vss virtual table's column.
+func NearestNeighbors(table, column, vec string, k int) string {
+	return fmt.Sprintf("SELECT rowid, distance FROM %s WHERE vss_search(%s, vss_search_params('%s', %d))", table, column, vec, k)
+}
+
 // Time provides a type that will correctly scan the various timestamps
 // values stored by the github.com/mattn/go-sqlite3 driver for time.Time
 // values, as well as correctly satisfying the sql/driver/Valuer interface.
@@ -79,3 +87,23 @@ var SQLiteTimestampFormats = []string{
 	"2006-01-02T15:04",
 	"2006-01-02",
 }
+
+// ForeignKeys returns the query selecting the foreign key columns of the
+// table, or of all tables when table is empty.
+func ForeignKeys(table string) (string, error) {
+	sqlstr := `SELECT 'fk_' || m.name || '_' || p.id, ` + quoteIdentExpr("m.name") + `, ` +
+		quoteIdentExpr(`p."table"`) + `, ` + quoteIdentExpr(`p."from"`) + `, ` +
+		quoteIdentExpr(`COALESCE(p."to", (SELECT t.name FROM pragma_table_info(p."table") t WHERE t.pk = p.seq + 1))`) + `, 1 ` +
+		`FROM sqlite_master m JOIN pragma_foreign_key_list(m.name) p ` +
+		`WHERE m.type = 'table'`
+	if table != "" {
+		sqlstr += ` AND m.name = ` + drivers.QuoteLiteral(table)
+	}
+	return sqlstr + ` ORDER BY 2, 1, p.seq`, nil
+}
+
+// quoteIdentExpr returns the expression quoting the identifier value of the
+// expression expr with double quotes.
+func quoteIdentExpr(expr string) string {
+	return `'"' || REPLACE(` + expr + `, '"', '""') || '"'`
+}
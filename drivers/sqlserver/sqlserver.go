@@ -9,13 +9,16 @@ import (
 	"database/sql"
 	"fmt"
 	"io"
+	"regexp"
 	"strconv"
 	"strings"
 
 	mssql "github.com/microsoft/go-mssqldb"
 	sqlserver "github.com/microsoft/go-mssqldb" // DRIVER
+	"github.com/xo/dburl"
 	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/drivers/metadata"
+	"github.com/xo/usql/text"
 
 	// needed for azuresql authentication, named pipes, and shared memory transport protocols
 	_ "github.com/microsoft/go-mssqldb/azuread"
@@ -88,7 +91,22 @@ func init() {
 		NewMetadataWriter: func(db drivers.DB, w io.Writer, opts ...metadata.ReaderOption) metadata.Writer {
 			return metadata.NewDefaultWriter(NewReader(db, opts...))(db, w)
 		},
-		Copy: drivers.CopyWithInsert(placeholder),
+		Placeholder:        placeholder,
+		Copy:               drivers.CopyWithInsert(placeholder),
+		SetOption:          setOption,
+		SetApplicationName: drivers.ApplicationNameParameter("app name"),
+		Impersonate: func(_ *dburl.URL, name string) (*drivers.Impersonation, error) {
+			return &drivers.Impersonation{
+				Set:   "EXECUTE AS USER = " + drivers.QuoteLiteral(name),
+				Reset: "REVERT",
+			}, nil
+		},
+		Sequences: sequences,
+		FixSequences: func(_ context.Context, _ drivers.DB, table string) ([]string, error) {
+			return []string{"DBCC CHECKIDENT (" + drivers.QuoteLiteral(table) + ", RESEED)"}, nil
+		},
+		ForeignKeys: foreignKeys,
+		LimitQuery:  limitQuery,
 	})
 }
 
@@ -96,6 +114,23 @@ func placeholder(n int) string {
 	return fmt.Sprintf("@p%d", n)
 }
 
+// setOption returns the statement setting the session option name to value
+// (ie, SET NOCOUNT ON). The common session options are not supported.
+func setOption(name, value string) (string, error) {
+	switch name {
+	case "search_path", "time_zone", "statement_timeout":
+		return "", nil
+	}
+	if !optionValueRE.MatchString(value) {
+		return "", fmt.Errorf(text.InvalidOptionValue, value, name)
+	}
+	return "SET " + name + " " + value, nil
+}
+
+// optionValueRE matches a session option value passed through as a keyword
+// or number (ie, ON, OFF, 1000).
+var optionValueRE = regexp.MustCompile(`^[A-Za-z0-9_.+-]+$`)
+
 type NullUniqueIdentifier struct {
 	ID    mssql.UniqueIdentifier
 	Valid bool
@@ -119,3 +154,47 @@ func (nui NullUniqueIdentifier) String() string {
 	}
 	return ""
 }
+
+// sequences returns the query listing the sequences and identity columns
+// matching the pattern.
+func sequences(pattern string, _ bool) (string, error) {
+	schema, name := drivers.SplitTable(pattern)
+	return `SELECT * FROM (` +
+		`SELECT SCHEMA_NAME(s.schema_id) AS [Schema], s.name AS [Name], TYPE_NAME(s.user_type_id) AS [Type], ` +
+		`CAST(s.current_value AS DECIMAL(38)) AS [Current], CAST(s.increment AS DECIMAL(38)) AS [Increment], ` +
+		`CAST(s.maximum_value AS DECIMAL(38)) AS [Max], NULL AS [Owned by], 'sequence' AS [Kind] ` +
+		`FROM sys.sequences s ` +
+		`UNION ALL ` +
+		`SELECT SCHEMA_NAME(t.schema_id), t.name, TYPE_NAME(c.user_type_id), ` +
+		`CAST(c.last_value AS DECIMAL(38)), CAST(c.increment_value AS DECIMAL(38)), NULL, ` +
+		`t.name + '.' + c.name, 'identity' ` +
+		`FROM sys.identity_columns c JOIN sys.tables t ON t.object_id = c.object_id` +
+		`) s WHERE 1 = 1` + drivers.LikeCond("[Schema]", schema) + drivers.LikeCond("[Name]", name) + ` ORDER BY 1, 2`, nil
+}
+
+// foreignKeys returns the query selecting the foreign key columns of the
+// table, or of all tables when table is empty.
+func foreignKeys(table string) (string, error) {
+	sqlstr := `SELECT fk.name, QUOTENAME(SCHEMA_NAME(t.schema_id)) + '.' + QUOTENAME(t.name), ` +
+		`QUOTENAME(SCHEMA_NAME(rt.schema_id)) + '.' + QUOTENAME(rt.name), ` +
+		`QUOTENAME(COL_NAME(fkc.parent_object_id, fkc.parent_column_id)), ` +
+		`QUOTENAME(COL_NAME(fkc.referenced_object_id, fkc.referenced_column_id)), ` +
+		`CASE WHEN fk.is_disabled = 1 OR fk.is_not_trusted = 1 THEN 0 ELSE 1 END ` +
+		`FROM sys.foreign_keys fk ` +
+		`JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id ` +
+		`JOIN sys.tables t ON t.object_id = fk.parent_object_id ` +
+		`JOIN sys.tables rt ON rt.object_id = fk.referenced_object_id `
+	if table != "" {
+		sqlstr += `WHERE fk.parent_object_id = OBJECT_ID(` + drivers.QuoteLiteral(table) + `) `
+	}
+	return sqlstr + `ORDER BY 2, 1, fkc.constraint_column_id`, nil
+}
+
+// limitQuery limits the rows selected by the query to n, skipping the first
+// offset rows. Skipping rows requires the query to be ordered.
+func limitQuery(sqlstr string, n, offset int) string {
+	if offset != 0 {
+		return sqlstr + " OFFSET " + strconv.Itoa(offset) + " ROWS FETCH NEXT " + strconv.Itoa(n) + " ROWS ONLY"
+	}
+	return "SELECT TOP " + strconv.Itoa(n) + strings.TrimPrefix(sqlstr, "SELECT")
+}
@@ -0,0 +1,33 @@
+package drivers
+
+import (
+	"github.com/xo/dburl"
+	"github.com/xo/usql/stmtclass"
+)
+
+// Style returns the style for the SQL generated for the driver of the
+// database URL (ie, catalog queries, or DDL), with the keyword case (upper,
+// lower, or preserve) and identifier quoting (always, minimal, or preserve).
+//
+// Identifiers are quoted with the driver's identifier quote, and folded to
+// the case the database folds unquoted identifiers to, so that quoting an
+// identifier does not change the object it refers to.
+func Style(u *dburl.URL, keywordCase, quoteIdent string) stmtclass.Style {
+	s := stmtclass.Style{
+		KeywordCase: keywordCase,
+		QuoteIdent:  quoteIdent,
+		Quote:       '"',
+	}
+	if u == nil {
+		return s
+	}
+	switch u.Driver {
+	case "mysql", "mymysql", "clickhouse":
+		s.Quote = '`'
+	case "postgres", "pgx":
+		s.Fold = "lower"
+	case "godror", "oracle", "snowflake":
+		s.Fold = "upper"
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package drivers
+
+import (
+	"crypto/fips140"
+	"fmt"
+	"strings"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/text"
+)
+
+// TLS policies.
+const (
+	// TLSPolicyOff allows all connections.
+	TLSPolicyOff = "off"
+	// TLSPolicyRequire refuses network connections that do not explicitly
+	// require TLS.
+	TLSPolicyRequire = "require"
+	// TLSPolicyFIPS is as TLSPolicyRequire, and additionally requires FIPS
+	// 140-3 mode, and refuses connections skipping certificate verification.
+	TLSPolicyFIPS = "fips"
+)
+
+// FIPS returns whether the Go FIPS 140-3 mode is enabled, either by building
+// with GOFIPS140 or by running with GODEBUG=fips140=on.
+//
+// In FIPS 140-3 mode, crypto/tls only negotiates FIPS-approved protocol
+// versions, cipher suites, signature algorithms, and key exchanges, for all
+// drivers using crypto/tls.
+func FIPS() bool {
+	return fips140.Enabled()
+}
+
+// CheckTLS checks the database URL against the TLS policy, returning an error
+// when the connection is not allowed.
+//
+// Connections without a host (such as to a database file or a local unix
+// socket) are always allowed. Network connections are allowed when the URL
+// explicitly requires TLS with one of the sslmode, tls, encrypt, secure, or
+// ssl query parameters used by the drivers.
+func CheckTLS(u *dburl.URL, policy string) error {
+	switch {
+	case policy == TLSPolicyOff || policy == "":
+		return nil
+	case policy == TLSPolicyFIPS && !FIPS():
+		return text.ErrFIPSNotEnabled
+	case u.Host == "" || strings.HasPrefix(u.Host, "/"):
+		return nil
+	}
+	mode, ok := TLSMode(u)
+	switch {
+	case !ok:
+		return fmt.Errorf(text.TLSPolicyRefused, u.Driver, policy, "plaintext")
+	case policy == TLSPolicyFIPS && (mode == "tls=skip-verify" || mode == "sslmode=require"):
+		return fmt.Errorf(text.TLSPolicyRefused, u.Driver, policy, mode)
+	}
+	return nil
+}
+
+// TLSMode returns the query parameter of the database URL requiring TLS, and
+// whether TLS is required.
+func TLSMode(u *dburl.URL) (string, bool) {
+	q := u.Query()
+	for _, k := range []string{"sslmode", "tls", "encrypt", "secure", "ssl"} {
+		if !q.Has(k) {
+			continue
+		}
+		v := strings.ToLower(q.Get(k))
+		switch k {
+		case "sslmode":
+			return k + "=" + v, v == "require" || v == "verify-ca" || v == "verify-full"
+		case "tls":
+			// anything other than these is a registered tls.Config
+			return k + "=" + v, v != "" && v != "false" && v != "preferred"
+		case "encrypt":
+			return k + "=" + v, v == "true" || v == "strict" || v == "mandatory"
+		}
+		return k + "=" + v, v == "true" || v == "1"
+	}
+	return "", false
+}
@@ -0,0 +1,79 @@
+package drivers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/text"
+)
+
+func TestTLSMode(t *testing.T) {
+	tests := []struct {
+		s    string
+		mode string
+		ok   bool
+	}{
+		{"postgres://host/db", "", false},
+		{"postgres://host/db?sslmode=disable", "sslmode=disable", false},
+		{"postgres://host/db?sslmode=require", "sslmode=require", true},
+		{"postgres://host/db?sslmode=VERIFY-FULL", "sslmode=verify-full", true},
+		{"mysql://host/db?tls=true", "tls=true", true},
+		{"mysql://host/db?tls=skip-verify", "tls=skip-verify", true},
+		{"mysql://host/db?tls=custom", "tls=custom", true},
+		{"mysql://host/db?tls=preferred", "tls=preferred", false},
+		{"mysql://host/db?tls=false", "tls=false", false},
+		{"sqlserver://host/db?encrypt=strict", "encrypt=strict", true},
+		{"sqlserver://host/db?encrypt=disable", "encrypt=disable", false},
+		{"clickhouse://host/db?secure=true", "secure=true", true},
+		{"clickhouse://host/db?secure=false", "secure=false", false},
+		{"oracle://host/db?ssl=1", "ssl=1", true},
+		{"postgres://host/db?sslmode=disable&ssl=true", "sslmode=disable", false},
+	}
+	for i, test := range tests {
+		u, err := dburl.Parse(test.s)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		mode, ok := TLSMode(u)
+		if mode != test.mode || ok != test.ok {
+			t.Errorf("test %d expected %q, %t, got: %q, %t", i, test.mode, test.ok, mode, ok)
+		}
+	}
+}
+
+func TestCheckTLS(t *testing.T) {
+	tests := []struct {
+		s      string
+		policy string
+		exp    string
+	}{
+		{"postgres://host/db", "", ""},
+		{"postgres://host/db", TLSPolicyOff, ""},
+		{"postgres://host/db", TLSPolicyRequire, fmt.Sprintf(text.TLSPolicyRefused, "postgres", TLSPolicyRequire, "plaintext")},
+		{"postgres://host/db?sslmode=disable", TLSPolicyRequire, fmt.Sprintf(text.TLSPolicyRefused, "postgres", TLSPolicyRequire, "plaintext")},
+		{"postgres://host/db?sslmode=verify-full", TLSPolicyRequire, ""},
+		{"mysql://host/db?tls=skip-verify", TLSPolicyRequire, ""},
+		{"sqlite:/tmp/test.db", TLSPolicyRequire, ""},
+		{"postgres:///db?host=/var/run/postgresql", TLSPolicyRequire, ""},
+		{"postgres://host/db?sslmode=verify-full", TLSPolicyFIPS, ""},
+		{"postgres://host/db?sslmode=require", TLSPolicyFIPS, fmt.Sprintf(text.TLSPolicyRefused, "postgres", TLSPolicyFIPS, "sslmode=require")},
+		{"mysql://host/db?tls=skip-verify", TLSPolicyFIPS, fmt.Sprintf(text.TLSPolicyRefused, "mysql", TLSPolicyFIPS, "tls=skip-verify")},
+	}
+	for i, test := range tests {
+		u, err := dburl.Parse(test.s)
+		if err != nil {
+			t.Fatalf("test %d expected no error, got: %v", i, err)
+		}
+		exp := test.exp
+		if test.policy == TLSPolicyFIPS && !FIPS() {
+			exp = text.ErrFIPSNotEnabled.Error()
+		}
+		switch err := CheckTLS(u, test.policy); {
+		case exp == "" && err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case exp != "" && (err == nil || err.Error() != exp):
+			t.Errorf("test %d expected error %q, got: %v", i, exp, err)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package trino
 import (
 	"context"
 	"io"
+	"strconv"
 
 	_ "github.com/trinodb/trino-go-client/trino" // DRIVER
 	"github.com/xo/usql/drivers"
@@ -53,6 +54,28 @@ func init() {
 		NewMetadataWriter: func(db drivers.DB, w io.Writer, opts ...metadata.ReaderOption) metadata.Writer {
 			return metadata.NewDefaultWriter(newReader(db, opts...))(db, w)
 		},
-		Copy: drivers.CopyWithInsert(func(int) string { return "?" }),
+		Copy:      drivers.CopyWithInsert(func(int) string { return "?" }),
+		SetOption: setOption,
 	})
 }
+
+// setOption returns the statement setting the session option name to value.
+func setOption(name, value string) (string, error) {
+	switch name {
+	case "search_path":
+		schemas, err := drivers.SearchPath(value, `"`)
+		if err != nil {
+			return "", err
+		}
+		return "USE " + schemas[0], nil
+	case "time_zone":
+		return "SET TIME ZONE " + drivers.QuoteLiteral(value), nil
+	case "statement_timeout":
+		timeout, err := drivers.StatementTimeout(value)
+		if err != nil {
+			return "", err
+		}
+		return "SET SESSION query_max_execution_time = " + drivers.QuoteLiteral(strconv.FormatInt(int64(timeout.Seconds()), 10)+"s"), nil
+	}
+	return "SET SESSION " + name + " = " + drivers.QuoteLiteral(value), nil
+}
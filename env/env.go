@@ -4,7 +4,9 @@ package env
 
 import (
 	"bytes"
+	"crypto/fips140"
 	"fmt"
+	"image"
 	"io"
 	"os"
 	"os/exec"
@@ -12,8 +14,12 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
@@ -154,6 +160,79 @@ func HistoryFile(u *user.User) string {
 	return passfile.Expand(u.HomeDir, path)
 }
 
+// RecoverFile returns the path to the query buffer recovery file of this
+// instance, keyed by its process id so that multiple running instances do not
+// overwrite each other's recovery file.
+//
+// Defaults to ~/.<command name>_recover.<pid>, with the path prefix overridden
+// by environment variable <COMMAND NAME>_RECOVER (ie, ~/.usql_recover.1234 and
+// USQL_RECOVER).
+func RecoverFile(u *user.User) string {
+	return recoverPrefix(u) + "." + strconv.Itoa(os.Getpid())
+}
+
+// StaleRecoverFiles returns the paths to the query buffer recovery files left
+// behind by instances that are no longer running, most recently written
+// first. See RecoverFile.
+func StaleRecoverFiles(u *user.User) []string {
+	prefix := recoverPrefix(u)
+	dir, base := filepath.Split(prefix)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil
+	}
+	type file struct {
+		name string
+		mod  time.Time
+	}
+	var files []file
+	for _, entry := range entries {
+		s, ok := strings.CutPrefix(entry.Name(), base+".")
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		pid, err := strconv.Atoi(s)
+		if err != nil || pid == os.Getpid() || processRunning(pid) {
+			continue
+		}
+		if fi, err := entry.Info(); err == nil {
+			files = append(files, file{filepath.Join(dir, entry.Name()), fi.ModTime()})
+		}
+	}
+	slices.SortFunc(files, func(a, b file) int {
+		return b.mod.Compare(a.mod)
+	})
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.name
+	}
+	return names
+}
+
+// recoverPrefix returns the path prefix of the query buffer recovery files.
+func recoverPrefix(u *user.User) string {
+	n := text.CommandUpper() + "_RECOVER"
+	path := "~/." + strings.ToLower(n)
+	if s, ok := Getenv(n); ok {
+		path = s
+	}
+	return passfile.Expand(u.HomeDir, path)
+}
+
+// ColWidthsFile returns the path to the file of the column widths learned
+// for queries (see the colwidth print variable).
+//
+// Defaults to ~/.<command name>_colwidths, overridden by environment variable
+// <COMMAND NAME>_COLWIDTHS (ie, ~/.usql_colwidths and USQL_COLWIDTHS).
+func ColWidthsFile(u *user.User) string {
+	n := text.CommandUpper() + "_COLWIDTHS"
+	path := "~/." + strings.ToLower(n)
+	if s, ok := Getenv(n); ok {
+		path = s
+	}
+	return passfile.Expand(u.HomeDir, path)
+}
+
 // RCFile returns the path to the RC file.
 //
 // Defaults to ~/.<command name>rc, overridden by environment variable
@@ -167,6 +246,34 @@ func RCFile(u *user.User) string {
 	return passfile.Expand(u.HomeDir, path)
 }
 
+// ScratchFile returns the path to the local scratchpad database file.
+//
+// Defaults to ~/.<command name>_scratch, overridden by environment variable
+// <COMMAND NAME>_SCRATCH (ie, ~/.usql_scratch and USQL_SCRATCH).
+func ScratchFile(u *user.User) string {
+	n := text.CommandUpper() + "_SCRATCH"
+	path := "~/." + strings.ToLower(n)
+	if s, ok := Getenv(n); ok {
+		path = s
+	}
+	return passfile.Expand(u.HomeDir, path)
+}
+
+// SchemaCacheDir returns the path to the directory of the on-disk metadata
+// cache.
+//
+// Defaults to ~/.<command name>_schema_cache, overridden by environment
+// variable <COMMAND NAME>_SCHEMA_CACHE (ie, ~/.usql_schema_cache and
+// USQL_SCHEMA_CACHE).
+func SchemaCacheDir(u *user.User) string {
+	n := text.CommandUpper() + "_SCHEMA_CACHE"
+	path := "~/." + strings.ToLower(n)
+	if s, ok := Getenv(n); ok {
+		path = s
+	}
+	return passfile.Expand(u.HomeDir, path)
+}
+
 // Getshell returns the user's defined SHELL, or system default (if found on
 // path) and the appropriate command-line argument for the returned shell.
 //
@@ -329,13 +436,601 @@ func Quote(s string) string {
 	return "'" + s[1:len(s)-1] + "'"
 }
 
+// TLSPolicy returns the TLS policy for connections based on the TLS_POLICY
+// variable, or "fips" when running in FIPS 140-3 mode.
+func TLSPolicy() string {
+	switch s, _ := vars.Get("TLS_POLICY"); {
+	case fips140.Enabled():
+		return "fips"
+	case s == "":
+		return "off"
+	default:
+		return s
+	}
+}
+
+// AllowPlaintext returns whether plaintext connections are allowed for the
+// named connection name, regardless of the TLS policy (the allow_plaintext
+// connection option).
+func AllowPlaintext(name string) bool {
+	v, _ := ParseBool(vars.ConnOpt(name, "allow_plaintext"), name)
+	return v == "on"
+}
+
 // TermGraphics returns the [rasterm.TermType] based on TERM_GRAPHICS
-// environment variable.
+// environment variable. When TERM_GRAPHICS is a comma-separated preference
+// list (ie, sixel,kitty), the first type of the list is returned.
 func TermGraphics() rasterm.TermType {
-	var typ rasterm.TermType
 	s, _ := vars.Get("TERM_GRAPHICS")
-	_ = typ.UnmarshalText([]byte(s))
-	return typ
+	return termGraphicsTypes(s)[0]
+}
+
+// termGraphicsTypes parses a TERM_GRAPHICS preference list.
+func termGraphicsTypes(s string) []rasterm.TermType {
+	var types []rasterm.TermType
+	for _, z := range strings.Split(s, ",") {
+		var typ rasterm.TermType
+		_ = typ.UnmarshalText([]byte(strings.TrimSpace(z)))
+		types = append(types, typ)
+	}
+	return types
+}
+
+// termGraphicsAvailable are the detected terminal graphics availabilities,
+// keyed by the TERM_GRAPHICS variable.
+var termGraphicsAvailable sync.Map
+
+// TermGraphicsAvailable returns the [rasterm.TermType] based on the
+// TERM_GRAPHICS environment variable, and whether it is available. When
+// TERM_GRAPHICS is a comma-separated preference list, the first available type
+// of the list is returned.
+//
+// Availability is detected (which may query the terminal) only once for each
+// value of TERM_GRAPHICS. Terminal graphics are not available when escape
+// sequences are disabled (see Escapes), unless forced with ForceType. See
+// DetectTermGraphics.
+func TermGraphicsAvailable() (rasterm.TermType, bool) {
+	if typ := forcedTermGraphics.Load(); typ != nil {
+		return *typ, true
+	}
+	s, _ := vars.Get("TERM_GRAPHICS")
+	types := termGraphicsTypes(s)
+	if !Escapes() {
+		return types[0], false
+	}
+	if i := detectTermGraphics(s, types)(); i != -1 {
+		return types[i], true
+	}
+	return types[0], false
+}
+
+// detectTermGraphics returns the func detecting the index of the first
+// available type of the TERM_GRAPHICS value s, once.
+func detectTermGraphics(s string, types []rasterm.TermType) func() int {
+	f, _ := termGraphicsAvailable.LoadOrStore(s, sync.OnceValue(func() int {
+		for i, typ := range types {
+			if typ.Available() {
+				return i
+			}
+		}
+		return -1
+	}))
+	return f.(func() int)
+}
+
+// forcedTermGraphics is the terminal graphics type forced by ForceType.
+var forcedTermGraphics atomic.Pointer[rasterm.TermType]
+
+// ForceType forces the terminal graphics type typ, skipping detection and
+// ignoring TERM_GRAPHICS and TERM_ESCAPES. Used by programs embedding usql to
+// produce the escape sequences of a terminal graphics type, such as when
+// comparing output against golden files.
+func ForceType(typ rasterm.TermType) {
+	forcedTermGraphics.Store(&typ)
+}
+
+// Disable disables all terminal escape sequences (graphics, hyperlinks, shell
+// integration marks, and terminal notifications), as when TERM_ESCAPES is
+// off, and clears any type forced with ForceType.
+func Disable() {
+	forcedTermGraphics.Store(nil)
+	_ = vars.Set("TERM_ESCAPES", "off")
+}
+
+// Escapes returns whether terminal escape sequences are enabled by the
+// TERM_ESCAPES variable (on, off, or auto). When auto, escape sequences are
+// disabled when running in a CI environment (see CI), when NO_COLOR is set, or
+// when TERM is dumb.
+//
+// Escape sequences explicitly enabled (such as with \pset hyperlinks on) are
+// only disabled when TERM_ESCAPES is off.
+func Escapes() bool {
+	switch Get("TERM_ESCAPES") {
+	case "on":
+		return true
+	case "auto":
+		return !escapesUnwanted()
+	}
+	return false
+}
+
+// escapesUnwanted returns whether the environment does not want escape
+// sequences.
+var escapesUnwanted = sync.OnceValue(func() bool {
+	if s, ok := Getenv("NO_COLOR"); ok && s != "0" && s != "false" && s != "off" {
+		return true
+	}
+	return os.Getenv("TERM") == "dumb" || CI()
+})
+
+// ciVars are the environment variables set by common CI environments.
+var ciVars = []string{
+	"BUILDKITE",
+	"BUILD_NUMBER",
+	"CIRCLECI",
+	"CONTINUOUS_INTEGRATION",
+	"GITHUB_ACTIONS",
+	"GITLAB_CI",
+	"JENKINS_URL",
+	"TEAMCITY_VERSION",
+	"TF_BUILD",
+	"TRAVIS",
+}
+
+// CI returns whether usql is running in a CI environment, based on the CI
+// environment variable and the environment variables set by common CI
+// environments.
+func CI() bool {
+	if s, ok := os.LookupEnv("CI"); ok {
+		return s != "0" && s != "false"
+	}
+	for _, k := range ciVars {
+		if os.Getenv(k) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// termGraphicsDetection is the detection started by DetectTermGraphics.
+var termGraphicsDetection atomic.Pointer[func() int]
+
+// DetectTermGraphics starts detecting the availability of the terminal
+// graphics in the background, allowing the detection to happen concurrently
+// with other startup work, such as connecting to a database. The variables
+// are read before returning, and are not accessed by the detection.
+//
+// Detection may query the terminal, reading its response from stdin, so
+// anything reading stdin (such as a password prompt) must first wait for the
+// detection to finish. See WaitTermGraphics.
+func DetectTermGraphics() {
+	if typ := forcedTermGraphics.Load(); typ != nil || !Escapes() {
+		return
+	}
+	s, _ := vars.Get("TERM_GRAPHICS")
+	f := detectTermGraphics(s, termGraphicsTypes(s))
+	termGraphicsDetection.Store(&f)
+	go f()
+}
+
+// WaitTermGraphics waits for the detection started by DetectTermGraphics (if
+// any) to finish. Does not start a detection.
+func WaitTermGraphics() {
+	if f := termGraphicsDetection.Load(); f != nil {
+		(*f)()
+	}
+}
+
+// OpenOutput opens the output file name for writing, handling an existing file
+// according to the OUTPUT_EXISTS variable:
+//
+//	overwrite - truncate the existing file (default)
+//	append    - append to the existing file
+//	backup    - rename the existing file to name.bak
+//	error     - return an error
+//	prompt    - prompt the user (using readVar) to overwrite the file
+//
+// When atomic is true (or the OUTPUT_ATOMIC variable is on), output is written
+// to a temporary file that replaces name only when closed. See AtomicFile.
+func OpenOutput(name string, atomic bool, readVar func(string) (string, error)) (io.WriteCloser, error) {
+	appendTo := false
+	switch _, err := os.Stat(name); {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		switch Get("OUTPUT_EXISTS") {
+		case "append":
+			appendTo = true
+		case "backup":
+			if err := os.Rename(name, name+".bak"); err != nil {
+				return nil, err
+			}
+		case "error":
+			return nil, fmt.Errorf(text.OutputFileExists, name)
+		case "prompt":
+			s, err := readVar(fmt.Sprintf(text.OverwriteFilePrompt, name))
+			if err != nil {
+				return nil, err
+			}
+			if s = strings.ToLower(strings.TrimSpace(s)); s != "y" && s != "yes" {
+				return nil, fmt.Errorf(text.OutputFileExists, name)
+			}
+		}
+	}
+	if !atomic && Get("OUTPUT_ATOMIC") != "on" {
+		flag := os.O_TRUNC
+		if appendTo {
+			flag = os.O_APPEND
+		}
+		return os.OpenFile(name, flag|os.O_CREATE|os.O_WRONLY, 0o644)
+	}
+	return NewAtomicFile(name, appendTo)
+}
+
+// AtomicFile is a output file that is written to a temporary file in the same
+// directory, and is renamed to its final name when closed, ensuring that an
+// interrupted write never leaves a partial file in place.
+type AtomicFile struct {
+	*os.File
+	name string
+	done bool
+}
+
+// NewAtomicFile creates a atomic file for name. When appendTo is true, the
+// contents of the existing file are copied to the temporary file.
+func NewAtomicFile(name string, appendTo bool) (*AtomicFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return nil, err
+	}
+	af := &AtomicFile{File: f, name: name}
+	if appendTo {
+		src, err := os.Open(name)
+		if err != nil {
+			af.Discard()
+			return nil, err
+		}
+		defer src.Close()
+		if _, err := io.Copy(f, src); err != nil {
+			af.Discard()
+			return nil, err
+		}
+	}
+	return af, nil
+}
+
+// Close closes the temporary file, and renames it to the final name.
+func (f *AtomicFile) Close() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+	tmp := f.File.Name()
+	if err := f.File.Chmod(0o644); err != nil {
+		f.File.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.File.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, f.name)
+}
+
+// Discard closes and removes the temporary file, leaving any existing file in
+// place. Does nothing if the file was already closed.
+func (f *AtomicFile) Discard() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+	f.File.Close()
+	return os.Remove(f.File.Name())
+}
+
+const (
+	// lockStale is the age after which a lock file is considered left behind
+	// by a crashed process.
+	lockStale = 10 * time.Second
+	// lockTimeout is the maximum time to wait for a lock.
+	lockTimeout = 2 * time.Second
+)
+
+// LockFile acquires an exclusive lock on the file name, coordinating writes to
+// the file between multiple running instances, returning a func that releases
+// the lock.
+//
+// The lock is held by exclusively creating the file <name>.lock. Locks are only
+// held briefly, so a lock file older than 10 seconds is assumed to have been
+// left behind by a crashed instance, and is removed (see breakLock). Breaking
+// a stale lock is not atomic, so two instances may briefly both hold the lock
+// when one takes it while another breaks it.
+func LockFile(name string) (func(), error) {
+	lock := name + ".lock"
+	for start := time.Now(); ; {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		switch {
+		case err == nil:
+			fi, err := f.Stat()
+			f.Close()
+			return func() {
+				// not removed when broken as stale, and taken by another
+				// instance since
+				if gi, e := os.Stat(lock); err != nil || e == nil && os.SameFile(fi, gi) {
+					os.Remove(lock)
+				}
+			}, nil
+		case !os.IsExist(err):
+			return nil, err
+		}
+		if fi, err := os.Stat(lock); err == nil && time.Since(fi.ModTime()) > lockStale {
+			breakLock(lock, fi)
+			continue
+		}
+		if time.Since(start) > lockTimeout {
+			return nil, fmt.Errorf(text.FileLocked, name, lock)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// breakLock removes the stale lock file lock, last seen as fi. As other
+// instances may break the same lock, and take the lock since fi was seen, the
+// lock is first moved to a unique name, and compared against fi. A lock that
+// is not the stale one is moved back in place (with a hard link, so as to not
+// replace a lock taken again). When hard links are not supported (ie, FAT,
+// some network filesystems, or Windows without the privilege), the lock is
+// instead recreated with the moved lock's time, and so is only removed once
+// stale.
+func breakLock(lock string, fi os.FileInfo) {
+	tmp := lock + "." + strconv.Itoa(os.Getpid()) + "." + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := os.Rename(lock, tmp); err != nil {
+		// broken by another instance
+		return
+	}
+	if gi, err := os.Stat(tmp); err == nil && (!os.SameFile(fi, gi) || !gi.ModTime().Equal(fi.ModTime())) {
+		if err := os.Link(tmp, lock); err != nil && !os.IsExist(err) {
+			if f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600); err == nil {
+				f.Close()
+				_ = os.Chtimes(lock, gi.ModTime(), gi.ModTime())
+			}
+		}
+	}
+	os.Remove(tmp)
+}
+
+// EncodeGraphics encodes img to w using the terminal graphics type. When
+// running inside tmux or GNU screen, the escape sequences are wrapped in DCS
+// passthrough sequences so that they reach the outer terminal.
+//
+// Note: tmux 3.3+ requires "set -g allow-passthrough on".
+func EncodeGraphics(typ rasterm.TermType, w io.Writer, img image.Image) error {
+	mux := Multiplexer()
+	if mux == "" {
+		return typ.Encode(w, img)
+	}
+	buf := new(bytes.Buffer)
+	if err := typ.Encode(buf, img); err != nil {
+		return err
+	}
+	_, err := w.Write(Passthrough(mux, buf.Bytes()))
+	return err
+}
+
+// Hyperlinks returns whether terminal hyperlinks (OSC 8) are enabled for the
+// hyperlinks print variable value mode (on, off, or auto). When auto,
+// hyperlinks are enabled when the terminal is known to support them and escape
+// sequences are enabled (see Escapes).
+func Hyperlinks(mode string) bool {
+	switch {
+	case Get("TERM_ESCAPES") == "off":
+		return false
+	case mode == "on":
+		return true
+	case mode == "auto":
+		return Escapes() && hyperlinksSupported()
+	}
+	return false
+}
+
+// hyperlinksSupported returns whether the terminal is known to support
+// hyperlinks, based on the environment variables set by the terminal.
+var hyperlinksSupported = sync.OnceValue(func() bool {
+	if Multiplexer() != "" {
+		return false
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return true
+	}
+	if v, _ := strconv.Atoi(os.Getenv("VTE_VERSION")); v >= 5000 {
+		return true
+	}
+	for _, k := range []string{"KITTY_WINDOW_ID", "WT_SESSION", "KONSOLE_VERSION", "ALACRITTY_WINDOW_ID"} {
+		if os.Getenv(k) != "" {
+			return true
+		}
+	}
+	return strings.HasPrefix(os.Getenv("TERM"), "foot")
+})
+
+// Hyperlink returns s as a terminal hyperlink (OSC 8) to target when enabled
+// by the hyperlinks print variable, otherwise returns s.
+func Hyperlink(target, s string) string {
+	if !Hyperlinks(vars.prnt["hyperlinks"]) {
+		return s
+	}
+	return FormatHyperlink(target, s)
+}
+
+// FormatHyperlink returns s as a terminal hyperlink (OSC 8) to target.
+func FormatHyperlink(target, s string) string {
+	return "\x1b]8;;" + target + "\x1b\\" + s + "\x1b]8;;\x1b\\"
+}
+
+// ShellMark writes the shell integration (OSC 133) mark to w, when enabled by
+// the SHELL_INTEGRATION variable and not disabled by TERM_ESCAPES. Marks are A
+// (prompt start), C (output start), and D;<status> (command finished).
+func ShellMark(w io.Writer, mark string) {
+	switch Get("SHELL_INTEGRATION") {
+	case "on":
+		if Get("TERM_ESCAPES") == "off" {
+			return
+		}
+	case "auto":
+		if !Escapes() || !shellIntegrationSupported() {
+			return
+		}
+	default:
+		return
+	}
+	fmt.Fprint(w, "\x1b]133;"+mark+"\x07")
+}
+
+// shellIntegrationSupported returns whether the terminal is known to support
+// shell integration sequences, based on the environment variables set by the
+// terminal.
+var shellIntegrationSupported = sync.OnceValue(func() bool {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty":
+		return true
+	}
+	for _, k := range []string{"KITTY_WINDOW_ID", "WT_SESSION"} {
+		if os.Getenv(k) != "" {
+			return true
+		}
+	}
+	return strings.HasPrefix(os.Getenv("TERM"), "foot")
+})
+
+// Notify notifies the user with a terminal notification written to w (OSC 9,
+// OSC 99, or OSC 777, depending on the terminal), or with a desktop
+// notification when the terminal is not known to support notifications or
+// escape sequences are disabled (see Escapes).
+func Notify(w io.Writer, title, body string) error {
+	var seq string
+	switch term := os.Getenv("TERM"); {
+	case !Escapes():
+		return desktopNotify(title, body)
+	case os.Getenv("KITTY_WINDOW_ID") != "":
+		seq = "\x1b]99;;" + title + ": " + body + "\x1b\\"
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app", os.Getenv("TERM_PROGRAM") == "ghostty", os.Getenv("ConEmuPID") != "":
+		seq = "\x1b]9;" + title + ": " + body + "\x07"
+	case os.Getenv("TERM_PROGRAM") == "WezTerm", strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "rxvt"):
+		seq = "\x1b]777;notify;" + title + ";" + body + "\x07"
+	default:
+		return desktopNotify(title, body)
+	}
+	buf := []byte(seq)
+	if mux := Multiplexer(); mux != "" {
+		buf = Passthrough(mux, buf)
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// desktopNotify displays a desktop notification, using notify-send or
+// osascript, when available and not running in a CI environment.
+func desktopNotify(title, body string) error {
+	if CI() {
+		return nil
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", fmt.Sprintf("display notification %q with title %q", body, title))
+	default:
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return nil
+		}
+		cmd = exec.Command("notify-send", title, body)
+	}
+	return cmd.Run()
+}
+
+// Multiplexer returns the terminal multiplexer ("tmux" or "screen") usql is
+// running in, if any. A TERM of screen is not used to detect screen, as it is
+// also tmux's default TERM.
+func Multiplexer() string {
+	switch {
+	case os.Getenv("TMUX") != "", strings.HasPrefix(os.Getenv("TERM"), "tmux"):
+		return "tmux"
+	case os.Getenv("STY") != "":
+		return "screen"
+	}
+	return ""
+}
+
+// Passthrough wraps each escape sequence in buf in a DCS passthrough sequence
+// for the terminal multiplexer mux. For tmux, the escape characters of the
+// wrapped sequence are doubled. For GNU screen, sequences are split into
+// chunks, as screen limits the length of a DCS sequence, and a string
+// terminator (ST) in the wrapped sequence is split between two chunks, as
+// screen ends the DCS sequence at the first ST. The ESC ending a chunk is
+// passed through by screen, as it is followed by the closing ESC instead of
+// a backslash.
+func Passthrough(mux string, buf []byte) []byte {
+	const esc, screenChunk = 0x1b, 768
+	out := new(bytes.Buffer)
+	for _, seq := range splitEscapes(buf) {
+		switch {
+		case len(seq) == 0 || seq[0] != esc:
+			out.Write(seq)
+		case mux == "tmux":
+			out.WriteString("\x1bPtmux;")
+			out.Write(bytes.ReplaceAll(seq, []byte{esc}, []byte{esc, esc}))
+			out.WriteString("\x1b\\")
+		case mux == "screen":
+			for len(seq) != 0 {
+				n := min(screenChunk, len(seq))
+				if i := bytes.Index(seq[:n], []byte("\x1b\\")); i != -1 {
+					n = i + 1
+				}
+				out.WriteString("\x1bP")
+				out.Write(seq[:n])
+				out.WriteString("\x1b\\")
+				seq = seq[n:]
+			}
+		default:
+			out.Write(seq)
+		}
+	}
+	return out.Bytes()
+}
+
+// splitEscapes splits buf into escape sequences (ESC up to and including the
+// string terminator, BEL, or the start of the next sequence) and the text
+// between them.
+func splitEscapes(buf []byte) [][]byte {
+	var seqs [][]byte
+	for len(buf) != 0 {
+		if buf[0] != 0x1b {
+			i := bytes.IndexByte(buf, 0x1b)
+			if i == -1 {
+				i = len(buf)
+			}
+			seqs, buf = append(seqs, buf[:i]), buf[i:]
+			continue
+		}
+		end := len(buf)
+		for i := 1; i < len(buf); i++ {
+			switch {
+			case buf[i] == 0x07:
+				end = i + 1
+			case buf[i] == 0x1b && i+1 < len(buf) && buf[i+1] == '\\':
+				end = i + 2
+			case buf[i] == 0x1b:
+				end = i
+			default:
+				continue
+			}
+			break
+		}
+		seqs, buf = append(seqs, buf[:end]), buf[end:]
+	}
+	return seqs
 }
 
 // ValidIdentifier returns an error when n is not a valid identifier.
@@ -379,6 +1074,68 @@ func ParseKeywordBool(value, name string, keywords ...string) (string, error) {
 	return "", fmt.Errorf(text.FormatFieldInvalid, value, name)
 }
 
+// ColTypes are the column display types of the coltype print variable.
+var ColTypes = []string{"auto", "bool", "float", "int", "text", "timestamp"}
+
+// ParseColTypes parses a coltype print variable value (ie,
+// created_at=timestamp,amount=float), returning the display type for each
+// column name. The column name * applies to all other columns.
+func ParseColTypes(value string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		col, typ, ok := strings.Cut(s, "=")
+		col, typ = strings.TrimSpace(col), strings.ToLower(strings.TrimSpace(typ))
+		if !ok || col == "" || !slices.Contains(ColTypes, typ) {
+			return nil, fmt.Errorf(text.FormatFieldInvalidValue, s, "coltype", "COLUMN="+strings.Join(ColTypes, "|"))
+		}
+		m[col] = typ
+	}
+	return m, nil
+}
+
+// ParseColWidths parses a colwidth print variable value (ie,
+// name=20,state=10), returning the fixed width for each column name. The
+// column name * applies to all other columns. Returns nil when value is auto
+// (ie, the widths are learned).
+func ParseColWidths(value string) (map[string]int, error) {
+	if value == "auto" {
+		return nil, nil
+	}
+	m := make(map[string]int)
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		col, width, ok := strings.Cut(s, "=")
+		col = strings.TrimSpace(col)
+		n, err := strconv.Atoi(strings.TrimSpace(width))
+		if !ok || col == "" || err != nil || n <= 0 {
+			return nil, fmt.Errorf(text.FormatFieldInvalidValue, s, "colwidth", "auto or COLUMN=WIDTH")
+		}
+		m[col] = n
+	}
+	return m, nil
+}
+
+// SetColumnValue sets the value of column col in a print variable value
+// consisting of a list of COLUMN=VALUE (ie, coltype or colwidth), removing the
+// column when v is empty.
+func SetColumnValue(value, col, v string) string {
+	var cols []string
+	for _, s := range strings.Split(value, ",") {
+		if c, _, _ := strings.Cut(s, "="); s != "" && s != "auto" && strings.TrimSpace(c) != col {
+			cols = append(cols, s)
+		}
+	}
+	if v != "" {
+		cols = append(cols, col+"="+v)
+	}
+	return strings.Join(cols, ",")
+}
+
 // lineend is the line ending.
 var lineend = []byte{'\n'}
 
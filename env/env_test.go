@@ -0,0 +1,97 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseColTypes(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp map[string]string
+		err bool
+	}{
+		{"", map[string]string{}, false},
+		{"created_at=timestamp", map[string]string{"created_at": "timestamp"}, false},
+		{"created_at=timestamp,amount=float", map[string]string{"created_at": "timestamp", "amount": "float"}, false},
+		{" id = INT , *=text ,", map[string]string{"id": "int", "*": "text"}, false},
+		{"flag=bool,flag=auto", map[string]string{"flag": "auto"}, false},
+		{"amount", nil, true},
+		{"=int", nil, true},
+		{"amount=decimal", nil, true},
+		{"id=int,amount=", nil, true},
+	}
+	for i, test := range tests {
+		m, err := ParseColTypes(test.s)
+		switch {
+		case test.err && err == nil:
+			t.Errorf("test %d expected error, got: %v", i, m)
+		case !test.err && err != nil:
+			t.Errorf("test %d expected no error, got: %v", i, err)
+		case !reflect.DeepEqual(m, test.exp):
+			t.Errorf("test %d expected %v, got: %v", i, test.exp, m)
+		}
+	}
+}
+
+func TestLockFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "history")
+	// left behind by a crashed instance
+	if err := os.WriteFile(name+".lock", nil, 0o600); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	old := time.Now().Add(-2 * lockStale)
+	if err := os.Chtimes(name+".lock", old, old); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var held, overlaps atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				unlock, err := LockFile(name)
+				if err != nil {
+					t.Errorf("expected no error, got: %v", err)
+					return
+				}
+				if held.Add(1) != 1 {
+					overlaps.Add(1)
+				}
+				time.Sleep(time.Millisecond)
+				held.Add(-1)
+				unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if n := overlaps.Load(); n != 0 {
+		t.Errorf("expected lock to be held by one locker at a time, got %d overlaps", n)
+	}
+	if _, err := os.Stat(name + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("expected lock file to be removed, got: %v", err)
+	}
+}
+
+func TestForceType(t *testing.T) {
+	escapes, _ := vars.Get("TERM_ESCAPES")
+	defer vars.Set("TERM_ESCAPES", escapes)
+	typ := termGraphicsTypes("kitty")[0]
+	ForceType(typ)
+	if v, ok := TermGraphicsAvailable(); !ok || v != typ {
+		t.Errorf("expected forced type %v to be available, got: %v %t", typ, v, ok)
+	}
+	Disable()
+	if Escapes() {
+		t.Errorf("expected escape sequences to be disabled")
+	}
+	if v, ok := TermGraphicsAvailable(); ok {
+		t.Errorf("expected no terminal graphics, got: %v", v)
+	}
+}
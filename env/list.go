@@ -89,39 +89,166 @@ func (v varName) String() string {
 }
 
 var varNames = []varName{
+	{
+		`AI_MODEL`,
+		`model used by \ai (requires AI_URL)`,
+	},
+	{
+		`AI_URL`,
+		`base URL of the OpenAI-compatible endpoint used by \ai (ie, http://localhost:11434/v1)`,
+	},
+	{
+		`APPLICATION_NAME`,
+		`application name reported to the database when connecting (ie, application_name for PostgreSQL)`,
+	},
+	{
+		`AUTH_COMMAND`,
+		`command run at connect time to produce the password or token of connections to a host (see also auth_command for named connections)`,
+	},
+	{
+		`BANNER`,
+		`template of the banner shown when connecting, with ${driver}, ${version}, ${database}, ${schema}, ${encoding}, ${tls}, ${role}, ${user}, ${host}, and ${port} placeholders, or off (see also banner for named connections)`,
+	},
+	{
+		`CONFIRM_DESTRUCTIVE`,
+		`ask for confirmation before executing DROP, TRUNCATE, and UPDATE or DELETE statements without a WHERE clause, refusing them when not interactive (see \preview)`,
+	},
 	{
 		`ECHO_HIDDEN`,
 		`if set, display internal queries executed by backslash commands; if set to "noexec", shows queries without execution`,
 	},
+	{
+		`ERROR`,
+		`true when the last statement failed, otherwise false`,
+	},
+	{
+		`LAST_DURATION_MS`,
+		`duration of the last statement, in milliseconds`,
+	},
+	{
+		`LAST_ERROR`,
+		`error message of the last failed statement`,
+	},
+	{
+		`LAST_SQLSTATE`,
+		`error code (ie, SQLSTATE) of the last failed statement`,
+	},
 	{
 		`ON_ERROR_STOP`,
 		`stop batch execution after error`,
 	},
+	{
+		`OUTPUT_ATOMIC`,
+		`write output files to a temporary file, replacing the file only when complete`,
+	},
+	{
+		`OUTPUT_EXISTS`,
+		`action when an output file exists [overwrite, append, backup, error, prompt]`,
+	},
+	{
+		`OUTPUT_LINEAGE`,
+		`write query provenance for output files [off, comment, sidecar]`,
+	},
 	{
 		`PROMPT1`,
 		`specifies the standard ` + text.CommandName + ` prompt`,
 	},
+	{
+		`QUERY_TAG`,
+		`tags added as a comment to every statement for server-side attribution (see \tag)`,
+	},
 	{
 		`QUIET`,
 		`run quietly (same as -q option)`,
 	},
+	{
+		`READ_ONLY`,
+		`refuse to execute statements that modify data or schema`,
+	},
 	{
 		`ROW_COUNT`,
 		`number of rows returned or affected by last query, or 0`,
 	},
+	{
+		`SCHEMA_CACHE`,
+		`cache metadata of connections on disk for completion and describe commands (see \refresh)`,
+	},
+	{
+		`SCHEMA_CACHE_LISTEN`,
+		`channel to listen on for notifications of schema changes, invalidating cached metadata (PostgreSQL only)`,
+	},
+	{
+		`SCHEMA_CACHE_TTL`,
+		`duration cached metadata is used before being read again (default 24h)`,
+	},
+	{
+		`SHELL_INTEGRATION`,
+		`mark prompts and statement output with shell integration sequences (OSC 133), auto enables when supported by the terminal [on, off, auto]`,
+	},
+	{
+		`SQL_KEYWORD_CASE`,
+		`case of keywords in SQL generated by commands (ie, ECHO_HIDDEN queries, \fixseq, and \partitions) [preserve, upper, lower]`,
+	},
+	{
+		`SQL_QUOTE_IDENT`,
+		`quoting of identifiers in SQL generated by commands, minimal removing unneeded quotes [preserve, minimal, always]`,
+	},
+	{
+		`SQLSTATE`,
+		`error code (ie, SQLSTATE) of the last statement, or 00000 when it succeeded`,
+	},
+	{
+		`STRICT_VARS`,
+		`refuse to interpolate unquoted variables (ie, :name) into statements`,
+	},
+	{
+		`STRICT_VARS_ALLOW`,
+		`comma-separated list of variables allowed to be interpolated unquoted when STRICT_VARS is on`,
+	},
+	{
+		`TERM_ESCAPES`,
+		`enable terminal escape sequences (graphics, hyperlinks, shell integration, and notifications), auto disables when running in CI, NO_COLOR is set, or TERM is dumb [on, off, auto]`,
+	},
+	{
+		`TLS_POLICY`,
+		`refuse network connections not requiring TLS [off, require, fips] (see also allow_plaintext for named connections)`,
+	},
 }
 
 var (
-	formatRE    = regexp.MustCompile(`^(unaligned|aligned|wrapped|html|asciidoc|latex|latex-longtable|troff-ms|csv|json|vertical)$`)
 	linestlyeRE = regexp.MustCompile(`^(ascii|old-ascii|unicode)$`)
 	borderRE    = regexp.MustCompile(`^(single|double)$`)
 )
 
 var pvarNames = []varName{
+	{
+		`auto_expanded`,
+		`use expanded display for results too wide for the terminal when expanded is off [on, off]`,
+	},
+	{
+		`auto_json`,
+		`pretty print results having a single JSON column [on, off]`,
+	},
+	{
+		`auto_pager`,
+		`suggest the pager for results with at least this many rows when the pager is off, 0 to disable (default 0)`,
+	},
+	{
+		`auto_value`,
+		`print the bare value of results having a single row and column [on, off]`,
+	},
 	{
 		`border`,
 		`border style (number)`,
 	},
+	{
+		`coltype`,
+		`display types of columns, overriding the types reported by the driver, as a list of COLUMN=TYPE [auto, bool, float, int, text, timestamp] (* for all columns)`,
+	},
+	{
+		`colwidth`,
+		`fixed widths of columns for the aligned and wrapped formats, as a list of COLUMN=WIDTH (* for all columns), or auto to learn and save the widths per query`,
+	},
 	{
 		`columns`,
 		`target width for the wrapped format`,
@@ -150,10 +277,22 @@ var pvarNames = []varName{
 		`format`,
 		`set output format [unaligned, aligned, wrapped, vertical, html, asciidoc, csv, json, ...]`,
 	},
+	{
+		`hyperlinks`,
+		`display URLs in results as terminal hyperlinks (OSC 8), auto enables when supported by the terminal [on, off, auto]`,
+	},
+	{
+		`image_preview`,
+		`display PNG, JPEG, and GIF values in results as thumbnails using terminal graphics, or the maximum thumbnail size in pixels [on, off, SIZE]`,
+	},
 	{
 		`linestyle`,
 		`set the border line drawing style [ascii, old-ascii, unicode]`,
 	},
+	{
+		`notify`,
+		`notify with a terminal or desktop notification when a statement runs for at least this duration (ie, 30s), or off`,
+	},
 	{
 		`null`,
 		`set the string to be printed in place of a null value`,
@@ -210,9 +349,21 @@ var pvarNames = []varName{
 		`unicode_header_linestyle`,
 		`set the style of Unicode line drawing [single, double]`,
 	},
+	{
+		`vector_preview`,
+		`display vector columns as their dimensions, norm, and this many leading elements, 0 to disable (default 4)`,
+	},
 }
 
 var envVarNames = []varName{
+	{
+		text.CommandUpper() + `_AI_KEY, OPENAI_API_KEY`,
+		`API key sent to the language model endpoint used by \ai`,
+	},
+	{
+		text.CommandUpper() + `_APPLICATION_NAME`,
+		`default APPLICATION_NAME (default "` + text.CommandName + `")`,
+	},
 	{
 		text.CommandUpper() + `_EDITOR, EDITOR, VISUAL`,
 		`editor used by the \e, \ef, and \ev commands`,
@@ -229,10 +380,18 @@ var envVarNames = []varName{
 		text.CommandUpper() + `_PAGER, PAGER`,
 		`name of external pager program`,
 	},
+	{
+		text.CommandUpper() + `_SCHEMA_CACHE`,
+		`alternative location for the metadata cache directory (see SCHEMA_CACHE)`,
+	},
 	{
 		text.CommandUpper() + `_SHOW_HOST_INFORMATION`,
 		`display host information when connecting to a database`,
 	},
+	{
+		text.CommandUpper() + `_RECOVER`,
+		`alternative location prefix for the query buffer recovery files (see \recover)`,
+	},
 	{
 		text.CommandUpper() + `RC`,
 		`alternative location for the user's .usqlrc file`,
@@ -241,6 +400,10 @@ var envVarNames = []varName{
 		text.CommandUpper() + `_SSLMODE, SSLMODE`,
 		`when set to 'retry', allows connections to attempt to reconnect when no ?sslmode= was specified on the url`,
 	},
+	{
+		text.CommandUpper() + `_TERM_ESCAPES`,
+		`default TERM_ESCAPES`,
+	},
 	{
 		`SYNTAX_HL`,
 		`enable syntax highlighting`,
@@ -259,7 +422,11 @@ var envVarNames = []varName{
 	},
 	{
 		`TERM_GRAPHICS`,
-		`use the specified terminal graphics`,
+		`use the specified terminal graphics, or the first available of a comma-separated preference list (ie, sixel,kitty)`,
+	},
+	{
+		text.CommandUpper() + `_TLS_POLICY`,
+		`default TLS_POLICY (always fips in FIPS 140-3 mode)`,
 	},
 	{
 		`SHELL`,
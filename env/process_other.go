@@ -0,0 +1,18 @@
+//go:build !unix
+
+package env
+
+import (
+	"os"
+)
+
+// processRunning returns whether the process pid is running. On this
+// platform, finding a process fails when it is not running.
+func processRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
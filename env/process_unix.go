@@ -0,0 +1,15 @@
+//go:build unix
+
+package env
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// processRunning returns whether the process pid is running.
+func processRunning(pid int) bool {
+	err := unix.Kill(pid, 0)
+	return err == nil || errors.Is(err, unix.EPERM)
+}
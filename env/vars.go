@@ -1,6 +1,7 @@
 package env
 
 import (
+	"crypto/fips140"
 	"fmt"
 	"io"
 	"maps"
@@ -12,6 +13,7 @@ import (
 
 	syslocale "github.com/jeandeaual/go-locale"
 	"github.com/xo/terminfo"
+	"github.com/xo/usql/formats"
 	"github.com/xo/usql/text"
 )
 
@@ -23,14 +25,23 @@ type Variables struct {
 	prnt map[string]string
 	// conn holds connection variables.
 	conn map[string][]string
+	// connOpts holds the options of named connections (ie, auth_command),
+	// kept outside of the standard variables as connection names are not
+	// necessarily valid variable names.
+	connOpts map[string]map[string]string
+	// stack holds the standard and print variables saved by Push.
+	stack [][2]map[string]string
+	// local holds the print variables to restore after the next statement.
+	local map[string]string
 }
 
 // NewVars creates a set of empty variables.
 func NewVars() *Variables {
 	return &Variables{
-		vars: make(map[string]string),
-		prnt: make(map[string]string),
-		conn: make(map[string][]string),
+		vars:     make(map[string]string),
+		prnt:     make(map[string]string),
+		conn:     make(map[string][]string),
+		connOpts: make(map[string]map[string]string),
 	}
 }
 
@@ -48,6 +59,16 @@ func NewDefaultVars() *Variables {
 	if s, ok := Getenv("NO_COLOR"); ok {
 		noColor = s != "0" && s != "false" && s != "off"
 	}
+	// application name
+	applicationName, ok := Getenv(cmdNameUpper + "_APPLICATION_NAME")
+	if !ok {
+		applicationName = text.CommandName
+	}
+	// escape sequences
+	termEscapes, ok := Getenv(cmdNameUpper + "_TERM_ESCAPES")
+	if !ok {
+		termEscapes = "auto"
+	}
 	// get color level
 	colorLevel, _ := terminfo.ColorLevelFromEnv()
 	enableSyntaxHL := "true"
@@ -75,6 +96,14 @@ func NewDefaultVars() *Variables {
 	if !ok {
 		sslmode = "retry"
 	}
+	// tls policy, enforced when running in FIPS 140-3 mode
+	tlsPolicy, ok := Getenv(cmdNameUpper + "_TLS_POLICY")
+	switch {
+	case fips140.Enabled():
+		tlsPolicy = "fips"
+	case !ok:
+		tlsPolicy = "off"
+	}
 	// determine locale
 	locale := "en-US"
 	if s, err := syslocale.GetLocale(); err == nil {
@@ -84,22 +113,44 @@ func NewDefaultVars() *Variables {
 		vars: map[string]string{
 			// usql related logic
 			"SHOW_HOST_INFORMATION": showHostInformation,
+			"APPLICATION_NAME":      applicationName,
 			"PAGER":                 pagerCmd,
 			"EDITOR":                editorCmd,
 			"QUIET":                 "off",
+			"QUERY_TAG":             "",
+			"CONFIRM_DESTRUCTIVE":   "off",
 			"ON_ERROR_STOP":         "off",
+			"OUTPUT_ATOMIC":         "off",
+			"OUTPUT_EXISTS":         "overwrite",
+			"OUTPUT_LINEAGE":        "off",
+			"READ_ONLY":             "off",
+			"SCHEMA_CACHE":          "off",
+			"SCHEMA_CACHE_LISTEN":   "",
+			"SCHEMA_CACHE_TTL":      "24h",
+			"SHELL_INTEGRATION":     "auto",
+			"SQL_KEYWORD_CASE":      "preserve",
+			"SQL_QUOTE_IDENT":       "preserve",
+			"STRICT_VARS":           "off",
 			// prompts
-			"PROMPT1": "%S%N%m%/%R%# ",
+			"PROMPT1": "%S%N%m%/%T%I%R%# ",
 			// syntax highlighting variables
 			"SYNTAX_HL":             enableSyntaxHL,
 			"SYNTAX_HL_FORMAT":      colorLevel.ChromaFormatterName(),
 			"SYNTAX_HL_STYLE":       "monokai",
 			"SYNTAX_HL_OVERRIDE_BG": "true",
 			"SSLMODE":               sslmode,
+			"TLS_POLICY":            tlsPolicy,
+			"TERM_ESCAPES":          termEscapes,
 			"TERM_GRAPHICS":         "none",
 		},
 		prnt: map[string]string{
+			"auto_expanded":            "off",
+			"auto_json":                "off",
+			"auto_pager":               "0",
+			"auto_value":               "off",
 			"border":                   "1",
+			"coltype":                  "",
+			"colwidth":                 "",
 			"columns":                  "0",
 			"csv_fieldsep":             ",",
 			"expanded":                 "off",
@@ -107,8 +158,11 @@ func NewDefaultVars() *Variables {
 			"fieldsep_zero":            "off",
 			"footer":                   "on",
 			"format":                   "aligned",
+			"hyperlinks":               "auto",
+			"image_preview":            "off",
 			"linestyle":                "ascii",
 			"locale":                   locale,
+			"notify":                   "",
 			"null":                     "",
 			"numericlocale":            "off",
 			"pager_min_lines":          "0",
@@ -123,8 +177,10 @@ func NewDefaultVars() *Variables {
 			"unicode_border_linestyle": "single",
 			"unicode_column_linestyle": "single",
 			"unicode_header_linestyle": "single",
+			"vector_preview":           "4",
 		},
-		conn: make(map[string][]string),
+		conn:     make(map[string][]string),
+		connOpts: make(map[string]map[string]string),
 	}
 }
 
@@ -155,7 +211,7 @@ func (v *Variables) Set(name, value string) error {
 		return err
 	}
 	switch name {
-	case "ON_ERROR_STOP", "QUIET":
+	case "CONFIRM_DESTRUCTIVE", "ON_ERROR_STOP", "OUTPUT_ATOMIC", "QUIET", "READ_ONLY", "SCHEMA_CACHE", "STRICT_VARS":
 		if value == "" {
 			value = "on"
 		} else {
@@ -164,6 +220,46 @@ func (v *Variables) Set(name, value string) error {
 				return err
 			}
 		}
+	case "OUTPUT_EXISTS":
+		switch value {
+		case "overwrite", "append", "backup", "error", "prompt":
+		default:
+			return text.ErrInvalidOutputExists
+		}
+	case "OUTPUT_LINEAGE":
+		switch value {
+		case "off", "comment", "sidecar":
+		default:
+			return text.ErrInvalidOutputLineage
+		}
+	case "SQL_KEYWORD_CASE":
+		switch value {
+		case "preserve", "upper", "lower":
+		default:
+			return text.ErrInvalidKeywordCase
+		}
+	case "SQL_QUOTE_IDENT":
+		switch value {
+		case "preserve", "minimal", "always":
+		default:
+			return text.ErrInvalidQuoteIdent
+		}
+	case "SHELL_INTEGRATION", "TERM_ESCAPES":
+		var err error
+		if value, err = ParseKeywordBool(value, name, "auto"); err != nil {
+			return err
+		}
+	case "SCHEMA_CACHE_TTL":
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return text.ErrInvalidSchemaCacheTTL
+		}
+	case "TLS_POLICY":
+		switch {
+		case fips140.Enabled() && value != "fips":
+			return text.ErrTLSPolicyFIPSRequired
+		case value != "off" && value != "require" && value != "fips":
+			return text.ErrInvalidTLSPolicy
+		}
 	}
 	v.vars[name] = value
 	return nil
@@ -178,6 +274,42 @@ func (v *Variables) Unset(name string) error {
 	return nil
 }
 
+// Push saves the standard and print variables, to be restored by Pop.
+func (v *Variables) Push() {
+	v.stack = append(v.stack, [2]map[string]string{maps.Clone(v.vars), maps.Clone(v.prnt)})
+}
+
+// Pop restores the standard and print variables saved by the last Push.
+func (v *Variables) Pop() error {
+	if len(v.stack) == 0 {
+		return text.ErrNoPushedVariables
+	}
+	n := len(v.stack) - 1
+	v.vars, v.prnt, v.stack = v.stack[n][0], v.stack[n][1], v.stack[:n]
+	return nil
+}
+
+// SaveLocal saves the print variable's value, to be restored by
+// RestoreLocal.
+func (v *Variables) SaveLocal(name string) {
+	val, ok := v.prnt[name]
+	if !ok {
+		return
+	}
+	if v.local == nil {
+		v.local = make(map[string]string)
+	}
+	if _, ok := v.local[name]; !ok {
+		v.local[name] = val
+	}
+}
+
+// RestoreLocal restores the print variables saved by SaveLocal.
+func (v *Variables) RestoreLocal() {
+	maps.Copy(v.prnt, v.local)
+	v.local = nil
+}
+
 // Dump dumps the standard variables to w.
 func (v *Variables) Dump(w io.Writer) error {
 	for _, k := range slices.Sorted(maps.Keys(v.vars)) {
@@ -200,7 +332,7 @@ func (v *Variables) SetPrint(name, value string) (string, error) {
 		return "", fmt.Errorf(text.UnknownFormatFieldName, name)
 	}
 	switch name {
-	case "border", "columns", "pager_min_lines":
+	case "auto_pager", "border", "columns", "pager_min_lines", "vector_preview":
 		i, _ := strconv.Atoi(value)
 		v.prnt[name] = fmt.Sprintf("%d", i)
 	case "pager":
@@ -215,15 +347,31 @@ func (v *Variables) SetPrint(name, value string) (string, error) {
 			return "", text.ErrInvalidFormatExpandedType
 		}
 		v.prnt[name] = s
-	case "fieldsep_zero", "footer", "numericlocale", "recordsep_zero", "tuples_only":
+	case "hyperlinks":
+		s, err := ParseKeywordBool(value, name, "auto")
+		if err != nil {
+			return "", err
+		}
+		v.prnt[name] = s
+	case "image_preview":
+		if i, err := strconv.Atoi(value); err == nil && i > 0 {
+			v.prnt[name] = strconv.Itoa(i)
+			break
+		}
+		s, err := ParseBool(value, name)
+		if err != nil {
+			return "", err
+		}
+		v.prnt[name] = s
+	case "auto_expanded", "auto_json", "auto_value", "fieldsep_zero", "footer", "numericlocale", "recordsep_zero", "tuples_only":
 		s, err := ParseBool(value, name)
 		if err != nil {
 			return "", err
 		}
 		v.prnt[name] = s
 	case "format":
-		if !formatRE.MatchString(value) {
-			return "", text.ErrInvalidFormatType
+		if !formats.Registered(value) {
+			return "", fmt.Errorf(text.InvalidFormatType, strings.Join(formats.Names(), ", "))
 		}
 		v.prnt[name] = value
 	case "linestyle":
@@ -233,6 +381,40 @@ func (v *Variables) SetPrint(name, value string) (string, error) {
 		v.prnt[name] = value
 	case "csv_fieldsep", "fieldsep", "null", "recordsep", "tableattr", "time", "title", "locale":
 		v.prnt[name] = value
+	case "coltype":
+		m, err := ParseColTypes(value)
+		if err != nil {
+			return "", err
+		}
+		cols := make([]string, 0, len(m))
+		for _, col := range slices.Sorted(maps.Keys(m)) {
+			cols = append(cols, col+"="+m[col])
+		}
+		v.prnt[name] = strings.Join(cols, ",")
+	case "colwidth":
+		m, err := ParseColWidths(value)
+		switch {
+		case err != nil:
+			return "", err
+		case m == nil:
+			v.prnt[name] = value
+		default:
+			cols := make([]string, 0, len(m))
+			for _, col := range slices.Sorted(maps.Keys(m)) {
+				cols = append(cols, col+"="+strconv.Itoa(m[col]))
+			}
+			v.prnt[name] = strings.Join(cols, ",")
+		}
+	case "notify":
+		switch value {
+		case "", "0", "off":
+			value = ""
+		default:
+			if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+				return "", text.ErrInvalidNotifyDuration
+			}
+		}
+		v.prnt[name] = value
 	case "timezone":
 		if _, err := time.LoadLocation(value); err != nil {
 			return "", text.ErrInvalidTimezoneLocation
@@ -255,7 +437,7 @@ func (v *Variables) TogglePrint(name, extra string) (string, error) {
 		return "", fmt.Errorf(text.UnknownFormatFieldName, name)
 	}
 	switch name {
-	case "border", "columns", "pager_min_lines":
+	case "auto_pager", "border", "columns", "pager_min_lines", "vector_preview":
 	case "pager":
 		switch v.prnt[name] {
 		case "on", "always":
@@ -265,7 +447,7 @@ func (v *Variables) TogglePrint(name, extra string) (string, error) {
 		default:
 			panic(fmt.Sprintf("invalid state for field %s", name))
 		}
-	case "expanded":
+	case "expanded", "hyperlinks":
 		switch v.prnt[name] {
 		case "on", "auto":
 			v.prnt[name] = "off"
@@ -274,7 +456,13 @@ func (v *Variables) TogglePrint(name, extra string) (string, error) {
 		default:
 			panic(fmt.Sprintf("invalid state for field %s", name))
 		}
-	case "fieldsep_zero", "footer", "numericlocale", "recordsep_zero", "tuples_only":
+	case "image_preview":
+		if v.prnt[name] == "off" {
+			v.prnt[name] = "on"
+		} else {
+			v.prnt[name] = "off"
+		}
+	case "auto_expanded", "auto_json", "auto_value", "fieldsep_zero", "footer", "numericlocale", "recordsep_zero", "tuples_only":
 		switch v.prnt[name] {
 		case "on":
 			v.prnt[name] = "off"
@@ -294,7 +482,7 @@ func (v *Variables) TogglePrint(name, extra string) (string, error) {
 		}
 	case "linestyle":
 	case "csv_fieldsep", "fieldsep", "null", "recordsep", "time", "timezone", "locale":
-	case "tableattr", "title":
+	case "coltype", "colwidth", "notify", "tableattr", "title":
 		v.prnt[name] = ""
 	case "unicode_border_linestyle", "unicode_column_linestyle", "unicode_header_linestyle":
 	default:
@@ -348,6 +536,28 @@ func (v *Variables) SetConn(name string, vals ...string) error {
 	return nil
 }
 
+// SetConnOpt sets the option opt (ie, auth_command) of the named connection
+// name. An empty value unsets the option.
+func (v *Variables) SetConnOpt(name, opt, value string) {
+	if value == "" {
+		delete(v.connOpts[name], opt)
+		return
+	}
+	if v.connOpts[name] == nil {
+		v.connOpts[name] = make(map[string]string)
+	}
+	v.connOpts[name][opt] = value
+}
+
+// ConnOpt returns the option opt of the named connection name, when name is a
+// defined connection variable.
+func (v *Variables) ConnOpt(name, opt string) string {
+	if _, ok := v.conn[name]; !ok {
+		return ""
+	}
+	return v.connOpts[name][opt]
+}
+
 // GetConn returns a connection variable.
 func (v *Variables) GetConn(name string) ([]string, bool) {
 	vals, ok := v.conn[name]
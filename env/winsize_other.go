@@ -0,0 +1,10 @@
+//go:build !unix
+
+package env
+
+// TermSize returns the size of the terminal attached to standard output, in
+// columns and rows, and in pixels when reported by the terminal. Always
+// returns zeros on this platform.
+func TermSize() (int, int, int, int) {
+	return 0, 0, 0, 0
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package env
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// TermSize returns the size of the terminal attached to standard output, in
+// columns and rows, and in pixels when reported by the terminal. Returns
+// zeros when standard output is not a terminal.
+func TermSize() (int, int, int, int) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, 0, 0
+	}
+	return int(ws.Col), int(ws.Row), int(ws.Xpixel), int(ws.Ypixel)
+}
@@ -0,0 +1,549 @@
+package formats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v17/arrow"
+	"github.com/apache/arrow/go/v17/arrow/array"
+	"github.com/apache/arrow/go/v17/arrow/decimal128"
+	"github.com/apache/arrow/go/v17/arrow/ipc"
+	"github.com/apache/arrow/go/v17/arrow/memory"
+	"github.com/apache/arrow/go/v17/parquet"
+	"github.com/apache/arrow/go/v17/parquet/compress"
+	"github.com/apache/arrow/go/v17/parquet/pqarrow"
+	"github.com/xo/usql/text"
+)
+
+func init() {
+	RegisterWriter("arrow", newArrowWriter("arrow"))
+	RegisterWriter("parquet", newArrowWriter("parquet"))
+}
+
+// arrowBatchSize is the number of rows of the written record batches (and
+// parquet row groups). Only a single batch of rows is held in memory.
+const arrowBatchSize = 64 * 1024
+
+// newArrowWriter returns a func creating format writers writing result sets as
+// an Apache Arrow IPC (arrow) or Apache Parquet (parquet) file.
+func newArrowWriter(format string) NewWriterFunc {
+	return func(w io.Writer, params map[string]string) (Writer, error) {
+		return newFileWriter(w, format, params, func(w io.Writer) file {
+			// hide w's Close, as the record writers close their writer
+			return &arrowFile{format: format, w: struct{ io.Writer }{w}}
+		}), nil
+	}
+}
+
+// recordWriter is the shared interface of the arrow and parquet record batch
+// writers.
+type recordWriter interface {
+	Write(arrow.Record) error
+	Close() error
+}
+
+// arrowFile is an arrow or parquet file, written as record batches.
+//
+// The schema of the file is the arrow types of the first result set's column
+// types (see arrowType). Column types that are not known are inferred from the
+// values of the first batch of rows, which are held until the schema is known.
+// All result sets written to the file must have the same columns.
+type arrowFile struct {
+	format string
+	w      io.Writer
+	// cols are the columns of the first result set.
+	cols   []Column
+	header bool
+	// pending are the rows written before the schema is known.
+	pending [][]interface{}
+	rw      recordWriter
+	b       *array.RecordBuilder
+	// n is the number of rows of the current batch.
+	n int
+}
+
+// Header satisfies the file interface.
+func (f *arrowFile) Header(cols []Column, _ map[string]string) error {
+	if !f.header {
+		f.cols, f.header = cols, true
+		return nil
+	}
+	if len(cols) != len(f.cols) {
+		return fmt.Errorf(text.FormatColumnsMismatch, f.format)
+	}
+	for i, col := range cols {
+		if col.Name != f.cols[i].Name {
+			return fmt.Errorf(text.FormatColumnsMismatch, f.format)
+		}
+	}
+	return nil
+}
+
+// Row satisfies the file interface.
+func (f *arrowFile) Row(vals []interface{}) error {
+	if f.rw != nil {
+		return f.append(vals)
+	}
+	f.pending = append(f.pending, append([]interface{}(nil), vals...))
+	if len(f.pending) < arrowBatchSize {
+		return nil
+	}
+	return f.open()
+}
+
+// open determines the schema, and opens the record writer, writing the
+// pending rows.
+func (f *arrowFile) open() error {
+	fields := make([]arrow.Field, len(f.cols))
+	for i, col := range f.cols {
+		vals := make([]interface{}, len(f.pending))
+		for j, row := range f.pending {
+			vals[j] = row[i]
+		}
+		fields[i] = arrow.Field{Name: col.Name, Type: arrowType(col, vals), Nullable: true}
+	}
+	schema := arrow.NewSchema(fields, nil)
+	var err error
+	switch f.format {
+	case "parquet":
+		f.rw, err = pqarrow.NewFileWriter(
+			schema, f.w,
+			parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy)),
+			// keep the arrow schema (ie, timestamp time zones)
+			pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()),
+		)
+	default:
+		f.rw, err = ipc.NewFileWriter(f.w, ipc.WithSchema(schema))
+	}
+	if err != nil {
+		return err
+	}
+	f.b = array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	pending := f.pending
+	f.pending = nil
+	for _, row := range pending {
+		if err := f.append(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// append appends a row to the current batch, writing the batch when full.
+func (f *arrowFile) append(vals []interface{}) error {
+	for i, v := range vals {
+		if b := f.b.Field(i); !arrowAppend(b, v) {
+			return fmt.Errorf(text.FormatInvalidValue, f.format, arrowString(v), f.cols[i].Name, b.Type())
+		}
+	}
+	if f.n++; f.n < arrowBatchSize {
+		return nil
+	}
+	return f.write()
+}
+
+// write writes the current batch.
+func (f *arrowFile) write() error {
+	if f.n == 0 {
+		return nil
+	}
+	rec := f.b.NewRecord()
+	defer rec.Release()
+	f.n = 0
+	return f.rw.Write(rec)
+}
+
+// Flush satisfies the file interface.
+func (f *arrowFile) Flush() error {
+	if f.rw == nil {
+		return nil
+	}
+	return f.write()
+}
+
+// Close satisfies the file interface.
+func (f *arrowFile) Close() error {
+	if f.rw == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	defer f.b.Release()
+	if err := f.write(); err != nil {
+		f.rw.Close()
+		return err
+	}
+	return f.rw.Close()
+}
+
+// arrowType returns the arrow type for the column's database type, or the
+// type inferred from the column's values when the database type is not known.
+func arrowType(col Column, vals []interface{}) arrow.DataType {
+	switch strings.ToUpper(col.Type) {
+	case "BOOL", "BOOLEAN", "BIT":
+		return arrow.FixedWidthTypes.Boolean
+	case "INT2", "SMALLINT", "TINYINT", "YEAR":
+		return arrow.PrimitiveTypes.Int16
+	case "INT4", "INT", "INTEGER", "MEDIUMINT":
+		return arrow.PrimitiveTypes.Int32
+	case "INT8", "BIGINT":
+		return arrow.PrimitiveTypes.Int64
+	case "FLOAT4", "REAL", "BINARY_FLOAT":
+		return arrow.PrimitiveTypes.Float32
+	case "FLOAT8", "FLOAT", "DOUBLE", "DOUBLE PRECISION", "BINARY_DOUBLE":
+		return arrow.PrimitiveTypes.Float64
+	case "NUMERIC", "DECIMAL", "NUMBER":
+		if 0 < col.Precision && col.Precision <= 38 && 0 <= col.Scale && col.Scale <= col.Precision {
+			return &arrow.Decimal128Type{Precision: int32(col.Precision), Scale: int32(col.Scale)}
+		}
+		// unconstrained decimals are written as text, without loss
+		return arrow.BinaryTypes.String
+	case "DATE":
+		// dates with times (ie, oracle)
+		for _, v := range vals {
+			if t, ok := v.(time.Time); ok && t.Hour()+t.Minute()+t.Second()+t.Nanosecond() != 0 {
+				return &arrow.TimestampType{Unit: arrow.Microsecond}
+			}
+		}
+		return arrow.FixedWidthTypes.Date32
+	case "TIMESTAMP", "DATETIME", "DATETIME2", "SMALLDATETIME":
+		return &arrow.TimestampType{Unit: arrow.Microsecond}
+	case "TIMESTAMPTZ", "TIMESTAMP WITH TIME ZONE", "DATETIMEOFFSET":
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+	case "BYTEA", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "IMAGE", "RAW":
+		return arrow.BinaryTypes.Binary
+	case "TEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT", "CHAR", "BPCHAR", "VARCHAR", "NCHAR", "NVARCHAR",
+		"VARCHAR2", "NVARCHAR2", "NTEXT", "CLOB", "NCLOB", "NAME", "JSON", "JSONB", "XML", "UUID",
+		"UNIQUEIDENTIFIER", "INTERVAL", "TIME", "TIMETZ", "ENUM", "SET", "MONEY":
+		return arrow.BinaryTypes.String
+	}
+	return arrowInfer(vals)
+}
+
+// arrowInfer returns the arrow type inferred from the values. Lists and maps
+// (as returned by drivers supporting nested types) are inferred as list and
+// struct types. Values of mixed types are inferred as text.
+func arrowInfer(vals []interface{}) arrow.DataType {
+	var kind string
+	for _, v := range vals {
+		var k string
+		switch v.(type) {
+		case nil:
+			continue
+		case bool:
+			k = "bool"
+		case int, int8, int16, int32, int64, uint8, uint16, uint32:
+			k = "int"
+		case float32, float64:
+			k = "float"
+		case time.Time:
+			k = "time"
+		case []byte:
+			k = "bytes"
+		case []interface{}:
+			k = "list"
+		case map[string]interface{}:
+			k = "struct"
+		default:
+			k = "text"
+		}
+		switch {
+		case kind == "" || kind == k:
+			kind = k
+		case kind == "int" && k == "float", kind == "float" && k == "int":
+			kind = "float"
+		default:
+			kind = "text"
+		}
+	}
+	switch kind {
+	case "bool":
+		return arrow.FixedWidthTypes.Boolean
+	case "int":
+		return arrow.PrimitiveTypes.Int64
+	case "float":
+		return arrow.PrimitiveTypes.Float64
+	case "time":
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+	case "bytes":
+		return arrow.BinaryTypes.Binary
+	case "list":
+		var elems []interface{}
+		for _, v := range vals {
+			if x, ok := v.([]interface{}); ok {
+				elems = append(elems, x...)
+			}
+		}
+		return arrow.ListOf(arrowInfer(elems))
+	case "struct":
+		fields := make(map[string][]interface{})
+		for _, v := range vals {
+			if x, ok := v.(map[string]interface{}); ok {
+				for k, e := range x {
+					fields[k] = append(fields[k], e)
+				}
+			}
+		}
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		typ := make([]arrow.Field, len(names))
+		for i, name := range names {
+			typ[i] = arrow.Field{Name: name, Type: arrowInfer(fields[name]), Nullable: true}
+		}
+		return arrow.StructOf(typ...)
+	}
+	return arrow.BinaryTypes.String
+}
+
+// arrowAppend appends the value to the builder, converting the value to the
+// builder's type. Returns false when the value cannot be converted.
+func arrowAppend(b array.Builder, v interface{}) bool {
+	if v == nil {
+		b.AppendNull()
+		return true
+	}
+	switch b := b.(type) {
+	case *array.BooleanBuilder:
+		x, ok := arrowBool(v)
+		if ok {
+			b.Append(x)
+		}
+		return ok
+	case *array.Int16Builder:
+		x, ok := arrowInt(v, 16)
+		if ok {
+			b.Append(int16(x))
+		}
+		return ok
+	case *array.Int32Builder:
+		x, ok := arrowInt(v, 32)
+		if ok {
+			b.Append(int32(x))
+		}
+		return ok
+	case *array.Int64Builder:
+		x, ok := arrowInt(v, 64)
+		if ok {
+			b.Append(x)
+		}
+		return ok
+	case *array.Float32Builder:
+		x, ok := arrowFloat(v)
+		if ok {
+			b.Append(float32(x))
+		}
+		return ok
+	case *array.Float64Builder:
+		x, ok := arrowFloat(v)
+		if ok {
+			b.Append(x)
+		}
+		return ok
+	case *array.Decimal128Builder:
+		typ := b.Type().(*arrow.Decimal128Type)
+		x, err := decimal128.FromString(strings.TrimSpace(arrowString(v)), typ.Precision, typ.Scale)
+		if err == nil {
+			b.Append(x)
+		}
+		return err == nil
+	case *array.Date32Builder:
+		t, ok := arrowTime(v)
+		if ok {
+			b.Append(arrow.Date32FromTime(arrowWallClock(t)))
+		}
+		return ok
+	case *array.TimestampBuilder:
+		t, ok := arrowTime(v)
+		if !ok {
+			return false
+		}
+		typ := b.Type().(*arrow.TimestampType)
+		if typ.TimeZone == "" {
+			t = arrowWallClock(t)
+		}
+		x, err := arrow.TimestampFromTime(t.UTC(), typ.Unit)
+		if err == nil {
+			b.Append(x)
+		}
+		return err == nil
+	case *array.BinaryBuilder:
+		switch x := v.(type) {
+		case []byte:
+			b.Append(x)
+		case string:
+			b.AppendString(x)
+		default:
+			return false
+		}
+		return true
+	case *array.StringBuilder:
+		b.Append(arrowString(v))
+		return true
+	case *array.ListBuilder:
+		x, ok := v.([]interface{})
+		if !ok {
+			return false
+		}
+		b.Append(true)
+		for _, e := range x {
+			if !arrowAppend(b.ValueBuilder(), e) {
+				return false
+			}
+		}
+		return true
+	case *array.StructBuilder:
+		x, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		b.Append(true)
+		for i, field := range b.Type().(*arrow.StructType).Fields() {
+			if !arrowAppend(b.FieldBuilder(i), x[field.Name]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// arrowBool converts the value to a bool.
+func arrowBool(v interface{}) (bool, bool) {
+	switch x := v.(type) {
+	case bool:
+		return x, true
+	case []byte:
+		// bit(1) (ie, mysql)
+		if len(x) == 1 && x[0] <= 1 {
+			return x[0] == 1, true
+		}
+		return arrowBool(string(x))
+	case string:
+		b, err := strconv.ParseBool(x)
+		return b, err == nil
+	}
+	if n, ok := arrowInt(v, 64); ok {
+		return n != 0, true
+	}
+	return false, false
+}
+
+// arrowInt converts the value to an integer of the bit size.
+func arrowInt(v interface{}, bits int) (int64, bool) {
+	var n int64
+	switch x := reflect.ValueOf(v); x.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = x.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if x.Uint() > math.MaxInt64 {
+			return 0, false
+		}
+		n = int64(x.Uint())
+	case reflect.Float32, reflect.Float64:
+		f := x.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, false
+		}
+		n = int64(f)
+	case reflect.String:
+		var err error
+		if n, err = strconv.ParseInt(strings.TrimSpace(x.String()), 10, 64); err != nil {
+			return 0, false
+		}
+	case reflect.Slice:
+		b, ok := v.([]byte)
+		if !ok {
+			return 0, false
+		}
+		return arrowInt(string(b), bits)
+	default:
+		return 0, false
+	}
+	if bits < 64 && (n < -1<<(bits-1) || n >= 1<<(bits-1)) {
+		return 0, false
+	}
+	return n, true
+}
+
+// arrowFloat converts the value to a float.
+func arrowFloat(v interface{}) (float64, bool) {
+	switch x := reflect.ValueOf(v); x.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return x.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(x.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(x.Uint()), true
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x.String()), 64)
+		return f, err == nil
+	}
+	if b, ok := v.([]byte); ok {
+		return arrowFloat(string(b))
+	}
+	return 0, false
+}
+
+// arrowTimeLayouts are the layouts of date and time text values.
+var arrowTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	time.DateOnly,
+}
+
+// arrowTime converts the value to a time.
+func arrowTime(v interface{}) (time.Time, bool) {
+	var s string
+	switch x := v.(type) {
+	case time.Time:
+		return x, true
+	case []byte:
+		s = string(x)
+	case string:
+		s = x
+	default:
+		return time.Time{}, false
+	}
+	for _, layout := range arrowTimeLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// arrowWallClock returns the wall clock time of t, in UTC.
+func arrowWallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// arrowString converts the value to text. Lists and maps are converted to
+// JSON.
+func arrowString(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case []interface{}, map[string]interface{}:
+		if buf, err := json.Marshal(x); err == nil {
+			return string(buf)
+		}
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,293 @@
+// Package formats is the registry of the named output formats used to display
+// query results (ie, \pset format <name>).
+//
+// The built-in formats are encoded by tblfmt. Additional formats can be
+// registered by plugins with Register or RegisterWriter.
+package formats
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xo/tblfmt"
+	"github.com/xo/usql/text"
+)
+
+// EncodeFunc is the func signature for encoding a result set to a writer
+// using the print params.
+type EncodeFunc func(w io.Writer, rs tblfmt.ResultSet, params map[string]string, opts ...tblfmt.Option) error
+
+// Column is a result set column.
+type Column struct {
+	// Name is the column name.
+	Name string
+	// Type is the database type name of the column, if known.
+	Type string
+	// Nullable is whether or not the column is nullable, if known.
+	Nullable bool
+	// Precision and Scale are the precision and scale of a decimal column, if
+	// known.
+	Precision, Scale int64
+}
+
+// Writer is the interface for output format writers, receiving typed rows.
+//
+// Values passed to Row are as returned by the database driver (ie, int64,
+// float64, bool, []byte, string, time.Time, or nil).
+type Writer interface {
+	// Header writes the header for a result set. Called once per result set,
+	// before the result set's rows.
+	Header(cols []Column) error
+	// Row writes a row.
+	Row(vals []interface{}) error
+	// Close finishes writing.
+	Close() error
+}
+
+// NewWriterFunc is the func signature for creating a format writer that
+// writes to w using the print params.
+type NewWriterFunc func(w io.Writer, params map[string]string) (Writer, error)
+
+// formats are the registered formats.
+var formats = make(map[string]EncodeFunc)
+
+// Register registers the format name with the encode func f.
+func Register(name string, f EncodeFunc) {
+	if _, ok := formats[name]; ok {
+		panic(fmt.Sprintf("format %s is already registered", name))
+	}
+	formats[name] = f
+}
+
+// RegisterWriter registers the format name with the writer func f.
+func RegisterWriter(name string, f NewWriterFunc) {
+	Register(name, func(w io.Writer, rs tblfmt.ResultSet, params map[string]string, _ ...tblfmt.Option) error {
+		fw, err := f(w, params)
+		if err != nil {
+			return err
+		}
+		if err := writeAll(fw, rs); err != nil {
+			fw.Close()
+			return err
+		}
+		return fw.Close()
+	})
+}
+
+// Registered returns whether or not a format is registered.
+func Registered(name string) bool {
+	_, ok := formats[name]
+	return ok
+}
+
+// Names returns the sorted names of the registered formats.
+func Names() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// outputFormats are the formats of output file extensions.
+var outputFormats = map[string]string{
+	".arrow":   "arrow",
+	".feather": "arrow",
+	".parquet": "parquet",
+	".xlsx":    "xlsx",
+}
+
+// OutputFormat returns the format for the output file name (ie, xlsx for
+// file.xlsx), or empty when the file's extension has no format.
+func OutputFormat(name string) string {
+	return outputFormats[strings.ToLower(filepath.Ext(name))]
+}
+
+// Output is a query output file (ie, \o file.xlsx) written with a format.
+// Format writers writing to an output can accumulate the results of multiple
+// queries, writing them when the output is closed (ie, the sheets of a xlsx
+// workbook).
+type Output struct {
+	io.WriteCloser
+	format string
+	file   file
+}
+
+// NewOutput creates a query output writing to w with the format.
+func NewOutput(w io.WriteCloser, format string) *Output {
+	return &Output{WriteCloser: w, format: format}
+}
+
+// Format returns the output's format.
+func (o *Output) Format() string {
+	return o.format
+}
+
+// Write satisfies the io.Writer interface. Text written to the output (ie,
+// command tags, or \qecho) is discarded, as it cannot be represented by the
+// output's format.
+func (o *Output) Write(buf []byte) (int, error) {
+	return len(buf), nil
+}
+
+// Close finishes writing the output, and closes the underlying writer.
+func (o *Output) Close() error {
+	if o.file != nil {
+		if err := o.file.Close(); err != nil {
+			o.WriteCloser.Close()
+			return err
+		}
+	}
+	return o.WriteCloser.Close()
+}
+
+// file is a format's output file, containing the result sets of one or more
+// queries.
+type file interface {
+	// Header starts a result set, using the query's print params.
+	Header(cols []Column, params map[string]string) error
+	// Row writes a row.
+	Row(vals []interface{}) error
+	// Flush finishes writing a query's result sets.
+	Flush() error
+	// Close finishes writing the file.
+	Close() error
+}
+
+// fileWriter is a format writer writing to a format's file.
+type fileWriter struct {
+	f      file
+	params map[string]string
+	// own is whether the writer owns the file, closing it when closed.
+	own bool
+}
+
+// newFileWriter creates a format writer writing to the file created by f.
+// When w is an output with the format (see NewOutput), the file is shared by
+// the writers of all queries written to the output, and is closed with the
+// output.
+func newFileWriter(w io.Writer, format string, params map[string]string, f func(io.Writer) file) Writer {
+	if o, ok := w.(*Output); ok && o.format == format {
+		if o.file == nil {
+			o.file = f(o.WriteCloser)
+		}
+		return &fileWriter{f: o.file, params: params}
+	}
+	return &fileWriter{f: f(w), params: params, own: true}
+}
+
+// Header satisfies the Writer interface.
+func (w *fileWriter) Header(cols []Column) error {
+	return w.f.Header(cols, w.params)
+}
+
+// Row satisfies the Writer interface.
+func (w *fileWriter) Row(vals []interface{}) error {
+	return w.f.Row(vals)
+}
+
+// Close satisfies the Writer interface.
+func (w *fileWriter) Close() error {
+	if !w.own {
+		return w.f.Flush()
+	}
+	return w.f.Close()
+}
+
+// EncodeAll encodes all result sets in rs to w using the format specified by
+// params["format"].
+func EncodeAll(w io.Writer, rs tblfmt.ResultSet, params map[string]string, opts ...tblfmt.Option) error {
+	name := params["format"]
+	if name == "" {
+		name = "aligned"
+	}
+	f, ok := formats[name]
+	if !ok {
+		return fmt.Errorf(text.UnknownFormat, name)
+	}
+	return f(w, rs, params, opts...)
+}
+
+// writeAll writes all result sets in rs to fw.
+func writeAll(fw Writer, rs tblfmt.ResultSet) error {
+	for {
+		cols, err := columns(rs)
+		if err != nil {
+			return err
+		}
+		if err := fw.Header(cols); err != nil {
+			return err
+		}
+		vals, ptrs := make([]interface{}, len(cols)), make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		for rs.Next() {
+			if err := rs.Scan(ptrs...); err != nil {
+				return err
+			}
+			if err := fw.Row(vals); err != nil {
+				return err
+			}
+		}
+		if err := rs.Err(); err != nil {
+			return err
+		}
+		if !rs.NextResultSet() {
+			return nil
+		}
+	}
+}
+
+// columns returns the columns of the result set, including the column types
+// when available.
+func columns(rs tblfmt.ResultSet) ([]Column, error) {
+	names, err := rs.Columns()
+	if err != nil {
+		return nil, err
+	}
+	cols := make([]Column, len(names))
+	for i, name := range names {
+		cols[i].Name = name
+	}
+	if ct, ok := rs.(interface {
+		ColumnTypes() ([]*sql.ColumnType, error)
+	}); ok {
+		if types, err := ct.ColumnTypes(); err == nil && len(types) == len(cols) {
+			for i, typ := range types {
+				cols[i].Type = typ.DatabaseTypeName()
+				cols[i].Nullable, _ = typ.Nullable()
+				cols[i].Precision, cols[i].Scale, _ = typ.DecimalSize()
+			}
+		}
+	}
+	return cols, nil
+}
+
+// encode encodes the result set using tblfmt.
+func encode(w io.Writer, rs tblfmt.ResultSet, params map[string]string, opts ...tblfmt.Option) error {
+	return tblfmt.EncodeAll(w, rs, params, opts...)
+}
+
+func init() {
+	for _, name := range []string{
+		"aligned",
+		"asciidoc",
+		"csv",
+		"html",
+		"json",
+		"latex",
+		"latex-longtable",
+		"troff-ms",
+		"unaligned",
+		"vertical",
+		"wrapped",
+	} {
+		Register(name, encode)
+	}
+}
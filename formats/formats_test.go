@@ -0,0 +1,220 @@
+package formats
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v17/arrow"
+)
+
+func TestRegisterWriter(t *testing.T) {
+	RegisterWriter("test", func(w io.Writer, params map[string]string) (Writer, error) {
+		return &testWriter{w: w, sep: params["fieldsep"]}, nil
+	})
+	if !Registered("test") {
+		t.Fatalf("expected test format to be registered")
+	}
+	rs := &testResultSet{
+		cols: [][]string{{"a", "b"}, {"c"}},
+		rows: [][][]interface{}{
+			{{int64(1), "x"}, {int64(2), nil}},
+			{{true}},
+		},
+		set: 0, row: -1,
+	}
+	buf := new(bytes.Buffer)
+	if err := EncodeAll(buf, rs, map[string]string{"format": "test", "fieldsep": "|"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if s, exp := buf.String(), "a|b\n1|x\n2|<nil>\nc\ntrue\nclosed\n"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+	if err := EncodeAll(buf, rs, map[string]string{"format": "unknown"}); err == nil {
+		t.Errorf("expected error, got: nil")
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	rs := &testResultSet{
+		cols: [][]string{{"a", "b", "c"}, {"d"}},
+		rows: [][][]interface{}{
+			{{int64(1), "x<y", time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)}, {2.5, nil, true}},
+			{{"z"}},
+		},
+		set: 0, row: -1,
+	}
+	buf := new(bytes.Buffer)
+	if err := EncodeAll(buf, rs, map[string]string{"format": "xlsx", "title": "Results"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		parts[f.Name] = string(b)
+	}
+	tests := []struct {
+		name string
+		exp  []string
+	}{
+		{"[Content_Types].xml", []string{`/xl/worksheets/sheet2.xml`}},
+		{"xl/workbook.xml", []string{`<sheet name="Results" sheetId="1" r:id="rId1"/>`, `<sheet name="Results (2)" sheetId="2" r:id="rId2"/>`}},
+		{"xl/_rels/workbook.xml.rels", []string{`Id="rId3"`, `Target="styles.xml"`}},
+		{"xl/styles.xml", []string{`formatCode="yyyy-mm-dd hh:mm:ss"`}},
+		{"xl/worksheets/sheet1.xml", []string{
+			`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">a</t></is></c>`,
+			`<c r="A2" s="2"><v>1</v></c>`,
+			`<c r="B2" t="inlineStr"><is><t xml:space="preserve">x&lt;y</t></is></c>`,
+			`<c r="C2" s="4"><v>45293.5</v></c>`,
+			`<row r="3"><c r="A3"><v>2.5</v></c><c r="C3" t="b"><v>1</v></c></row>`,
+			`<autoFilter ref="A1:C3"/>`,
+		}},
+		{"xl/worksheets/sheet2.xml", []string{`<c r="A2" t="inlineStr"><is><t xml:space="preserve">z</t></is></c>`}},
+	}
+	for _, test := range tests {
+		s, ok := parts[test.name]
+		if !ok {
+			t.Errorf("expected part %s", test.name)
+			continue
+		}
+		for _, exp := range test.exp {
+			if !strings.Contains(s, exp) {
+				t.Errorf("expected part %s to contain %q, got: %s", test.name, exp, s)
+			}
+		}
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for i, exp := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA", 16383: "XFD"} {
+		if s := xlsxColumn(i); s != exp {
+			t.Errorf("expected column %d to be %q, got: %q", i, exp, s)
+		}
+	}
+}
+
+func TestArrowType(t *testing.T) {
+	tests := []struct {
+		col  Column
+		vals []interface{}
+		exp  arrow.Type
+	}{
+		{Column{Type: "int4"}, nil, arrow.INT32},
+		{Column{Type: "BIGINT"}, []interface{}{[]byte("1")}, arrow.INT64},
+		{Column{Type: "NUMERIC", Precision: 10, Scale: 2}, nil, arrow.DECIMAL128},
+		{Column{Type: "NUMERIC"}, nil, arrow.STRING},
+		{Column{Type: "DATE"}, []interface{}{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}, arrow.DATE32},
+		{Column{Type: "DATE"}, []interface{}{time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)}, arrow.TIMESTAMP},
+		{Column{Type: "TIMESTAMPTZ"}, nil, arrow.TIMESTAMP},
+		{Column{}, []interface{}{int64(1), nil, 2.5}, arrow.FLOAT64},
+		{Column{}, []interface{}{int64(1), "x"}, arrow.STRING},
+		{Column{}, []interface{}{[]byte("x")}, arrow.BINARY},
+		{Column{}, nil, arrow.STRING},
+		{Column{}, []interface{}{[]interface{}{int64(1)}, nil}, arrow.LIST},
+		{Column{}, []interface{}{map[string]interface{}{"a": true}}, arrow.STRUCT},
+	}
+	for i, test := range tests {
+		if typ := arrowType(test.col, test.vals); typ.ID() != test.exp {
+			t.Errorf("test %d expected %v, got: %v", i, test.exp, typ.ID())
+		}
+	}
+}
+
+func TestArrowInt(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		bits int
+		exp  int64
+		ok   bool
+	}{
+		{int64(1), 64, 1, true},
+		{int32(-5), 16, -5, true},
+		{[]byte("42"), 32, 42, true},
+		{" 7 ", 64, 7, true},
+		{2.0, 64, 2, true},
+		{2.5, 64, 0, false},
+		{int64(40000), 16, 0, false},
+		{uint64(1 << 63), 64, 0, false},
+		{"x", 64, 0, false},
+		{true, 64, 0, false},
+	}
+	for i, test := range tests {
+		if n, ok := arrowInt(test.v, test.bits); n != test.exp || ok != test.ok {
+			t.Errorf("test %d expected %d, %t, got: %d, %t", i, test.exp, test.ok, n, ok)
+		}
+	}
+}
+
+type testWriter struct {
+	w   io.Writer
+	sep string
+}
+
+func (w *testWriter) Header(cols []Column) error {
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = col.Name
+	}
+	_, err := fmt.Fprintln(w.w, strings.Join(names, w.sep))
+	return err
+}
+
+func (w *testWriter) Row(vals []interface{}) error {
+	s := make([]string, len(vals))
+	for i, v := range vals {
+		s[i] = fmt.Sprint(v)
+	}
+	_, err := fmt.Fprintln(w.w, strings.Join(s, w.sep))
+	return err
+}
+
+func (w *testWriter) Close() error {
+	_, err := fmt.Fprintln(w.w, "closed")
+	return err
+}
+
+type testResultSet struct {
+	cols     [][]string
+	rows     [][][]interface{}
+	set, row int
+}
+
+func (rs *testResultSet) Next() bool {
+	rs.row++
+	return rs.row < len(rs.rows[rs.set])
+}
+
+func (rs *testResultSet) Scan(v ...interface{}) error {
+	for i, val := range rs.rows[rs.set][rs.row] {
+		*(v[i].(*interface{})) = val
+	}
+	return nil
+}
+
+func (rs *testResultSet) Columns() ([]string, error) {
+	return rs.cols[rs.set], nil
+}
+
+func (rs *testResultSet) Close() error { return nil }
+
+func (rs *testResultSet) Err() error { return nil }
+
+func (rs *testResultSet) NextResultSet() bool {
+	rs.set, rs.row = rs.set+1, -1
+	return rs.set < len(rs.cols)
+}
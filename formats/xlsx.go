@@ -0,0 +1,346 @@
+package formats
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterWriter("xlsx", newXLSXWriter)
+}
+
+// xlsxMaxRows is the maximum number of rows of a sheet. Result sets with more
+// rows are continued on additional sheets.
+const xlsxMaxRows = 1048576
+
+// xlsxMaxCell is the maximum length of a cell's text.
+const xlsxMaxCell = 32767
+
+// cell styles (see xlsxStyles).
+const (
+	xlsxStyleHeader = iota + 1
+	xlsxStyleInt
+	xlsxStyleDate
+	xlsxStyleTimestamp
+)
+
+// xlsxEpoch is the epoch of spreadsheet serial dates.
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// newXLSXWriter creates a format writer writing result sets as the sheets of
+// an Office Open XML spreadsheet (xlsx) workbook.
+func newXLSXWriter(w io.Writer, params map[string]string) (Writer, error) {
+	return newFileWriter(w, "xlsx", params, func(w io.Writer) file {
+		return newXLSXBook(w)
+	}), nil
+}
+
+// xlsxBook is a xlsx workbook, with its sheets streamed to a zip archive.
+type xlsxBook struct {
+	zw     *zip.Writer
+	sheets []string
+	// sw is the current sheet.
+	sw *bufio.Writer
+	// cols are the columns of the current sheet.
+	cols []Column
+	// n is the number of rows of the current sheet.
+	n int
+}
+
+// newXLSXBook creates a xlsx workbook written to w.
+func newXLSXBook(w io.Writer) *xlsxBook {
+	return &xlsxBook{zw: zip.NewWriter(w)}
+}
+
+// sheet starts a new sheet named title, writing the header row of the
+// columns.
+func (b *xlsxBook) sheet(title string, cols []Column) error {
+	if err := b.end(); err != nil {
+		return err
+	}
+	name := b.sheetName(title)
+	f, err := b.zw.Create("xl/worksheets/sheet" + strconv.Itoa(len(b.sheets)+1) + ".xml")
+	if err != nil {
+		return err
+	}
+	b.sheets, b.sw, b.cols, b.n = append(b.sheets, name), bufio.NewWriter(f), cols, 1
+	b.sw.WriteString(xml.Header)
+	b.sw.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.sw.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	b.sw.WriteString(`<sheetData><row r="1">`)
+	for i, col := range cols {
+		b.cell(i, xlsxStyleHeader, "inlineStr", col.Name)
+	}
+	_, err = b.sw.WriteString(`</row>`)
+	return err
+}
+
+// sheetName returns a unique sheet name for the title.
+func (b *xlsxBook) sheetName(title string) string {
+	title = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) || r < ' ' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(title))
+	for i := 1; ; i++ {
+		var name, suffix string
+		switch {
+		case title == "":
+			name = "Sheet" + strconv.Itoa(len(b.sheets)+i)
+		case i > 1:
+			suffix = " (" + strconv.Itoa(i) + ")"
+			fallthrough
+		default:
+			name = title
+			if r := []rune(name); len(r)+len(suffix) > 31 {
+				name = string(r[:31-len(suffix)])
+			}
+			name += suffix
+		}
+		unique := true
+		for _, s := range b.sheets {
+			if strings.EqualFold(s, name) {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			return name
+		}
+	}
+}
+
+// Header satisfies the file interface, starting a new sheet named by the
+// title param.
+func (b *xlsxBook) Header(cols []Column, params map[string]string) error {
+	return b.sheet(params["title"], cols)
+}
+
+// Row satisfies the file interface, continuing the sheet on a new sheet when
+// full.
+func (b *xlsxBook) Row(vals []interface{}) error {
+	if b.n == xlsxMaxRows {
+		if err := b.sheet(b.sheets[len(b.sheets)-1], b.cols); err != nil {
+			return err
+		}
+	}
+	b.n++
+	fmt.Fprintf(b.sw, `<row r="%d">`, b.n)
+	for i, v := range vals {
+		var typ string
+		if i < len(b.cols) {
+			typ = strings.ToUpper(b.cols[i].Type)
+		}
+		switch x := v.(type) {
+		case nil:
+		case bool:
+			s := "0"
+			if x {
+				s = "1"
+			}
+			b.cell(i, 0, "b", s)
+		case int64:
+			b.cell(i, xlsxStyleInt, "", strconv.FormatInt(x, 10))
+		case int, int8, int16, int32, uint, uint8, uint16, uint32, uint64:
+			b.cell(i, xlsxStyleInt, "", fmt.Sprint(x))
+		case float32:
+			b.number(i, float64(x))
+		case float64:
+			b.number(i, x)
+		case time.Time:
+			b.time(i, typ, x)
+		case []byte:
+			b.text(i, typ, string(x))
+		case string:
+			b.text(i, typ, x)
+		default:
+			b.cell(i, 0, "inlineStr", fmt.Sprint(x))
+		}
+	}
+	_, err := b.sw.WriteString(`</row>`)
+	return err
+}
+
+// number writes a numeric cell.
+func (b *xlsxBook) number(i int, f float64) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		b.cell(i, 0, "inlineStr", strconv.FormatFloat(f, 'g', -1, 64))
+		return
+	}
+	b.cell(i, 0, "", strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// time writes a date or timestamp cell, as a serial date.
+func (b *xlsxBook) time(i int, typ string, t time.Time) {
+	// wall clock time
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	if t.Before(xlsxEpoch) || t.Year() > 9999 {
+		// out of the range of serial dates
+		b.cell(i, 0, "inlineStr", t.Format(time.RFC3339Nano))
+		return
+	}
+	style := xlsxStyleTimestamp
+	if typ == "DATE" {
+		style = xlsxStyleDate
+	}
+	// days since the epoch, and the fraction of the day
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	serial := float64(day.Unix()-xlsxEpoch.Unix())/86400 + float64(t.Sub(day))/float64(24*time.Hour)
+	b.cell(i, style, "", strconv.FormatFloat(serial, 'f', -1, 64))
+}
+
+// text writes a text cell, or a numeric cell for the text of decimal and
+// numeric columns (as returned by some drivers).
+func (b *xlsxBook) text(i int, typ, s string) {
+	switch typ {
+	case "DECIMAL", "NUMERIC", "NUMBER", "MONEY", "INT8", "BIGINT", "HUGEINT":
+		if f, ok := new(big.Float).SetString(strings.TrimSpace(s)); ok {
+			if f.IsInt() && len(strings.TrimLeft(s, "+-")) <= 15 {
+				b.cell(i, xlsxStyleInt, "", f.Text('f', -1))
+				return
+			}
+			v, _ := f.Float64()
+			b.number(i, v)
+			return
+		}
+	}
+	b.cell(i, 0, "inlineStr", s)
+}
+
+// cell writes a cell of the current row, with the style and type (an empty
+// type is a number, inlineStr is a string, and b is a boolean).
+func (b *xlsxBook) cell(i, style int, typ, s string) {
+	b.sw.WriteString(`<c r="` + xlsxColumn(i) + strconv.Itoa(b.n) + `"`)
+	if style != 0 {
+		b.sw.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	if typ == "inlineStr" {
+		if utf8.RuneCountInString(s) > xlsxMaxCell {
+			s = string([]rune(s)[:xlsxMaxCell])
+		}
+		b.sw.WriteString(` t="inlineStr"><is><t xml:space="preserve">`)
+		_ = xml.EscapeText(b.sw, []byte(s))
+		b.sw.WriteString(`</t></is></c>`)
+		return
+	}
+	if typ != "" {
+		b.sw.WriteString(` t="` + typ + `"`)
+	}
+	b.sw.WriteString(`><v>` + s + `</v></c>`)
+}
+
+// end ends the current sheet, if any.
+func (b *xlsxBook) end() error {
+	if b.sw == nil {
+		return nil
+	}
+	b.sw.WriteString(`</sheetData>`)
+	if len(b.cols) != 0 {
+		b.sw.WriteString(`<autoFilter ref="A1:` + xlsxColumn(len(b.cols)-1) + strconv.Itoa(b.n) + `"/>`)
+	}
+	b.sw.WriteString(`</worksheet>`)
+	err := b.sw.Flush()
+	b.sw, b.cols = nil, nil
+	return err
+}
+
+// Flush satisfies the file interface, ending the current sheet.
+func (b *xlsxBook) Flush() error {
+	if err := b.end(); err != nil {
+		return err
+	}
+	return b.zw.Flush()
+}
+
+// Close satisfies the file interface, ending the current sheet and writing
+// the workbook.
+func (b *xlsxBook) Close() error {
+	if err := b.end(); err != nil {
+		return err
+	}
+	if len(b.sheets) == 0 {
+		if err := b.sheet("", nil); err != nil {
+			return err
+		}
+		if err := b.end(); err != nil {
+			return err
+		}
+	}
+	var types, sheets, rels strings.Builder
+	for i, name := range b.sheets {
+		n := strconv.Itoa(i + 1)
+		types.WriteString(`<Override PartName="/xl/worksheets/sheet` + n + `.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`)
+		sheets.WriteString(`<sheet name="`)
+		_ = xml.EscapeText(&sheets, []byte(name))
+		sheets.WriteString(`" sheetId="` + n + `" r:id="rId` + n + `"/>`)
+		rels.WriteString(`<Relationship Id="rId` + n + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet` + n + `.xml"/>`)
+	}
+	styles := strconv.Itoa(len(b.sheets) + 1)
+	for _, part := range []struct {
+		name, s string
+	}{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			types.String() + `</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() +
+			`<Relationship Id="rId` + styles + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+			`</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	} {
+		f, err := b.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, xml.Header+part.s); err != nil {
+			return err
+		}
+	}
+	return b.zw.Close()
+}
+
+// xlsxColumn returns the column reference (ie, A, B, ..., AA) of the column
+// i.
+func xlsxColumn(i int) string {
+	var s []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		s = append([]byte{byte('A' + (i-1)%26)}, s...)
+	}
+	return string(s)
+}
+
+// xlsxStyles are the workbook's styles: the default style, the header style
+// (bold), the integer style, and the date and timestamp styles.
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="5">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="1" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -68,6 +68,7 @@ require (
 	github.com/ydb-platform/ydb-go-sdk/v3 v3.113.0
 	github.com/yookoala/realpath v1.0.0
 	github.com/ziutek/mymysql v1.5.4
+	golang.org/x/sys v0.34.0
 	gorm.io/driver/bigquery v1.2.0
 	modernc.org/ql v1.4.16
 	modernc.org/sqlite v1.38.0
@@ -342,7 +343,6 @@ require (
 	golang.org/x/net v0.42.0 // indirect
 	golang.org/x/oauth2 v0.30.0 // indirect
 	golang.org/x/sync v0.16.0 // indirect
-	golang.org/x/sys v0.34.0 // indirect
 	golang.org/x/term v0.33.0 // indirect
 	golang.org/x/text v0.27.0 // indirect
 	golang.org/x/time v0.12.0 // indirect
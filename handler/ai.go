@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/drivers/metadata"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/stmtclass"
+	"github.com/xo/usql/text"
+)
+
+// aiMaxColumns is the maximum number of columns included in the schema sent
+// to the language model endpoint.
+const aiMaxColumns = 1000
+
+// aiClient is the client for requests to the language model endpoint. A
+// stalled endpoint otherwise blocks the prompt until interrupted.
+var aiClient = &http.Client{Timeout: 2 * time.Minute}
+
+// aiMessage is a chat message for an OpenAI-compatible chat completions
+// endpoint.
+type aiMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Assist sends the prompt, along with the schema of the open database, to the
+// language model endpoint, returning the generated query.
+func (h *Handler) Assist(ctx context.Context, prompt string) (string, error) {
+	sqlstr, err := h.complete(ctx, text.AISystemPrompt, prompt)
+	if err != nil {
+		return "", err
+	}
+	if sqlstr = stripCodeFence(sqlstr); sqlstr == "" {
+		return "", text.ErrAINoQuery
+	}
+	return sqlstr, nil
+}
+
+// Explain sends the query, with its data values redacted, to the language
+// model endpoint, returning a plain language explanation of the query.
+func (h *Handler) Explain(ctx context.Context, sqlstr string) (string, error) {
+	sqlstr, _ = redactValues(sqlstr)
+	return h.complete(ctx, text.AIExplainPrompt, sqlstr)
+}
+
+// Fix sends the last failed query and its error, with data values redacted,
+// to the language model endpoint, returning the suggested correction of the
+// query with the data values restored.
+func (h *Handler) Fix(ctx context.Context) (string, error) {
+	if h.lastFailed == nil {
+		return "", text.ErrNoFailedQuery
+	}
+	sqlstr, values := redactValues(h.lastFailed.Buf)
+	prompt := fmt.Sprintf(text.AIFixRequest, sqlstr, redactError(h.lastFailed.Err.Error()))
+	sqlstr, err := h.complete(ctx, text.AIFixPrompt, prompt)
+	if err != nil {
+		return "", err
+	}
+	if sqlstr = stripCodeFence(sqlstr); sqlstr == "" {
+		return "", text.ErrAINoQuery
+	}
+	return restoreValues(sqlstr, values), nil
+}
+
+// complete sends the system prompt, formatted with the driver and schema of
+// the open database, and the user prompt to the OpenAI-compatible chat
+// completions endpoint specified by the AI_URL variable, returning the
+// response.
+func (h *Handler) complete(ctx context.Context, system, prompt string) (string, error) {
+	endpoint := env.Get("AI_URL")
+	switch {
+	case endpoint == "":
+		return "", text.ErrAINotConfigured
+	case h.db == nil:
+		return "", text.ErrNotConnected
+	}
+	schema, err := h.aiSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	buf, err := json.Marshal(struct {
+		Model       string      `json:"model,omitempty"`
+		Messages    []aiMessage `json:"messages"`
+		Temperature float64     `json:"temperature"`
+	}{
+		Model: env.Get("AI_MODEL"),
+		Messages: []aiMessage{
+			{"system", fmt.Sprintf(system, h.u.Driver, schema)},
+			{"user", prompt},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/chat/completions", bytes.NewReader(buf))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key, ok := env.Getenv(text.CommandUpper()+"_AI_KEY", "OPENAI_API_KEY"); ok {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	res, err := aiClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return "", fmt.Errorf(text.AIRequestFailed, res.Status, strings.TrimSpace(string(body)))
+	}
+	var v struct {
+		Choices []struct {
+			Message aiMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&v); err != nil {
+		return "", err
+	}
+	if len(v.Choices) == 0 {
+		return "", text.ErrAINoResponse
+	}
+	return strings.TrimSpace(v.Choices[0].Message.Content), nil
+}
+
+// aiSchema returns the tables and columns of the open database, one table per
+// line, as read by the driver's metadata reader.
+func (h *Handler) aiSchema(ctx context.Context) (string, error) {
+	r, err := drivers.NewMetadataReader(ctx, h.u, h.db, h.l.Stdout(), h.readerOpts()...)
+	if err != nil {
+		// not all drivers have a metadata reader
+		return "", nil
+	}
+	if h.cache != nil {
+		r = h.cache.Reader(r)
+	}
+	cr, ok := r.(metadata.ColumnReader)
+	if !ok {
+		return "", nil
+	}
+	cols, err := cr.Columns(metadata.Filter{OnlyVisible: true})
+	if err != nil {
+		return "", err
+	}
+	defer cols.Close()
+	var b strings.Builder
+	var table string
+	for i := 0; i < aiMaxColumns && cols.Next(); i++ {
+		col := cols.Get()
+		name := col.Table
+		if col.Schema != "" {
+			name = col.Schema + "." + col.Table
+		}
+		switch {
+		case name != table && table != "":
+			b.WriteString(")\n")
+			fallthrough
+		case name != table:
+			b.WriteString(name + "(")
+			table = name
+		default:
+			b.WriteString(", ")
+		}
+		b.WriteString(col.Name + " " + col.DataType)
+	}
+	if table != "" {
+		b.WriteString(")\n")
+	}
+	return b.String(), nil
+}
+
+// stripCodeFence strips any markdown code fence surrounding s.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "```") {
+		if i := strings.IndexByte(s, '\n'); i != -1 {
+			s = s[i+1:]
+		}
+		s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	}
+	return strings.TrimSpace(s)
+}
+
+// redactPlaceholderRE matches the placeholders of redacted values.
+var redactPlaceholderRE = regexp.MustCompile(`:_[0-9]+\b`)
+
+// redactErrorRE matches the quoted values, key values (such as PostgreSQL's
+// "Key (id)=(1)"), and unquoted values (such as SQL Server's "The duplicate
+// key value is (1)", and PostgreSQL's "Failing row contains (1, a)") of an
+// error message.
+var redactErrorRE = regexp.MustCompile(`'(?:[^']|'')*'|=\([^)]*\)|\b(?:value is|row contains) \([^)]*\)`)
+
+// redactValues replaces the data values (string and numeric literals) in the
+// query sqlstr with numbered placeholders (:_1, :_2, ...), and removes its
+// comments, so that only the structure of the query is sent to the language
+// model endpoint. Returns the redacted query and the replaced values.
+func redactValues(sqlstr string) (string, []string) {
+	r := []rune(sqlstr)
+	var values []string
+	var b strings.Builder
+	pos := 0
+	// gap writes the whitespace preceding end, replacing comments
+	gap := func(end int) {
+		if s := string(r[pos:end]); strings.TrimSpace(s) == "" {
+			b.WriteString(s)
+		} else {
+			b.WriteString(" ")
+		}
+	}
+	for _, tok := range stmtclass.Tokenize(sqlstr) {
+		gap(tok.Pos)
+		s := string(r[tok.Pos:tok.End])
+		switch tok.Type {
+		case stmtclass.String, stmtclass.Number:
+			values = append(values, s)
+			s = ":_" + strconv.Itoa(len(values))
+		}
+		b.WriteString(s)
+		pos = tok.End
+	}
+	gap(len(r))
+	return strings.TrimSpace(b.String()), values
+}
+
+// restoreValues replaces the placeholders in sqlstr with the values replaced
+// by redactValues.
+func restoreValues(sqlstr string, values []string) string {
+	return redactPlaceholderRE.ReplaceAllStringFunc(sqlstr, func(v string) string {
+		if i, err := strconv.Atoi(v[2:]); err == nil && 0 < i && i <= len(values) {
+			return values[i-1]
+		}
+		return v
+	})
+}
+
+// redactError replaces the data values in the error message s with
+// placeholders.
+func redactError(s string) string {
+	return redactErrorRE.ReplaceAllStringFunc(s, func(v string) string {
+		if v[0] == '\'' {
+			return "'?'"
+		}
+		return v[:strings.IndexByte(v, '(')] + "(?)"
+	})
+}
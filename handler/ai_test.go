@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestRedactValues(t *testing.T) {
+	tests := []struct {
+		s      string
+		exp    string
+		values []string
+	}{
+		{`select a from b`, `select a from b`, nil},
+		{`select "c1" from t2 where a = 'x''y' and b > 1.5`, `select "c1" from t2 where a = :_1 and b > :_2`, []string{`'x''y'`, `1.5`}},
+		{`select $$secret$$, $tag$it's$tag$`, `select :_1, :_2`, []string{`$$secret$$`, `$tag$it's$tag$`}},
+		{`select E'a\'b', e'c'`, `select :_1, :_2`, []string{`E'a\'b'`, `e'c'`}},
+		{"select a -- ssn 123-45-6789\nfrom b /* card 4111 */ where c = $1", `select a from b where c = $1`, nil},
+		{"/* x */ select 1", `select :_1`, []string{`1`}},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			s, values := redactValues(test.s)
+			if s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+			if !reflect.DeepEqual(values, test.values) {
+				t.Errorf("expected values %q, got: %q", test.values, values)
+			}
+		})
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp string
+	}{
+		{`relation "b" does not exist`, `relation "b" does not exist`},
+		{`Duplicate entry 'alice@example.com' for key 'users.email'`, `Duplicate entry '?' for key '?'`},
+		{`Key (email)=(alice@example.com) already exists.`, `Key (email)=(?) already exists.`},
+		{`Violation of UNIQUE KEY constraint 'uq'. Cannot insert duplicate key in object 'dbo.users'. The duplicate key value is (alice@example.com).`, `Violation of UNIQUE KEY constraint '?'. Cannot insert duplicate key in object '?'. The duplicate key value is (?).`},
+		{`null value in column "id" violates not-null constraint. Failing row contains (null, alice, 42).`, `null value in column "id" violates not-null constraint. Failing row contains (?).`},
+	}
+	for i, test := range tests {
+		if s := redactError(test.s); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
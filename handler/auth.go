@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+// authCommand returns the authentication command for the named connection
+// name (the auth_command connection option), or for u the AUTH_COMMAND
+// variable when u has a host, as database files and local sockets are not
+// authenticated with passwords or tokens.
+func authCommand(name string, u *dburl.URL) string {
+	if s := env.Vars().ConnOpt(name, "auth_command"); s != "" {
+		return s
+	}
+	if u.Host == "" {
+		return ""
+	}
+	return env.Get("AUTH_COMMAND")
+}
+
+// authenticate runs the authentication command s, setting the credentials
+// written by the command on u.
+//
+// The command is run with the user's shell, and is passed the connection's
+// driver, host, port, database name, and user in the USQL_AUTH_* environment
+// variables. The command writes either a password (or token), or a JSON
+// object with "user" and "password" keys, to its standard output.
+func (h *Handler) authenticate(u *dburl.URL, s string) error {
+	shell, param := env.Getshell()
+	if shell == "" {
+		return text.ErrNoShellAvailable
+	}
+	username := h.user.Username
+	if u.User != nil {
+		username = u.User.Username()
+	}
+	prefix := text.CommandUpper() + "_AUTH_"
+	cmd := exec.Command(shell, param, s)
+	cmd.Env = append(os.Environ(),
+		prefix+"DRIVER="+u.Driver,
+		prefix+"HOST="+u.Hostname(),
+		prefix+"PORT="+u.Port(),
+		prefix+"DBNAME="+strings.TrimPrefix(u.Path, "/"),
+		prefix+"USER="+username,
+	)
+	var stdout bytes.Buffer
+	env.WaitTermGraphics()
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, &stdout, h.l.Stderr()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf(text.AuthCommandFailed, err)
+	}
+	buf := bytes.TrimSpace(stdout.Bytes())
+	password := string(buf)
+	if len(buf) != 0 && buf[0] == '{' {
+		var v struct {
+			User     string `json:"user"`
+			Password string `json:"password"`
+		}
+		if err := json.Unmarshal(buf, &v); err != nil {
+			return fmt.Errorf(text.AuthCommandFailed, err)
+		}
+		if v.User != "" {
+			username = v.User
+		}
+		password = v.Password
+	}
+	if password == "" {
+		return fmt.Errorf(text.AuthCommandFailed, text.ErrAuthNoCredentials)
+	}
+	u.User = url.UserPassword(username, password)
+	z, err := dburl.Parse(u.String())
+	if err != nil {
+		return err
+	}
+	*u = *z
+	return nil
+}
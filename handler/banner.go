@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+// bannerTemplate returns the connect banner template for the named connection
+// name (its banner option), or the BANNER variable.
+func bannerTemplate(name string) string {
+	if s := env.Vars().ConnOpt(name, "banner"); s != "" {
+		return s
+	}
+	return env.Get("BANNER")
+}
+
+// banner returns the connect banner for the server info. When a banner
+// template is set, its ${name} placeholders are replaced with the driver,
+// version, database, schema, encoding, tls, role, user, host, and port of the
+// connection. A template of off disables the banner.
+func (h *Handler) banner(info drivers.ServerInfo) string {
+	if info.Version == "" {
+		info.Version = "<unknown>"
+	}
+	fields := [][2]string{
+		{"database", info.Database},
+		{"schema", info.Schema},
+		{"encoding", info.Encoding},
+		{"tls", info.TLS},
+		{"role", info.Role},
+	}
+	switch tmpl := bannerTemplate(h.bannerName); tmpl {
+	case "off":
+		return ""
+	case "":
+		s := fmt.Sprintf(text.ConnInfo, h.u.Driver, info.Version)
+		var v []string
+		for _, f := range fields {
+			if f[1] != "" {
+				v = append(v, f[0]+": "+f[1])
+			}
+		}
+		if len(v) != 0 {
+			s += "\n" + strings.Join(v, ", ")
+		}
+		return s
+	default:
+		vals := map[string]string{
+			"driver":  h.u.Driver,
+			"version": info.Version,
+			"user":    h.u.User.Username(),
+			"host":    h.u.Hostname(),
+			"port":    h.u.Port(),
+		}
+		for _, f := range fields {
+			vals[f[0]] = f[1]
+		}
+		return os.Expand(tmpl, func(name string) string {
+			return vals[name]
+		})
+	}
+}
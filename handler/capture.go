@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/stmt"
+	"github.com/xo/usql/text"
+)
+
+// captureEntry is a statement recorded in a workload capture file.
+type captureEntry struct {
+	// Time is when the statement started.
+	Time time.Time `json:"time"`
+	// Session identifies the session that executed the statement.
+	Session string `json:"session"`
+	// Statement is the statement.
+	Statement string `json:"statement"`
+	// Params are the statement's bound parameters.
+	Params []captureParam `json:"params,omitempty"`
+	// DurationMS is the duration of the statement in milliseconds.
+	DurationMS int64 `json:"duration_ms"`
+	// Error is the error of the statement, if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+// captureParam is a bound parameter recorded in a workload capture file,
+// with its type, so that it is bound with the same type when replayed.
+type captureParam struct {
+	// Type is the type of the parameter (null, bool, int, uint, float, string,
+	// bytes, or time).
+	Type string `json:"type"`
+	// Value is the JSON encoded value of the parameter.
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// encodeParams encodes the bound parameters bind as recorded in a workload
+// capture file. Parameters of other types (ie, a driver.Valuer) are recorded
+// as their string representation.
+func encodeParams(bind []interface{}) ([]captureParam, error) {
+	params := make([]captureParam, len(bind))
+	for i, v := range bind {
+		switch x := v.(type) {
+		case nil:
+			params[i].Type = "null"
+			continue
+		case bool:
+			params[i].Type = "bool"
+		case int, int8, int16, int32, int64:
+			params[i].Type = "int"
+		case uint, uint8, uint16, uint32, uint64:
+			params[i].Type = "uint"
+		case float32, float64:
+			params[i].Type = "float"
+		case string:
+			params[i].Type = "string"
+		case []byte:
+			params[i].Type = "bytes"
+		case time.Time:
+			params[i].Type = "time"
+		default:
+			params[i].Type, v = "string", fmt.Sprint(x)
+		}
+		var err error
+		if params[i].Value, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	return params, nil
+}
+
+// decodeParams decodes the bound parameters recorded in a workload capture
+// file.
+func decodeParams(params []captureParam) ([]interface{}, error) {
+	bind := make([]interface{}, len(params))
+	for i, param := range params {
+		var v interface{}
+		switch param.Type {
+		case "null":
+			continue
+		case "bool":
+			v = new(bool)
+		case "int":
+			v = new(int64)
+		case "uint":
+			v = new(uint64)
+		case "float":
+			v = new(float64)
+		case "string":
+			v = new(string)
+		case "bytes":
+			v = new([]byte)
+		case "time":
+			v = new(time.Time)
+		default:
+			return nil, fmt.Errorf(text.InvalidCaptureParam, param.Type)
+		}
+		if err := json.Unmarshal(param.Value, v); err != nil {
+			return nil, err
+		}
+		bind[i] = reflect.ValueOf(v).Elem().Interface()
+	}
+	return bind, nil
+}
+
+// capture is an active workload capture.
+type capture struct {
+	name    string
+	session string
+	f       *os.File
+	enc     *json.Encoder
+}
+
+// CaptureStart starts recording the executed statements, with their timing
+// and parameters, as JSON lines appended to the file name, for later replay
+// (see Replay). As the statements and parameters may contain credentials or
+// personal data, the file is only readable by the user.
+func (h *Handler) CaptureStart(name string) error {
+	if err := h.CaptureStop(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	h.capture = &capture{
+		name:    name,
+		session: strconv.Itoa(os.Getpid()) + "-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		f:       f,
+		enc:     json.NewEncoder(f),
+	}
+	return nil
+}
+
+// CaptureStop stops recording the executed statements.
+func (h *Handler) CaptureStop() error {
+	if h.capture == nil {
+		return nil
+	}
+	err := h.capture.f.Close()
+	h.capture = nil
+	return err
+}
+
+// Capture returns the name of the file the executed statements are recorded
+// to, or an empty string when not recording.
+func (h *Handler) Capture() string {
+	if h.capture == nil {
+		return ""
+	}
+	return h.capture.name
+}
+
+// record records the statement sqlstr started at start and executed with the
+// bound parameters bind, when capturing statements.
+func (h *Handler) record(start time.Time, sqlstr string, bind []interface{}, err error) {
+	if h.capture == nil {
+		return
+	}
+	entry := captureEntry{
+		Time:       start,
+		Session:    h.capture.session,
+		Statement:  sqlstr,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	params, err := encodeParams(bind)
+	if err == nil {
+		entry.Params = params
+		err = h.capture.enc.Encode(entry)
+	}
+	if err != nil {
+		fmt.Fprintln(h.l.Stderr(), fmt.Sprintf(text.CaptureFailed, h.capture.name, err))
+		_ = h.CaptureStop()
+	}
+}
+
+// ParseSpeed parses a replay speed, as a multiplier (ie, 2x or 0.5).
+func ParseSpeed(s string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf(text.InvalidReplaySpeed, s)
+	}
+	return speed, nil
+}
+
+// Replay replays the statements recorded in the workload capture file name
+// (see CaptureStart) against the open database, writing a summary to w.
+//
+// The relative timing of the statements is preserved, scaled by speed (ie, 2
+// replays the statements twice as fast). The statements of each captured
+// session are executed in order on a dedicated connection, concurrently with
+// the statements of the other sessions.
+func (h *Handler) Replay(ctx context.Context, w io.Writer, name string, speed float64) error {
+	if h.db == nil {
+		return text.ErrNotConnected
+	}
+	entries, err := readCapture(name)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf(text.CaptureEmpty, name)
+	}
+	// group by session
+	var sessions [][]captureEntry
+	index := make(map[string]int)
+	for _, entry := range entries {
+		i, ok := index[entry.Session]
+		if !ok {
+			i, index[entry.Session] = len(sessions), len(sessions)
+			sessions = append(sessions, nil)
+		}
+		sessions[i] = append(sessions[i], entry)
+	}
+	first, start := entries[0].Time, time.Now()
+	var mu sync.Mutex
+	var failed int
+	var wg sync.WaitGroup
+	for _, session := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := h.db.Conn(ctx)
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				failed += len(session)
+				fmt.Fprintln(h.l.Stderr(), "error:", drivers.WrapErr(h.u.Driver, err))
+				return
+			}
+			defer conn.Close()
+			for _, entry := range session {
+				offset := time.Duration(float64(entry.Time.Sub(first)) / speed)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Until(start.Add(offset))):
+				}
+				if err := h.replay(ctx, conn, entry); err != nil {
+					mu.Lock()
+					failed++
+					fmt.Fprintln(h.l.Stderr(), fmt.Sprintf(text.ReplayFailed, entry.Session, entry.Time.Format(time.RFC3339Nano), err))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, fmt.Sprintf(
+		text.ReplaySummary, len(entries), len(sessions), failed,
+		time.Since(start).Round(time.Millisecond), entries[len(entries)-1].Time.Sub(first).Round(time.Millisecond),
+	))
+	return nil
+}
+
+// replay executes a recorded statement on conn, discarding any results.
+func (h *Handler) replay(ctx context.Context, conn *sql.Conn, entry captureEntry) error {
+	prefix := stmt.FindPrefix(entry.Statement, true, true, true)
+	_, sqlstr, qtyp, err := drivers.Process(h.u, prefix, entry.Statement)
+	if err != nil {
+		return drivers.WrapErr(h.u.Driver, err)
+	}
+	// enforce read-only mode, destructive statement confirmation, and policy
+	if sqlstr, err = h.Check(sqlstr); err != nil {
+		return err
+	}
+	bind, err := decodeParams(entry.Params)
+	if err != nil {
+		return err
+	}
+	if !qtyp {
+		_, err := conn.ExecContext(ctx, sqlstr, bind...)
+		return drivers.WrapErr(h.u.Driver, err)
+	}
+	rows, err := conn.QueryContext(ctx, sqlstr, bind...)
+	if err != nil {
+		return drivers.WrapErr(h.u.Driver, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return drivers.WrapErr(h.u.Driver, rows.Err())
+}
+
+// readCapture reads the statements recorded in the workload capture file
+// name, ordered by when they started.
+func readCapture(name string) ([]captureEntry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []captureEntry
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 64*1024*1024)
+	for i := 1; s.Scan(); i++ {
+		if len(strings.TrimSpace(s.Text())) == 0 {
+			continue
+		}
+		var entry captureEntry
+		if err := json.Unmarshal(s.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf(text.InvalidCaptureEntry, name, i, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(entries, func(a, b captureEntry) int {
+		return a.Time.Compare(b.Time)
+	})
+	return entries, nil
+}
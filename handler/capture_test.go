@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+func TestCaptureParams(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	bind := []interface{}{nil, true, 42, uint8(7), 4.2, "abc", []byte{0, 1, 2}, ts, struct{ A int }{1}}
+	exp := []interface{}{nil, true, int64(42), uint64(7), 4.2, "abc", []byte{0, 1, 2}, ts, "{1}"}
+	params, err := encodeParams(bind)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	buf, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	var decoded []captureParam
+	if err := json.Unmarshal(buf, &decoded); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	v, err := decodeParams(decoded)
+	switch {
+	case err != nil:
+		t.Fatalf("expected no error, got: %v", err)
+	case !reflect.DeepEqual(v, exp):
+		t.Errorf("expected %#v, got: %#v", exp, v)
+	}
+	if _, err := decodeParams([]captureParam{{Type: "blob"}}); err == nil {
+		t.Errorf("expected error for unknown parameter type")
+	}
+}
+
+func TestReplayReadOnly(t *testing.T) {
+	if err := env.Vars().Set("READ_ONLY", "on"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	defer env.Vars().Set("READ_ONLY", "off")
+	h := &Handler{u: &dburl.URL{Driver: "postgres"}}
+	// the read-only check precedes execution, so no connection is needed
+	err := h.replay(context.Background(), nil, captureEntry{Statement: "delete from b"})
+	if exp := fmt.Sprintf(text.ReadOnlyDenied, "DELETE"); err == nil || err.Error() != exp {
+		t.Errorf("expected error %q, got: %v", exp, err)
+	}
+}
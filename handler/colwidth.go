@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/user"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/xo/usql/env"
+)
+
+// colWidths are the fixed column widths of the result sets of a query (see
+// the colwidth print variable), so that the layout of repeated output (ie,
+// \watch) does not change as the length of the values change.
+//
+// Declared widths are fixed, truncating longer values. Learned widths are the
+// widest values (and column names) seen for the query, and only grow.
+type colWidths struct {
+	// declared are the declared widths by column name, or nil when learning.
+	declared map[string]int
+	// learned are the learned widths of all queries, by key.
+	learned map[string]learnedWidths
+	// changed indicates the learned widths changed.
+	changed bool
+	// sum is the hash of the query.
+	sum string
+	// convert converts values to strings.
+	convert func(interface{}) (string, error)
+	// null is the display value of NULL values.
+	null string
+	// key is the key of the current result set.
+	key string
+	// widths are the widths of the columns of the current result set, 0
+	// when the column has no fixed width.
+	widths []int
+}
+
+// learnedWidths are the learned widths of the columns of a result set.
+type learnedWidths struct {
+	Columns []string `json:"columns"`
+	Widths  []int    `json:"widths"`
+}
+
+// newColWidths creates the fixed column widths for the query sqlstr from the
+// colwidth print variable value, loading the learned widths when value is
+// auto.
+func newColWidths(u *user.User, value, sqlstr, null string, convert func(interface{}) (string, error)) (*colWidths, error) {
+	declared, err := env.ParseColWidths(value)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(sqlstr))
+	cw := &colWidths{
+		declared: declared,
+		learned:  make(map[string]learnedWidths),
+		sum:      hex.EncodeToString(sum[:]),
+		convert:  convert,
+		null:     null,
+	}
+	if declared == nil {
+		// best effort, as the widths are learned again
+		if buf, err := os.ReadFile(env.ColWidthsFile(u)); err == nil {
+			_ = json.Unmarshal(buf, &cw.learned)
+		}
+	}
+	return cw, nil
+}
+
+// start starts the n'th result set of the query, with columns cols.
+func (cw *colWidths) start(n int, cols []string) {
+	cw.key, cw.widths = cw.sum+":"+strconv.Itoa(n), make([]int, len(cols))
+	if cw.declared != nil {
+		for i, col := range cols {
+			width, ok := cw.declared[col]
+			if !ok {
+				width = cw.declared["*"]
+			}
+			cw.widths[i] = width
+		}
+		return
+	}
+	if l, ok := cw.learned[cw.key]; ok && slices.Equal(l.Columns, cols) && len(l.Widths) == len(cols) {
+		copy(cw.widths, l.Widths)
+		return
+	}
+	for i, col := range cols {
+		cw.widths[i] = runewidth.StringWidth(col)
+	}
+	cw.learned[cw.key] = learnedWidths{Columns: cols, Widths: slices.Clone(cw.widths)}
+	cw.changed = true
+}
+
+// value returns the value v of the i'th column of the current result set,
+// padded (or truncated) to the column's width.
+func (cw *colWidths) value(i int, v interface{}) (interface{}, error) {
+	width := cw.widths[i]
+	if width == 0 {
+		return v, nil
+	}
+	s := cw.null
+	if v != nil {
+		var err error
+		if s, err = cw.convert(v); err != nil {
+			return nil, err
+		}
+	}
+	switch w := runewidth.StringWidth(s); {
+	case w > width && cw.declared != nil:
+		s = runewidth.Truncate(s, width, "…")
+	case w > width:
+		width, cw.widths[i], cw.changed = w, w, true
+		cw.learned[cw.key].Widths[i] = w
+	}
+	if isNumber(v) {
+		return runewidth.FillLeft(s, width), nil
+	}
+	return runewidth.FillRight(s, width), nil
+}
+
+// save saves the learned widths when changed.
+//
+// Errors are ignored, as the widths are only learned as a best effort.
+func (cw *colWidths) save(u *user.User) {
+	if cw.declared != nil || !cw.changed {
+		return
+	}
+	buf, err := json.Marshal(cw.learned)
+	if err != nil {
+		return
+	}
+	name := env.ColWidthsFile(u)
+	if err := os.WriteFile(name+".tmp", buf, 0o600); err == nil {
+		_ = os.Rename(name+".tmp", name)
+	}
+	cw.changed = false
+}
+
+// isNumber returns true when v is a number, which is right aligned.
+func isNumber(v interface{}) bool {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
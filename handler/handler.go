@@ -8,9 +8,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"image/png"
 	"io"
-	"log"
 	"maps"
+	"math/rand/v2"
 	"net/url"
 	"os"
 	"os/exec"
@@ -39,10 +40,12 @@ import (
 	"github.com/xo/usql/drivers/completer"
 	"github.com/xo/usql/drivers/metadata"
 	"github.com/xo/usql/env"
+	"github.com/xo/usql/formats"
 	"github.com/xo/usql/metacmd"
 	"github.com/xo/usql/metacmd/charts"
 	"github.com/xo/usql/rline"
 	"github.com/xo/usql/stmt"
+	"github.com/xo/usql/stmtclass"
 	ustyles "github.com/xo/usql/styles"
 	"github.com/xo/usql/text"
 )
@@ -72,6 +75,11 @@ type Handler struct {
 	singleLineMode bool
 	// buf is the query statement buffer.
 	buf *stmt.Stmt
+	// cond is the conditional block stack.
+	cond stmt.Cond
+	// condLen is the length of the query statement buffer when entering a
+	// skipped conditional block branch.
+	condLen int
 	// lastExec is the last executed query statement.
 	lastExec string
 	// lastExecPrefix is the last executed query statement prefix.
@@ -80,6 +88,8 @@ type Handler struct {
 	lastPrint string
 	// lastRaw is the last executed raw query statement.
 	lastRaw string
+	// lastFailed is the last failed query statement and its error.
+	lastFailed *Error
 	// batch indicates a batch has been started.
 	batch bool
 	// batchEnd is the batch end string.
@@ -90,17 +100,68 @@ type Handler struct {
 	u *dburl.URL
 	// db is the active database connection.
 	db *sql.DB
+	// conn is the session connection pinned from db, once session state has
+	// been set (see pin).
+	conn *session
 	// tx is the active transaction, if any.
 	tx *sql.Tx
+	// bannerName is the name the active connection was opened with, used to
+	// find the banner template and plaintext option of a named connection.
+	bannerName string
+	// hosts are the single host URLs of the active multi-host connection.
+	hosts []string
+	// hostStatus is the status of each host tried when opening the active
+	// multi-host connection.
+	hostStatus []string
+	// route is the statement routing mode (see Route).
+	route string
+	// replicaURL is the replica connection information.
+	replicaURL *dburl.URL
+	// replica is the replica connection, opened when routing statements.
+	replica *sql.DB
+	// replicaErr is the error opening the replica connection in auto routing
+	// mode, kept until the routing mode is set or the connection is closed.
+	replicaErr error
+	// onReplica indicates the statement being executed is routed to the
+	// replica.
+	onReplica bool
+	// routed is where the last statement was executed when routing
+	// statements.
+	routed string
+	// opts are the tracked session options (see SetOpt).
+	opts [][2]string
+	// capture is the active workload capture (see CaptureStart).
+	capture *capture
+	// su is the active impersonation (see Su).
+	su *impersonation
 	// out file or pipe
 	out io.WriteCloser
+	// policy is the statement policy.
+	policy *stmt.Policy
+	// scratchURL is the local scratchpad database connection information.
+	scratchURL *dburl.URL
+	// scratch is the local scratchpad database connection.
+	scratch *sql.DB
+	// cache is the on-disk metadata cache for the active connection, if any.
+	cache *metadata.Cache
+	// cacheCancel stops listening for metadata changes.
+	cacheCancel context.CancelFunc
+	// autosaved is the query buffer last written to the recovery file.
+	autosaved string
+	// recovered is the query buffer recovered from a previous session.
+	recovered string
+	// claimed is the recovery file claimed from a previous session.
+	claimed string
 }
 
 // New creates a new input handler.
 func New(l rline.IO, user *user.User, wd string, charts billy.Filesystem, nopw bool) *Handler {
+	var h *Handler
 	f, iactive := l.Next, l.Interactive()
 	if iactive {
 		f = func() ([]rune, error) {
+			// save buffer for recovery
+			h.autosave()
 			// next line
 			r, err := l.Next()
 			if err != nil {
@@ -111,7 +172,7 @@ func New(l rline.IO, user *user.User, wd string, charts billy.Filesystem, nopw b
 			return r, nil
 		}
 	}
-	h := &Handler{
+	h = &Handler{
 		l:      l,
 		user:   user,
 		wd:     wd,
@@ -120,6 +181,7 @@ func New(l rline.IO, user *user.User, wd string, charts billy.Filesystem, nopw b
 		buf:    stmt.New(f),
 	}
 	if iactive {
+		h.loadRecovered()
 		l.SetOutput(h.outputHighlighter)
 		l.Completer(completer.NewDefaultCompleter(completer.WithConnStrings(h.connStrings())))
 	}
@@ -136,6 +198,12 @@ func (h *Handler) SetTiming(timing bool) {
 	h.timing = timing
 }
 
+// SetPolicy sets the statement policy applied to statements prior to
+// execution.
+func (h *Handler) SetPolicy(policy *stmt.Policy) {
+	h.policy = policy
+}
+
 // SetSingleLineMode sets the single line mode toggle.
 func (h *Handler) SetSingleLineMode(singleLineMode bool) {
 	h.singleLineMode = singleLineMode
@@ -147,8 +215,8 @@ func (h *Handler) Run() error {
 	// display welcome info
 	if iactive && env.Get("QUIET") == "off" {
 		// logo
-		if typ := env.TermGraphics(); typ.Available() {
-			if err := typ.Encode(stdout, text.Logo); err != nil {
+		if typ, ok := env.TermGraphicsAvailable(); ok {
+			if err := env.EncodeGraphics(typ, stdout, text.Logo); err != nil {
 				return err
 			}
 		}
@@ -156,6 +224,12 @@ func (h *Handler) Run() error {
 		fmt.Fprintln(stdout, text.WelcomeDesc)
 		fmt.Fprintln(stdout)
 	}
+	if iactive {
+		if h.recovered != "" {
+			fmt.Fprintln(stdout, text.RecoverAvailable)
+		}
+		defer h.clearAutosave()
+	}
 	var cmd string
 	var paramstr string
 	var err error
@@ -163,26 +237,50 @@ func (h *Handler) Run() error {
 	var cont bool
 	var lastErr error
 	var execute bool
+	var done string
 	for {
 		execute = false
 		// set prompt
 		if iactive {
+			if done != "" {
+				env.ShellMark(stdout, done)
+				done = ""
+			}
+			if h.buf.Len == 0 {
+				env.ShellMark(stdout, "A")
+			}
 			h.l.Prompt(h.Prompt(env.Get("PROMPT1")))
 		}
 		// read next statement/command
 		switch cmd, paramstr, err = h.buf.Next(env.Untick(h.user, env.Vars(), false)); {
+		case err == nil && !h.cond.Active():
+			// discard statement, and skip all but conditional commands
+			h.buf.Truncate(h.condLen)
+			if name := strings.TrimPrefix(cmd, `\`); isCondCommand(name) {
+				opt, cont, lastErr = h.apply(stdout, stderr, name, paramstr)
+			}
 		case h.singleLineMode && err == nil:
 			execute = h.buf.Len != 0
 		case err == rline.ErrInterrupt:
 			h.buf.Reset(nil)
+			h.condLen = 0
 			continue
 		case err == io.EOF:
+			if h.cond.Depth() != 0 {
+				fmt.Fprintln(stderr, "error:", text.ErrCondUnterminated)
+				return text.ErrCondUnterminated
+			}
 			return lastErr
 		case err != nil:
 			return err
 		case cmd != "":
 			opt, cont, lastErr = h.apply(stdout, stderr, strings.TrimPrefix(cmd, `\`), paramstr)
 		}
+		// within skipped conditional branch
+		if !h.cond.Active() {
+			h.condLen = h.buf.Len
+			continue
+		}
 		if cont {
 			continue
 		}
@@ -211,13 +309,20 @@ func (h *Handler) Run() error {
 		}
 		// quit
 		if opt.Quit {
-			if h.out != nil {
-				h.out.Close()
-			}
-			return nil
+			return h.CloseOutput()
 		}
 		// execute buf
 		if execute || h.buf.Ready() || opt.Exec != metacmd.ExecNone {
+			// refuse unquoted variables in strict mode
+			if err = checkStrictVars(h.buf.Vars); err != nil {
+				lastErr = WrapErr(h.buf.String(), err)
+				h.buf.Reset(nil)
+				if !iactive && env.Get("ON_ERROR_STOP") == "on" {
+					return err
+				}
+				fmt.Fprintln(stderr, "error:", err)
+				continue
+			}
 			// intercept batch query
 			if h.u != nil {
 				typ, end, batch := drivers.IsBatchQueryPrefix(h.u, h.buf.Prefix)
@@ -272,8 +377,15 @@ func (h *Handler) Run() error {
 					out = h.out
 				}
 				ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+				if iactive {
+					env.ShellMark(stdout, "C")
+				}
+				// marked as done before the next prompt, after any error
+				done = "D;0"
 				if err = h.Execute(ctx, out, opt, h.lastExecPrefix, h.lastExec, forceBatch, h.unbind()...); err != nil {
+					done = "D;1"
 					lastErr = WrapErr(h.lastExec, err)
+					h.lastFailed, _ = lastErr.(*Error)
 					if env.Get("ON_ERROR_STOP") == "on" {
 						if iactive {
 							fmt.Fprintln(stderr, "error:", err)
@@ -293,6 +405,23 @@ func (h *Handler) Run() error {
 	}
 }
 
+// checkStrictVars returns an error when the STRICT_VARS variable is on and
+// vars contains an unquoted variable not listed in STRICT_VARS_ALLOW.
+func checkStrictVars(vars []*stmt.Var) error {
+	if env.Get("STRICT_VARS") != "on" {
+		return nil
+	}
+	allow := strings.FieldsFunc(env.Get("STRICT_VARS_ALLOW"), func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	for _, v := range vars {
+		if v.Defined && v.Quote == 0 && !slices.Contains(allow, v.Name) {
+			return fmt.Errorf(text.StrictVarDenied, v.Name)
+		}
+	}
+	return nil
+}
+
 // apply applies the command against the handler.
 func (h *Handler) apply(stdout, stderr io.Writer, cmd, paramstr string) (metacmd.Option, bool, error) {
 	// cmd = strings.TrimPrefix(cmd, `\`)
@@ -430,8 +559,21 @@ loop:
 	return lastcolor(colored) + ss[len(ss)-1] + remaining + endl
 }
 
-// Execute executes a query against the connected database.
+// Execute executes a query against the connected database, setting the
+// statement status variables (see setStatusVars), and recording the statement
+// when capturing statements (see CaptureStart).
 func (h *Handler) Execute(ctx context.Context, w io.Writer, opt metacmd.Option, prefix, sqlstr string, forceTrans bool, bind ...interface{}) error {
+	start := time.Now()
+	err := h.execute(ctx, w, opt, prefix, sqlstr, forceTrans, bind)
+	setStatusVars(time.Since(start), err)
+	h.record(start, sqlstr, bind, err)
+	return err
+}
+
+// execute executes a query against the connected database.
+func (h *Handler) execute(ctx context.Context, w io.Writer, opt metacmd.Option, prefix, sqlstr string, forceTrans bool, bind []interface{}) error {
+	// restore the print variables set with \pset --local
+	defer env.Vars().RestoreLocal()
 	if h.db == nil {
 		return text.ErrNotConnected
 	}
@@ -440,12 +582,31 @@ func (h *Handler) Execute(ctx context.Context, w io.Writer, opt metacmd.Option,
 	if err != nil {
 		return drivers.WrapErr(h.u.Driver, err)
 	}
+	// enforce read-only mode, destructive statement confirmation, and policy
+	if sqlstr, err = h.Check(sqlstr); err != nil {
+		return err
+	}
+	// tag statement
+	if tag := queryTag(h.user.Username); tag != "" {
+		sqlstr = tag + " " + sqlstr
+	}
 	// start a transaction if forced
 	if forceTrans {
 		if err = h.BeginTx(ctx, nil); err != nil {
 			return err
 		}
 	}
+	// route to replica
+	if h.onReplica, err = h.routeReplica(ctx, sqlstr); err != nil {
+		return err
+	}
+	defer func() { h.onReplica = false }()
+	if h.route != "" {
+		h.routed = "primary"
+		if h.onReplica {
+			h.routed = "replica"
+		}
+	}
 	f := h.doExecSingle
 	switch opt.Exec {
 	case metacmd.ExecExec:
@@ -456,8 +617,15 @@ func (h *Handler) Execute(ctx context.Context, w io.Writer, opt metacmd.Option,
 		f = h.doExecWatch
 	case metacmd.ExecChart:
 		f = h.doExecChart
+	case metacmd.ExecStash:
+		f = h.doExecStash
+	}
+	start := time.Now()
+	err = drivers.WrapErr(h.u.Driver, f(ctx, w, opt, prefix, sqlstr, qtyp, bind))
+	if opt.Exec != metacmd.ExecWatch {
+		h.notify(time.Since(start), err)
 	}
-	if err = drivers.WrapErr(h.u.Driver, f(ctx, w, opt, prefix, sqlstr, qtyp, bind)); err != nil {
+	if err != nil {
 		if forceTrans {
 			defer h.tx.Rollback()
 			h.tx = nil
@@ -470,12 +638,113 @@ func (h *Handler) Execute(ctx context.Context, w io.Writer, opt metacmd.Option,
 	return nil
 }
 
+// queryTag returns the comment tagging statements with the user and the
+// QUERY_TAG variable (ie, /* usql:user=jane ticket=OPS-123 */), or an empty
+// string when QUERY_TAG is not set.
+func queryTag(username string) string {
+	tag := env.Get("QUERY_TAG")
+	if tag == "" {
+		return ""
+	}
+	s := text.CommandName + ":user=" + username + " " + tag
+	return "/* " + strings.ReplaceAll(s, "*/", "* /") + " */"
+}
+
+// setStatusVars sets the status variables of a statement that finished with
+// err after d:
+//
+//	ERROR            - true when the statement failed, otherwise false
+//	SQLSTATE         - the error code of the statement, or 00000
+//	LAST_ERROR       - the error message of the last failed statement
+//	LAST_SQLSTATE    - the error code of the last failed statement
+//	LAST_DURATION_MS - the duration of the statement in milliseconds
+//
+// ROW_COUNT is set when the statement is executed, and is set to 0 when the
+// statement failed.
+func setStatusVars(d time.Duration, err error) {
+	v := env.Vars()
+	_ = v.Set("LAST_DURATION_MS", strconv.FormatInt(d.Milliseconds(), 10))
+	if err == nil {
+		_ = v.Set("ERROR", "false")
+		_ = v.Set("SQLSTATE", "00000")
+		return
+	}
+	var code string
+	if e := new(drivers.Error); errors.As(err, &e) {
+		code = e.Code()
+	}
+	_ = v.Set("ERROR", "true")
+	_ = v.Set("SQLSTATE", code)
+	_ = v.Set("LAST_ERROR", err.Error())
+	_ = v.Set("LAST_SQLSTATE", code)
+	_ = v.Set("ROW_COUNT", "0")
+}
+
+// notify notifies the user that a statement finished with err after d, when
+// d is at least the duration of the notify print variable.
+func (h *Handler) notify(d time.Duration, err error) {
+	s, _ := env.Vars().GetPrint("notify")
+	threshold, _ := time.ParseDuration(s)
+	if threshold <= 0 || d < threshold || !h.l.Interactive() {
+		return
+	}
+	msg := text.NotifySucceeded
+	if err != nil {
+		msg = text.NotifyFailed
+	}
+	if err := env.Notify(h.l.Stdout(), text.CommandName, fmt.Sprintf(msg, d.Round(time.Second))); err != nil {
+		fmt.Fprintln(h.l.Stderr(), "warning:", err)
+	}
+}
+
 // Reset resets the handler's query statement buffer.
 func (h *Handler) Reset(r []rune) {
 	h.buf.Reset(r)
 	h.lastExec, h.lastExecPrefix, h.lastPrint, h.lastRaw, h.batch, h.batchEnd = "", "", "", "", false, ""
 }
 
+// Check checks the statement sqlstr against the read-only mode (see
+// READ_ONLY), destructive statement confirmation (see CONFIRM_DESTRUCTIVE),
+// and the statement policy, returning the (possibly modified) statement.
+//
+// Commands writing to a database with statements other than the query buffer
+// (ie, \fixseq, \import, \copy) check their statements before executing
+// them.
+func (h *Handler) Check(sqlstr string) (string, error) {
+	if readOnly, confirm := env.Get("READ_ONLY") == "on", env.Get("CONFIRM_DESTRUCTIVE") == "on"; readOnly || confirm {
+		switch c := stmtclass.Classify(sqlstr); {
+		case readOnly && !c.ReadOnly():
+			return "", fmt.Errorf(text.ReadOnlyDenied, c.Kind)
+		case confirm && c.Destructive():
+			if err := h.confirmDestructive(c); err != nil {
+				return "", err
+			}
+		}
+	}
+	var driver string
+	if h.u != nil {
+		driver = h.u.Driver
+	}
+	return h.policy.Apply(driver, sqlstr)
+}
+
+// confirmDestructive asks the user to confirm the execution of the
+// destructive statement c (see CONFIRM_DESTRUCTIVE). Destructive statements
+// are refused when not interactive.
+func (h *Handler) confirmDestructive(c stmtclass.Class) error {
+	if !h.l.Interactive() {
+		return fmt.Errorf(text.DestructiveNotConfirmed, c.Verb)
+	}
+	v, err := h.ReadVar("string", fmt.Sprintf(text.DestructivePrompt, c.Verb))
+	if err != nil {
+		return err
+	}
+	if v = strings.ToLower(strings.TrimSpace(v)); v != "y" && v != "yes" {
+		return fmt.Errorf(text.DestructiveNotConfirmed, c.Verb)
+	}
+	return nil
+}
+
 // Bind sets the bind parameters for the next query execution.
 func (h *Handler) Bind(bind []interface{}) {
 	h.bind = bind
@@ -678,8 +947,20 @@ func (h *Handler) Prompt(prompt string) string {
 				buf = append(buf, '>')
 			}
 		// case 'p': // the process id of the connected backend -- never going to be supported
-		case 'R': // statement state
-			buf = append(buf, h.buf.State()...)
+		case 'R': // statement state, or @ when within a skipped conditional block branch
+			if !h.cond.Active() {
+				buf = append(buf, '@')
+			} else {
+				buf = append(buf, h.buf.State()...)
+			}
+		case 'T': // where the last statement was executed, when routing statements
+			if connected && h.routed != "" {
+				buf = append(buf, "@"+h.routed...)
+			}
+		case 'I': // the impersonated principal, when impersonating (see Su)
+			if connected && h.su != nil {
+				buf = append(buf, "["+h.su.name+"]"...)
+			}
 		case 'x': // empty when not in a transaction block, * in transaction block, ! in failed transaction block, or ? when indeterminate
 		case 'l': // line number
 		case ':': // variable value
@@ -709,8 +990,13 @@ func (h *Handler) URL() *dburl.URL {
 
 // DB returns the sql.DB for the handler.
 func (h *Handler) DB() drivers.DB {
-	if h.tx != nil {
+	switch {
+	case h.tx != nil:
 		return h.tx
+	case h.onReplica:
+		return h.replica
+	case h.conn != nil:
+		return h.conn
 	}
 	return h.db
 }
@@ -735,6 +1021,11 @@ func (h *Handler) Buf() *stmt.Stmt {
 	return h.buf
 }
 
+// Cond returns the conditional block stack.
+func (h *Handler) Cond() *stmt.Cond {
+	return &h.cond
+}
+
 // Highlight highlights using the current environment settings.
 func (h *Handler) Highlight(w io.Writer, buf string) error {
 	// create lexer, formatter, styler
@@ -770,22 +1061,63 @@ func (h *Handler) Open(ctx context.Context, params ...string) error {
 	if h.tx != nil {
 		return text.ErrPreviousTransactionExists
 	}
+	name, plaintext := params[0], env.AllowPlaintext(params[0])
+	h.bannerName = name
 	if len(params) == 1 {
 		if v, ok := env.Vars().GetConn(params[0]); ok {
 			params = v
 		}
 	}
+	h.hosts, h.hostStatus = nil, nil
+	if len(params) == 1 {
+		// resolved on every open, so that reconnecting uses the current targets
+		dsn, err := drivers.ResolveSRV(ctx, params[0])
+		if err != nil {
+			return err
+		}
+		params = []string{dsn}
+		if hosts, attrs := drivers.SplitHosts(params[0]); len(hosts) > 1 || attrs != "" {
+			return h.openHosts(ctx, hosts, attrs, name, plaintext)
+		}
+	}
+	return h.open(ctx, params, name, plaintext, "")
+}
+
+// open opens a database connection, running the authentication command of
+// the connection opened with name (see authCommand), checking the TLS policy
+// unless plaintext is allowed, and checking the session matches attrs (see
+// checkSessionAttrs). See Open.
+func (h *Handler) open(ctx context.Context, params []string, name string, plaintext bool, attrs string) error {
 	if len(params) < 2 {
 		dsn := params[0]
 		// parse dsn
 		u, err := dburl.Parse(dsn)
 		if err != nil {
+			h.diagnose(dsn, err)
 			return err
 		}
 		h.u = u
 		// force parameters
+		drivers.SetApplicationName(h.u, env.Get("APPLICATION_NAME"))
 		h.forceParams(h.u)
+		// run the authentication command when no password is present
+		if _, ok := h.u.User.Password(); !ok {
+			if authCmd := authCommand(name, h.u); authCmd != "" {
+				if err := h.authenticate(h.u, authCmd); err != nil {
+					return err
+				}
+			}
+		}
+		if !plaintext {
+			if err := drivers.CheckTLS(h.u, env.TLSPolicy()); err != nil {
+				return err
+			}
+		}
 	} else {
+		// the tls mode of a driver specific dsn cannot be determined
+		if policy := env.TLSPolicy(); policy != drivers.TLSPolicyOff && !plaintext {
+			return fmt.Errorf(text.TLSPolicyRefused, params[0], policy, "unknown")
+		}
 		h.u = &dburl.URL{
 			Driver: params[0],
 			DSN:    strings.Join(params[1:], " "),
@@ -795,6 +1127,9 @@ func (h *Handler) Open(ctx context.Context, params ...string) error {
 	var err error
 	h.db, err = drivers.Open(ctx, h.u, h.GetOutput, h.l.Stderr)
 	if err != nil && !drivers.IsPasswordErr(h.u, err) {
+		if len(params) < 2 {
+			h.diagnose(params[0], err)
+		}
 		defer h.Close()
 		return err
 	}
@@ -803,11 +1138,22 @@ func (h *Handler) Open(ctx context.Context, params ...string) error {
 	// force error/check connection
 	if err == nil {
 		if err = drivers.Ping(ctx, h.u, h.db); err == nil {
+			// checked before the session options, cache and banner, as the
+			// hosts of a multi-host connection not matching are closed
+			if err := h.checkSessionAttrs(ctx, attrs); err != nil {
+				defer h.Close()
+				return err
+			}
+			h.replayOpts(ctx)
+			h.openCache(ctx)
 			return h.Version(ctx)
 		}
 	}
 	// bail without getting password
 	if h.nopw || !drivers.IsPasswordErr(h.u, err) || len(params) > 1 || !h.l.Interactive() {
+		if !drivers.IsPasswordErr(h.u, err) && len(params) < 2 {
+			h.diagnose(params[0], err)
+		}
 		defer h.Close()
 		return err
 	}
@@ -821,7 +1167,16 @@ func (h *Handler) Open(ctx context.Context, params ...string) error {
 		return err
 	}
 	// reconnect
-	return h.Open(ctx, dsn)
+	return h.open(ctx, []string{dsn}, name, plaintext, attrs)
+}
+
+// diagnose writes the diagnostics for the database URL dsn that failed to
+// parse or connect with err to stderr, when interactive. See
+// drivers.Diagnose.
+func (h *Handler) diagnose(dsn string, err error) {
+	if h.l.Interactive() {
+		drivers.Diagnose(h.l.Stderr(), dsn, err)
+	}
 }
 
 func (h *Handler) connStrings() []string {
@@ -899,6 +1254,7 @@ func (h *Handler) Password(dsn string) (string, error) {
 	if u.User != nil {
 		user = u.User.Username()
 	}
+	env.WaitTermGraphics()
 	pass, err := h.l.Password(text.EnterPassword)
 	if err != nil {
 		return "", err
@@ -913,9 +1269,13 @@ func (h *Handler) Close() error {
 		return text.ErrPreviousTransactionExists
 	}
 	if h.db != nil {
+		h.closeCache()
+		_ = h.unpin()
 		err := h.db.Close()
 		drv := h.u.Driver
-		h.db, h.u = nil, nil
+		h.closeReplica()
+		h.db, h.u, h.su = nil, nil, nil
+		h.hosts, h.hostStatus = nil, nil
 		return drivers.WrapErr(drv, err)
 	}
 	return nil
@@ -1009,22 +1369,18 @@ func (h *Handler) ChangePassword(user string) (string, error) {
 	return drivers.ChangePassword(h.u, h.DB(), user, newpw, oldpw)
 }
 
-// Version prints the database version information after a successful connection.
+// Version prints the connect banner, summarizing the database server and
+// session, after a successful connection. See banner.
 func (h *Handler) Version(ctx context.Context) error {
-	if env.Get("SHOW_HOST_INFORMATION") != "true" || !h.l.Interactive() {
+	if env.Get("SHOW_HOST_INFORMATION") != "true" || env.Get("QUIET") == "on" || !h.l.Interactive() {
 		return nil
 	}
 	if h.db == nil {
 		return text.ErrNotConnected
 	}
-	ver, err := drivers.Version(ctx, h.u, h.DB())
-	switch {
-	case err != nil:
-		ver = fmt.Sprintf("<unknown, error: %v>", err)
-	case ver == "":
-		ver = "<unknown>"
+	if s := h.banner(drivers.Server(ctx, h.u, h.DB())); s != "" {
+		fmt.Fprintln(h.l.Stdout(), s)
 	}
-	h.Print(text.ConnInfo, h.u.Driver, ver)
 	return nil
 }
 
@@ -1038,32 +1394,62 @@ func (h *Handler) Print(s string, v ...interface{}) {
 
 // doExecWatch repeatedly executes a query against the database.
 func (h *Handler) doExecWatch(ctx context.Context, w io.Writer, opt metacmd.Option, prefix, sqlstr string, qtyp bool, bind []interface{}) error {
+	loc := time.Local
+	if tz, _ := env.Vars().GetPrint("timezone"); tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+	every := opt.Watch.String()
+	if opt.WatchAlign != 0 {
+		every = opt.WatchAlign.String() + ", aligned"
+	}
+	var deadline time.Time
+	if opt.WatchMaxRuntime != 0 {
+		deadline = time.Now().Add(opt.WatchMaxRuntime)
+	}
 	for {
 		// the actual output that psql has: "Mon Jan 2006 3:04:05 PM MST" -- which is _slightly_ different than RFC1123
 		// fmt.Fprintf(w, "%s (every %fs)\n\n", time.Now().Format("Mon Jan 2006 3:04:05 PM MST"), float64(opt.Watch)/float64(time.Second))
-		fmt.Fprintf(w, "%s (every %v)\n", time.Now().Format(time.RFC1123), opt.Watch)
+		fmt.Fprintf(w, "%s (every %s)\n", time.Now().In(loc).Format(time.RFC1123), every)
 		fmt.Fprintln(w)
 		if err := h.doExecSingle(ctx, w, opt, prefix, sqlstr, qtyp, bind); err != nil {
 			return err
 		}
+		// determine next execution
+		now := time.Now()
+		next := now.Add(opt.Watch)
+		if opt.WatchAlign != 0 {
+			next = nextAligned(now, opt.WatchAlign, loc)
+		}
+		if opt.WatchJitter != 0 {
+			next = next.Add(rand.N(opt.WatchJitter))
+		}
+		if !deadline.IsZero() && next.After(deadline) {
+			return nil
+		}
 		select {
 		case <-ctx.Done():
 			if err := ctx.Err(); err != nil && !errors.Is(err, context.Canceled) {
 				return err
 			}
 			return nil
-		case <-time.After(opt.Watch):
+		case <-time.After(next.Sub(now)):
 		}
 	}
 }
 
+// nextAligned returns the next multiple of align after now, counted from
+// midnight in loc.
+func nextAligned(now time.Time, align time.Duration, loc *time.Location) time.Time {
+	t := now.In(loc)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return midnight.Add((t.Sub(midnight)/align + 1) * align)
+}
+
 // doExecChart executes a single query against the database, displaying its output as a chart.
 func (h *Handler) doExecChart(ctx context.Context, w io.Writer, opt metacmd.Option, prefix, sqlstr string, qtyp bool, bind []interface{}) error {
-	stdout, _, _ := h.l.Stdout(), h.l.Stderr(), h.l.Interactive()
-	typ := env.TermGraphics()
-	if !typ.Available() {
-		return text.ErrGraphicsNotSupported
-	}
+	stdout := h.l.Stdout()
 	if _, ok := opt.Params["help"]; ok {
 		fmt.Fprintln(stdout, text.ChartUsage)
 		return nil
@@ -1072,22 +1458,32 @@ func (h *Handler) doExecChart(ctx context.Context, w io.Writer, opt metacmd.Opti
 	if err != nil {
 		return err
 	}
+	// size in cells
+	cols, rows, xpixel, ypixel := env.TermSize()
+	if cfg.Cols != 0 {
+		cw, ch := 10, 20
+		if cols != 0 && rows != 0 && xpixel != 0 && ypixel != 0 {
+			cw, ch = xpixel/cols, ypixel/rows
+		}
+		cfg.W, cfg.H = cfg.Cols*cw, cfg.Rows*ch
+	}
 	start := time.Now()
 	// query
-	rows, err := h.DB().QueryContext(ctx, sqlstr, bind...)
+	res, err := h.DB().QueryContext(ctx, sqlstr, bind...)
 	if err != nil {
 		return err
 	}
+	defer res.Close()
 	// get cols
-	cols, err := drivers.Columns(h.u, rows)
+	names, err := drivers.Columns(h.u, res)
 	if err != nil {
 		return err
 	}
 	// process row(s)
-	transposed := make([][]string, len(cols))
-	clen, tfmt := len(cols), env.Vars().PrintTimeFormat()
-	for rows.Next() {
-		row, err := h.scan(rows, clen, tfmt)
+	transposed := make([][]string, len(names))
+	clen, tfmt := len(names), env.Vars().PrintTimeFormat()
+	for res.Next() {
+		row, err := h.scan(res, clen, tfmt)
 		if err != nil {
 			return err
 		}
@@ -1096,28 +1492,47 @@ func (h *Handler) doExecChart(ctx context.Context, w io.Writer, opt metacmd.Opti
 		}
 	}
 	// display
-	c, err := charts.MakeChart(cfg, cols, transposed)
+	c, err := charts.MakeChart(cfg, names, transposed)
 	if err != nil {
 		return err
 	}
+	typ, ok := env.TermGraphicsAvailable()
+	if !ok && cfg.File == "" {
+		// text fallback
+		width := cfg.Cols
+		if width == 0 {
+			if width = cols; width == 0 {
+				width = 80
+			}
+		}
+		return c.WriteText(stdout, width)
+	}
 	data, err := c.ToEcharts()
 	if err != nil {
 		return err
 	}
 	echarts := echartsgoja.New(echartsgoja.WithWidthHeight(cfg.W, cfg.H))
-	res, err := echarts.RenderOptions(ctx, data)
+	svg, err := echarts.RenderOptions(ctx, data)
 	if err != nil {
 		return err
 	}
-	if cfg.File != "" {
-		fmt.Println("writing to", cfg.File)
-		return os.WriteFile(cfg.File, []byte(res), 0o644)
+	if cfg.File != "" && !strings.EqualFold(filepath.Ext(cfg.File), ".png") {
+		fmt.Println("writing to", env.Hyperlink(fileURL(cfg.File), cfg.File))
+		return os.WriteFile(cfg.File, []byte(svg), 0o644)
 	}
-	img, err := resvg.Render([]byte(res), resvg.WithBackground(cfg.Background))
+	img, err := resvg.Render([]byte(svg), resvg.WithBackground(cfg.Background))
 	if err != nil {
 		return err
 	}
-	if err := typ.Encode(stdout, img); err != nil {
+	if cfg.File != "" {
+		fmt.Println("writing to", env.Hyperlink(fileURL(cfg.File), cfg.File))
+		buf := new(bytes.Buffer)
+		if err := png.Encode(buf, img); err != nil {
+			return err
+		}
+		return os.WriteFile(cfg.File, buf.Bytes(), 0o644)
+	}
+	if err := env.EncodeGraphics(typ, stdout, img); err != nil {
 		return err
 	}
 	if h.timing {
@@ -1158,63 +1573,106 @@ func (h *Handler) doExecSingle(ctx context.Context, w io.Writer, opt metacmd.Opt
 	return nil
 }
 
-// doExecSet executes a SQL query, setting all returned columns as variables.
+// doExecSet executes a SQL query, setting the columns of the returned row as
+// variables (prefixed with the prefix param). Variables of NULL columns are
+// unset. No variables are changed when the query does not return exactly one
+// row.
 func (h *Handler) doExecSet(ctx context.Context, w io.Writer, opt metacmd.Option, prefix, sqlstr string, _ bool, bind []interface{}) error {
 	// query
 	rows, err := h.DB().QueryContext(ctx, sqlstr, bind...)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 	// get cols
 	cols, err := drivers.Columns(h.u, rows)
 	if err != nil {
 		return err
 	}
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = opt.Params["prefix"] + c
+		if err := env.ValidIdentifier(names[i]); err != nil {
+			return fmt.Errorf(text.CouldNotSetVariable, names[i])
+		}
+	}
 	// process row(s)
-	var i int
+	var n int
 	var row []string
-	clen, tfmt := len(cols), env.Vars().PrintTimeFormat()
+	var null []bool
+	tfmt := env.Vars().PrintTimeFormat()
 	for rows.Next() {
-		if i == 0 {
-			row, err = h.scan(rows, clen, tfmt)
-			if err != nil {
+		if n++; n > 1 {
+			return text.ErrGsetTooManyRows
+		}
+		r := make([]interface{}, len(cols))
+		for i := range r {
+			r[i] = new(interface{})
+		}
+		if err := rows.Scan(r...); err != nil {
+			return err
+		}
+		row, null = make([]string, len(cols)), make([]bool, len(cols))
+		for i, z := range r {
+			v := *z.(*interface{})
+			if null[i] = v == nil; null[i] {
+				continue
+			}
+			if row[i], err = h.convert(v, tfmt); err != nil {
 				return err
 			}
 		}
-		i++
 	}
-	if i > 1 {
-		return text.ErrTooManyRows
+	switch {
+	case rows.Err() != nil:
+		return rows.Err()
+	case n == 0:
+		return text.ErrGsetNoRows
 	}
 	// set vars
-	for i, c := range cols {
-		n := opt.Params["prefix"] + c
-		if err = env.ValidIdentifier(n); err != nil {
-			return fmt.Errorf(text.CouldNotSetVariable, n)
+	for i, name := range names {
+		if null[i] {
+			err = env.Vars().Unset(name)
+		} else {
+			err = env.Vars().Set(name, row[i])
+		}
+		if err != nil {
+			return err
 		}
-		_ = env.Vars().Set(n, row[i])
 	}
 	return nil
 }
 
 // doExecExec executes a query and re-executes all columns of all rows as if they
-// were their own queries.
+// were their own queries, in row order, skipping NULL and empty values. The
+// generated statements are read before being executed, so that they can be
+// executed on the same connection (ie, in a transaction). When a statement
+// fails, the remaining statements are executed unless ON_ERROR_STOP is set.
 func (h *Handler) doExecExec(ctx context.Context, w io.Writer, _ metacmd.Option, prefix, sqlstr string, qtyp bool, bind []interface{}) error {
 	// query
 	rows, err := h.DB().QueryContext(ctx, sqlstr, bind...)
 	if err != nil {
 		return err
 	}
-	// exec resulting rows
-	if err := h.doExecRows(ctx, w, rows); err != nil {
+	// read generated statements
+	stmts, err := h.readExecRows(rows)
+	if err != nil {
 		return err
 	}
-	// check for additional result sets ...
-	for rows.NextResultSet() {
-		if err := h.doExecRows(ctx, w, rows); err != nil {
-			return err
+	// execute
+	failed := 0
+	for _, s := range stmts {
+		if err := h.Execute(ctx, w, metacmd.Option{Exec: metacmd.ExecOnly}, stmt.FindPrefix(s, true, true, true), s, false); err != nil {
+			if env.Get("ON_ERROR_STOP") == "on" {
+				return err
+			}
+			fmt.Fprintln(h.l.Stderr(), "error:", err)
+			failed++
 		}
 	}
+	if failed != 0 {
+		return fmt.Errorf(text.GexecFailed, failed, len(stmts))
+	}
 	return nil
 }
 
@@ -1228,9 +1686,24 @@ func (h *Handler) doQuery(ctx context.Context, w io.Writer, opt metacmd.Option,
 	defer rows.Close()
 	params := env.Vars().Print()
 	params["time"] = env.Vars().PrintTimeFormat()
+	// format of the output file (ie, \o file.xlsx)
+	if o, ok := w.(*formats.Output); ok && o.Format() != "" {
+		params["format"] = o.Format()
+	}
+	if pipeName := opt.Params["pipe"]; pipeName != "" && pipeName[0] != '|' {
+		if f := formats.OutputFormat(pipeName); f != "" {
+			params["format"] = f
+		}
+	}
 	for k, v := range opt.Params {
 		params[k] = v
 	}
+	// title from magic comment
+	if _, ok := opt.Params["title"]; !ok {
+		if m := titleCommentRE.FindStringSubmatch(sqlstr); m != nil {
+			params["title"] = strings.TrimSpace(m[1])
+		}
+	}
 	var pipe io.WriteCloser
 	var cmd *exec.Cmd
 	if pipeName := params["pipe"]; pipeName != "" || h.out != nil {
@@ -1242,12 +1715,23 @@ func (h *Handler) doQuery(ctx context.Context, w io.Writer, opt metacmd.Option,
 			if pipeName[0] == '|' {
 				pipe, cmd, err = env.Pipe(h.l.Stdout(), h.l.Stderr(), pipeName[1:])
 			} else {
-				pipe, err = os.OpenFile(pipeName, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0o644)
+				pipe, err = env.OpenOutput(pipeName, false, func(prompt string) (string, error) {
+					return h.ReadVar("string", prompt)
+				})
 			}
 			if err != nil {
 				return err
 			}
+			if f, ok := pipe.(*env.AtomicFile); ok {
+				// discard incomplete output on error
+				defer f.Discard()
+			}
 			w = pipe
+			if cmd == nil {
+				if err := h.writeLineage(w, pipeName, params["format"], sqlstr); err != nil {
+					return err
+				}
+			}
 		}
 	} else if opt.Exec != metacmd.ExecWatch {
 		params["pager_cmd"] = env.Get("PAGER")
@@ -1260,11 +1744,68 @@ func (h *Handler) doQuery(ctx context.Context, w io.Writer, opt metacmd.Option,
 	case drivers.UseColumnTypes(h.u):
 		extra = append(extra, tblfmt.WithUseColumnTypes(true))
 	}
-	resultSet := tblfmt.ResultSet(rows)
+	rs := &autoResultSet{Rows: rows}
+	if opt.Exec != metacmd.ExecCrosstab {
+		defer func() {
+			_ = env.Vars().Set("ROW_COUNT", strconv.Itoa(rs.count))
+		}()
+	}
+	if params["coltype"] != "" {
+		types, err := env.ParseColTypes(params["coltype"])
+		if err != nil {
+			return err
+		}
+		rs.setColTypes(types)
+		// cast values are scanned without the driver's column types
+		extra = nil
+	}
+	switch params["format"] {
+	case "aligned", "vertical", "wrapped":
+		n, _ := strconv.Atoi(params["vector_preview"])
+		rs.setVectors(n)
+	}
+	// preview images when displaying to a terminal supporting graphics
+	if _, ok := env.TermGraphicsAvailable(); ok && h.l.Interactive() && w == h.l.Stdout() &&
+		(opt.Exec == metacmd.ExecNone || opt.Exec == metacmd.ExecOnly) {
+		rs.imageSize = imagePreviewSize(params["image_preview"])
+	}
+	resultSet := tblfmt.ResultSet(rs)
+	// apply display heuristics when displaying to the terminal
+	auto := h.l.Interactive() && w == h.l.Stdout() &&
+		(opt.Exec == metacmd.ExecNone || opt.Exec == metacmd.ExecOnly) &&
+		(params["format"] == "aligned" || params["format"] == "wrapped")
+	// link urls when displaying to the terminal
+	if h.l.Interactive() && w == h.l.Stdout() && env.Hyperlinks(params["hyperlinks"]) {
+		hw := &hyperlinkWriter{w: w}
+		defer hw.Flush()
+		w = hw
+	}
+	if auto {
+		switch ok, err := h.autoFormat(w, rs, params); {
+		case err != nil:
+			return err
+		case ok && !rs.NextResultSet():
+			return nil
+		}
+	}
+	// pad values to fixed column widths
+	if params["colwidth"] != "" && opt.Exec != metacmd.ExecCrosstab &&
+		(params["format"] == "aligned" || params["format"] == "wrapped") {
+		widths, err := newColWidths(h.user, params["colwidth"], sqlstr, params["null"], func(v interface{}) (string, error) {
+			return h.convert(v, params["time"])
+		})
+		if err != nil {
+			return err
+		}
+		defer widths.save(h.user)
+		rs.setColWidths(widths)
+		// padded values are scanned without the driver's column types
+		extra = nil
+	}
 	// wrap query with crosstab
 	if opt.Exec == metacmd.ExecCrosstab {
 		var err error
-		if resultSet, err = tblfmt.NewCrosstabView(rows, append(extra, tblfmt.WithParams(opt.Crosstab...))...); err != nil {
+		if resultSet, err = tblfmt.NewCrosstabView(rs, append(extra, tblfmt.WithParams(opt.Crosstab...))...); err != nil {
 			return err
 		}
 		extra = nil
@@ -1273,7 +1814,7 @@ func (h *Handler) doQuery(ctx context.Context, w io.Writer, opt metacmd.Option,
 		params["lower_column_names"] = "true"
 	}
 	// encode and handle error conditions
-	switch err := tblfmt.EncodeAll(w, resultSet, params, extra...); {
+	switch err := formats.EncodeAll(w, resultSet, params, extra...); {
 	case err != nil && cmd != nil && errors.Is(err, syscall.EPIPE):
 		// broken pipe means pager quit before consuming all data, which might be expected
 		return nil
@@ -1286,42 +1827,68 @@ func (h *Handler) doQuery(ctx context.Context, w io.Writer, opt metacmd.Option,
 	case params["format"] == "aligned":
 		fmt.Fprintln(w)
 	}
+	if len(rs.images) != 0 {
+		if err := writeImagePreviews(w, rs); err != nil {
+			return err
+		}
+	}
+	// suggest pager for large results
+	if n, _ := strconv.Atoi(params["auto_pager"]); auto && n > 0 && rs.count >= n && params["pager"] == "off" {
+		fmt.Fprintf(h.l.Stderr(), text.AutoPagerHint+"\n", rs.count)
+	}
 	if pipe != nil {
-		pipe.Close()
+		// closing renames atomic output files into place
+		closeErr := pipe.Close()
 		if cmd != nil {
 			cmd.Wait()
 		}
+		if err == nil {
+			err = closeErr
+		}
 	}
 	return err
 }
 
-// doExecRows executes all the columns in the row.
-func (h *Handler) doExecRows(ctx context.Context, w io.Writer, rows *sql.Rows) error {
-	// get columns
-	cols, err := drivers.Columns(h.u, rows)
-	if err != nil {
-		return err
-	}
-	// process rows
-	res := metacmd.Option{
-		Exec: metacmd.ExecOnly,
-	}
-	clen, tfmt := len(cols), env.Vars().PrintTimeFormat()
-	for rows.Next() {
-		if clen != 0 {
-			row, err := h.scan(rows, clen, tfmt)
-			if err != nil {
-				return err
+// readExecRows reads the non-NULL, non-empty values of all rows of all result
+// sets, closing rows.
+func (h *Handler) readExecRows(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+	var stmts []string
+	tfmt := env.Vars().PrintTimeFormat()
+	for {
+		cols, err := drivers.Columns(h.u, rows)
+		if err != nil {
+			return nil, err
+		}
+		r := make([]interface{}, len(cols))
+		for i := range r {
+			r[i] = new(interface{})
+		}
+		for rows.Next() {
+			if err := rows.Scan(r...); err != nil {
+				return nil, err
 			}
-			// execute
-			for _, sqlstr := range row {
-				if err = h.Execute(ctx, w, res, stmt.FindPrefix(sqlstr, true, true, true), sqlstr, false); err != nil {
-					return err
+			for _, z := range r {
+				v := *z.(*interface{})
+				if v == nil {
+					continue
+				}
+				s, err := h.convert(v, tfmt)
+				switch {
+				case err != nil:
+					return nil, err
+				case strings.TrimSpace(s) != "":
+					stmts = append(stmts, s)
 				}
 			}
 		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		if !rows.NextResultSet() {
+			return stmts, nil
+		}
 	}
-	return nil
 }
 
 // scan scans a row.
@@ -1334,51 +1901,46 @@ func (h *Handler) scan(rows *sql.Rows, clen int, tfmt string) ([]string, error)
 	if err := rows.Scan(r...); err != nil {
 		return nil, err
 	}
-	// get conversion funcs
-	cb, cm, cs, cd := drivers.ConvertBytes(h.u), drivers.ConvertMap(h.u), drivers.ConvertSlice(h.u), drivers.ConvertDefault(h.u)
 	row := make([]string, clen)
 	for n, z := range r {
-		j := z.(*interface{})
-		switch x := (*j).(type) {
-		case []byte:
-			if x != nil {
-				var err error
-				if row[n], err = cb(x, tfmt); err != nil {
-					return nil, err
-				}
-			}
-		case string:
-			row[n] = x
-		case time.Time:
-			row[n] = x.Format(tfmt)
-		case fmt.Stringer:
-			row[n] = x.String()
-		case map[string]interface{}:
-			if x != nil {
-				var err error
-				if row[n], err = cm(x); err != nil {
-					return nil, err
-				}
-			}
-		case []interface{}:
-			if x != nil {
-				var err error
-				if row[n], err = cs(x); err != nil {
-					return nil, err
-				}
-			}
-		default:
-			if x != nil {
-				var err error
-				if row[n], err = cd(x); err != nil {
-					return nil, err
-				}
-			}
+		var err error
+		if row[n], err = h.convert(*z.(*interface{}), tfmt); err != nil {
+			return nil, err
 		}
 	}
 	return row, nil
 }
 
+// convert converts a scanned value to its string representation, using the
+// driver's conversion funcs.
+func (h *Handler) convert(v interface{}, tfmt string) (string, error) {
+	switch x := v.(type) {
+	case []byte:
+		if x != nil {
+			return drivers.ConvertBytes(h.u)(x, tfmt)
+		}
+	case string:
+		return x, nil
+	case time.Time:
+		return x.Format(tfmt), nil
+	case fmt.Stringer:
+		return x.String(), nil
+	case map[string]interface{}:
+		if x != nil {
+			return drivers.ConvertMap(h.u)(x)
+		}
+	case []interface{}:
+		if x != nil {
+			return drivers.ConvertSlice(h.u)(x)
+		}
+	default:
+		if x != nil {
+			return drivers.ConvertDefault(h.u)(x)
+		}
+	}
+	return "", nil
+}
+
 // doExec does a database exec.
 func (h *Handler) doExec(ctx context.Context, w io.Writer, _ metacmd.Option, typ, sqlstr string, bind []interface{}) error {
 	res, err := h.DB().ExecContext(ctx, sqlstr, bind...)
@@ -1418,7 +1980,11 @@ func (h *Handler) BeginTx(ctx context.Context, txOpts *sql.TxOptions) error {
 		return text.ErrPreviousTransactionExists
 	}
 	var err error
-	h.tx, err = h.db.BeginTx(ctx, txOpts)
+	if h.conn != nil {
+		h.tx, err = h.conn.BeginTx(ctx, txOpts)
+	} else {
+		h.tx, err = h.db.BeginTx(ctx, txOpts)
+	}
 	if err != nil {
 		return drivers.WrapErr(h.u.Driver, err)
 	}
@@ -1516,10 +2082,15 @@ func (h *Handler) IncludeReader(rdr io.Reader, path string) error {
 		Pw:  h.l.Password,
 	}
 	p := New(l, h.user, filepath.Dir(path), h.charts, h.nopw)
-	p.db, p.u = h.db, h.u
+	p.db, p.conn, p.u, p.policy = h.db, h.conn, h.u, h.policy
+	p.su, p.opts = h.su, h.opts
 	drivers.ConfigStmt(p.u, p.buf)
 	err := p.Run()
-	h.db, h.u = p.db, p.u
+	if closeErr := p.CloseOutput(); err == nil {
+		err = closeErr
+	}
+	h.db, h.conn, h.u = p.db, p.conn, p.u
+	h.su, h.opts = p.su, p.opts
 	return err
 }
 
@@ -1543,7 +2114,11 @@ func (h *Handler) MetadataWriter(ctx context.Context) (metadata.Writer, error) {
 	if h.db == nil {
 		return nil, text.ErrNotConnected
 	}
-	return drivers.NewMetadataWriter(ctx, h.u, h.db, h.l.Stdout(), readerOpts()...)
+	w, err := drivers.NewMetadataWriter(ctx, h.u, h.db, h.l.Stdout(), h.readerOpts()...)
+	if err != nil || h.cache == nil {
+		return w, err
+	}
+	return h.cache.Writer(w), nil
 }
 
 // GetOutput gets the output writer.
@@ -1562,6 +2137,18 @@ func (h *Handler) SetOutput(o io.WriteCloser) {
 	h.out = o
 }
 
+// CloseOutput closes the output writer, if any, resetting the output to
+// stdout. Atomic output files (see env.AtomicFile) are only renamed into place
+// when closed, so the output writer must be closed when done running.
+func (h *Handler) CloseOutput() error {
+	if h.out == nil {
+		return nil
+	}
+	err := h.out.Close()
+	h.out = nil
+	return err
+}
+
 // FS is the filesystem interface.
 type FS interface{}
 
@@ -1593,21 +2180,6 @@ func (e *Error) Unwrap() error {
 	return e.Err
 }
 
-func readerOpts() []metadata.ReaderOption {
-	var opts []metadata.ReaderOption
-	if env.Get("ECHO_HIDDEN") == "on" || env.Get("ECHO_HIDDEN") == "noexec" {
-		if env.Get("ECHO_HIDDEN") == "noexec" {
-			opts = append(opts, metadata.WithDryRun(true))
-		}
-		opts = append(
-			opts,
-			metadata.WithLogger(log.New(os.Stdout, "DEBUG: ", log.LstdFlags)),
-			metadata.WithTimeout(30*time.Second),
-		)
-	}
-	return opts
-}
-
 // peekEnding peeks to see if the next successive bytes in r is \n or \r\n,
 // writing to w if it is. Does not advance r if the next bytes are not \n or
 // \r\n.
@@ -1673,6 +2245,16 @@ func isSpaceOrControl(r rune) bool {
 	return unicode.IsSpace(r) || unicode.IsControl(r)
 }
 
+// isCondCommand returns true when name is a conditional block command (ie,
+// \if, \elif, \else, or \endif).
+func isCondCommand(name string) bool {
+	switch name {
+	case "if", "elif", "else", "endif":
+		return true
+	}
+	return false
+}
+
 // lastIndex returns the last index in r of needle, or -1 if not found.
 func lastIndex(r []rune, needle rune) int {
 	for i := len(r) - 1; i >= 0; i-- {
@@ -1686,6 +2268,10 @@ func lastIndex(r []rune, needle rune) int {
 // ansiRE matches ansi escape (color) codes.
 var ansiRE = regexp.MustCompile(`\x1b[[0-9]+([:;][0-9]+)*m`)
 
+// titleCommentRE is a regexp matching the magic title comment of a query (ie,
+// --usql:title Revenue by region).
+var titleCommentRE = regexp.MustCompile(`(?m)^\s*--\s*` + text.CommandName + `:title\s+(.+)$`)
+
 // lineendRE is the end of line terminal.
 var lineendRE = regexp.MustCompile(`(?:\r?\n)+$`)
 
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/drivers/metadata"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+// HiddenDB returns the database connection for the internal queries of
+// commands (ie, the catalog queries of \fixseq). When ECHO_HIDDEN is set, the
+// queries are written to the output before being executed, or instead of
+// being executed when ECHO_HIDDEN is noexec.
+func (h *Handler) HiddenDB() drivers.DB {
+	echo := env.Get("ECHO_HIDDEN")
+	if h.db == nil || (echo != "on" && echo != "noexec") {
+		return h.DB()
+	}
+	return &hiddenDB{
+		DB:     h.DB(),
+		w:      h.l.Stdout(),
+		u:      h.u,
+		noexec: echo == "noexec",
+	}
+}
+
+// readerOpts returns the metadata reader options for the handler's
+// connection, writing the queries of the metadata readers (ie, \d, and
+// completion) when ECHO_HIDDEN is set.
+func (h *Handler) readerOpts() []metadata.ReaderOption {
+	var opts []metadata.ReaderOption
+	if env.Get("ECHO_HIDDEN") == "on" || env.Get("ECHO_HIDDEN") == "noexec" {
+		if env.Get("ECHO_HIDDEN") == "noexec" {
+			opts = append(opts, metadata.WithDryRun(true))
+		}
+		opts = append(
+			opts,
+			metadata.WithLogger(hiddenLogger{w: h.l.Stdout(), u: h.u}),
+			metadata.WithTimeout(30*time.Second),
+		)
+	}
+	return opts
+}
+
+// echoHidden writes an internal query and its args to w, styled with the SQL
+// style for the database URL (see SQL_KEYWORD_CASE and SQL_QUOTE_IDENT).
+func echoHidden(w io.Writer, u *dburl.URL, sqlstr string, args []interface{}) {
+	sqlstr = drivers.Style(u, env.Get("SQL_KEYWORD_CASE"), env.Get("SQL_QUOTE_IDENT")).Apply(sqlstr)
+	fmt.Fprintln(w, text.HiddenQueryHeader)
+	fmt.Fprintln(w, strings.TrimSpace(sqlstr))
+	for i, arg := range args {
+		if b, ok := arg.([]byte); ok {
+			arg = string(b)
+		}
+		fmt.Fprintf(w, text.HiddenQueryArg+"\n", i+1, arg)
+	}
+	fmt.Fprintln(w, text.HiddenQueryFooter)
+	fmt.Fprintln(w)
+}
+
+// hiddenLogger is a metadata logger writing the queries of the metadata
+// readers.
+type hiddenLogger struct {
+	w io.Writer
+	u *dburl.URL
+}
+
+// Println satisfies the metadata logger interface.
+func (l hiddenLogger) Println(v ...interface{}) {
+	fmt.Fprintln(l.w, v...)
+}
+
+// LogQuery satisfies the metadata query logger interface.
+func (l hiddenLogger) LogQuery(sqlstr string, args []interface{}) {
+	echoHidden(l.w, l.u, sqlstr, args)
+}
+
+// hiddenDB wraps a database connection, writing the queries executed on it.
+type hiddenDB struct {
+	drivers.DB
+	w      io.Writer
+	u      *dburl.URL
+	noexec bool
+}
+
+// Exec satisfies the drivers.DB interface.
+func (db *hiddenDB) Exec(sqlstr string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), sqlstr, args...)
+}
+
+// ExecContext satisfies the drivers.DB interface.
+func (db *hiddenDB) ExecContext(ctx context.Context, sqlstr string, args ...interface{}) (sql.Result, error) {
+	if echoHidden(db.w, db.u, sqlstr, args); db.noexec {
+		return nil, text.ErrHiddenNotExecuted
+	}
+	return db.DB.ExecContext(ctx, sqlstr, args...)
+}
+
+// Query satisfies the drivers.DB interface.
+func (db *hiddenDB) Query(sqlstr string, args ...interface{}) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), sqlstr, args...)
+}
+
+// QueryContext satisfies the drivers.DB interface.
+func (db *hiddenDB) QueryContext(ctx context.Context, sqlstr string, args ...interface{}) (*sql.Rows, error) {
+	if echoHidden(db.w, db.u, sqlstr, args); db.noexec {
+		return nil, text.ErrHiddenNotExecuted
+	}
+	return db.DB.QueryContext(ctx, sqlstr, args...)
+}
+
+// QueryRow satisfies the drivers.DB interface.
+func (db *hiddenDB) QueryRow(sqlstr string, args ...interface{}) *sql.Row {
+	return db.QueryRowContext(context.Background(), sqlstr, args...)
+}
+
+// QueryRowContext satisfies the drivers.DB interface. When not executing
+// queries, the row's error is the canceled context error, as a row cannot be
+// created with an error.
+func (db *hiddenDB) QueryRowContext(ctx context.Context, sqlstr string, args ...interface{}) *sql.Row {
+	if echoHidden(db.w, db.u, sqlstr, args); db.noexec {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		cancel()
+	}
+	return db.DB.QueryRowContext(ctx, sqlstr, args...)
+}
+
+// Prepare satisfies the drivers.DB interface.
+func (db *hiddenDB) Prepare(sqlstr string) (*sql.Stmt, error) {
+	return db.PrepareContext(context.Background(), sqlstr)
+}
+
+// PrepareContext satisfies the drivers.DB interface.
+func (db *hiddenDB) PrepareContext(ctx context.Context, sqlstr string) (*sql.Stmt, error) {
+	if echoHidden(db.w, db.u, sqlstr, nil); db.noexec {
+		return nil, text.ErrHiddenNotExecuted
+	}
+	return db.DB.PrepareContext(ctx, sqlstr)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"path/filepath"
+	"regexp"
+
+	"github.com/xo/usql/env"
+)
+
+// hyperlinkWriter is a writer that writes the URLs in complete lines of
+// output as terminal hyperlinks. As the escape sequences are not displayed,
+// the layout of the output is not changed.
+type hyperlinkWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// Write satisfies the [io.Writer] interface.
+func (w *hyperlinkWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	i := bytes.LastIndexByte(w.buf, '\n')
+	if i == -1 {
+		return len(p), nil
+	}
+	buf := linkURLs(w.buf[:i+1])
+	w.buf = append(w.buf[:0], w.buf[i+1:]...)
+	if _, err := w.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes any remaining partial line.
+func (w *hyperlinkWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := linkURLs(w.buf)
+	w.buf = w.buf[:0]
+	_, err := w.w.Write(buf)
+	return err
+}
+
+// linkURLs returns buf with the URLs written as terminal hyperlinks.
+func linkURLs(buf []byte) []byte {
+	return urlRE.ReplaceAllFunc(buf, func(u []byte) []byte {
+		return []byte(env.FormatHyperlink(string(u), string(u)))
+	})
+}
+
+// fileURL returns the file:// URL for the file name.
+func fileURL(name string) string {
+	if s, err := filepath.Abs(name); err == nil {
+		name = s
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(name)}).String()
+}
+
+// urlRE matches http, https, and ftp URLs.
+var urlRE = regexp.MustCompile(`\b(?:https?|ftp)://[^\s"'<>|\\]*[^\s"'<>|\\.,;:)\]]`)
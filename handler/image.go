@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"strconv"
+
+	"github.com/xo/usql/env"
+)
+
+// defaultImagePreviewSize is the maximum width and height, in pixels, of image
+// previews when the image_preview print variable is on.
+const defaultImagePreviewSize = 160
+
+// maxImagePreviews is the maximum number of image previews displayed for a
+// result set.
+const maxImagePreviews = 20
+
+// imagePreviewSize returns the maximum width and height of image previews for
+// the image_preview print variable value s (on, off, or a size in pixels), or
+// 0 when image previews are disabled.
+func imagePreviewSize(s string) int {
+	if s == "on" {
+		return defaultImagePreviewSize
+	}
+	i, _ := strconv.Atoi(s)
+	return max(i, 0)
+}
+
+// imageMagic are the magic numbers of the previewed image formats.
+var imageMagic = [][]byte{
+	[]byte("\x89PNG\r\n\x1a\n"),
+	[]byte("\xff\xd8\xff"),
+	[]byte("GIF87a"),
+	[]byte("GIF89a"),
+}
+
+// isImage returns whether buf starts with the magic number of a PNG, JPEG, or
+// GIF image.
+func isImage(buf []byte) bool {
+	for _, magic := range imageMagic {
+		if bytes.HasPrefix(buf, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// imagePreview is a preview of an image value of a result set.
+type imagePreview struct {
+	label string
+	img   image.Image
+}
+
+// previewImage returns a placeholder for v when v is a PNG, JPEG, or GIF
+// image, adding a thumbnail of the image (at most size pixels wide and high)
+// to the previews. Otherwise, returns v.
+func (rs *autoResultSet) previewImage(v interface{}, size int) interface{} {
+	buf, ok := v.([]byte)
+	if !ok || !isImage(buf) || len(rs.images) >= maxImagePreviews {
+		return v
+	}
+	img, typ, err := image.Decode(bytes.NewReader(buf))
+	if err != nil {
+		return v
+	}
+	b := img.Bounds()
+	label := fmt.Sprintf("[image %d: %s %dx%d]", len(rs.images)+1, typ, b.Dx(), b.Dy())
+	rs.images = append(rs.images, imagePreview{label, thumbnail(img, size)})
+	return label
+}
+
+// thumbnail scales img to fit within size pixels, using nearest neighbor
+// sampling. Images smaller than size are returned as is.
+func thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	tw, th := size, h*size/w
+	if h > w {
+		tw, th = w*size/h, size
+	}
+	tw, th = max(tw, 1), max(th, 1)
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := range th {
+		for x := range tw {
+			dst.Set(x, y, img.At(b.Min.X+x*w/tw, b.Min.Y+y*h/th))
+		}
+	}
+	return dst
+}
+
+// writeImagePreviews writes the image previews of rs to w using terminal
+// graphics, clearing the previews.
+func writeImagePreviews(w io.Writer, rs *autoResultSet) error {
+	typ, ok := env.TermGraphicsAvailable()
+	if !ok {
+		return nil
+	}
+	for _, preview := range rs.images {
+		fmt.Fprintln(w, preview.label)
+		if err := env.EncodeGraphics(typ, w, preview.img); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	rs.images = nil
+	return nil
+}
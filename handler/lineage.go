@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+// lineageKeys are the ordered keys of the lineage written for output files.
+var lineageKeys = []string{"source_driver", "source_host", "source_database", "query_sha256", "executed_at", "usql_version"}
+
+// lineage returns the provenance of the results of the query sqlstr.
+func (h *Handler) lineage(sqlstr string) map[string]string {
+	hash := sha256.Sum256([]byte(sqlstr))
+	m := map[string]string{
+		"query_sha256": hex.EncodeToString(hash[:]),
+		"executed_at":  time.Now().UTC().Format(time.RFC3339),
+		"usql_version": text.CommandVersion,
+	}
+	if h.u != nil {
+		m["source_driver"] = h.u.Driver
+		m["source_host"] = h.u.Host
+		m["source_database"] = strings.TrimPrefix(h.u.Path, "/")
+	}
+	return m
+}
+
+// writeLineage writes the provenance of the query results being written to
+// the output file name, as specified by the OUTPUT_LINEAGE variable.
+//
+// Lineage is written to w as a header comment when OUTPUT_LINEAGE is comment
+// and the print format supports comments. Otherwise, it is written as JSON to
+// a sidecar file named <name>.lineage.json.
+func (h *Handler) writeLineage(w io.Writer, name, format, sqlstr string) error {
+	mode := env.Get("OUTPUT_LINEAGE")
+	if mode == "off" {
+		return nil
+	}
+	m := h.lineage(sqlstr)
+	if start, end, ok := lineageComment(format); mode == "comment" && ok {
+		for _, k := range lineageKeys {
+			if _, err := fmt.Fprintf(w, "%s%s: %s%s\n", start, k, m[k], end); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	buf, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name+".lineage.json", append(buf, '\n'), 0o644)
+}
+
+// lineageComment returns the comment start and end markers for a print
+// format, and false when the format does not support comments.
+func lineageComment(format string) (string, string, bool) {
+	switch format {
+	case "aligned", "csv", "unaligned", "vertical", "wrapped":
+		return "# ", "", true
+	case "asciidoc":
+		return "// ", "", true
+	case "html":
+		return "<!-- ", " -->", true
+	case "latex", "latex-longtable":
+		return "% ", "", true
+	case "troff-ms":
+		return `.\" `, "", true
+	}
+	return "", "", false
+}
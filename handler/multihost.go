@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/text"
+)
+
+// openHosts opens the first of the single host URLs of a multi-host
+// connection, in order, whose session matches attrs (the
+// target_session_attrs query parameter), recording the status of each host
+// tried.
+//
+// As with libpq, attrs is one of any (default), read-write, read-only,
+// primary, standby, or prefer-standby.
+func (h *Handler) openHosts(ctx context.Context, hosts []string, attrs, name string, plaintext bool) error {
+	passes := []string{attrs}
+	switch attrs {
+	case "", "any", "read-write", "read-only", "primary", "standby":
+	case "prefer-standby":
+		passes = []string{"standby", "any"}
+	default:
+		return fmt.Errorf(text.InvalidSessionAttrs, attrs)
+	}
+	var status []string
+	for _, attrs := range passes {
+		for _, urlstr := range hosts {
+			err := h.open(ctx, []string{urlstr}, name, plaintext, attrs)
+			host := urlstr
+			if u, err := url.Parse(urlstr); err == nil {
+				host = u.Host
+			}
+			if err != nil {
+				status = append(status, fmt.Sprintf(text.HostFailed, host, err))
+				continue
+			}
+			h.hosts, h.hostStatus = hosts, append(status, fmt.Sprintf(text.HostConnected, host))
+			return nil
+		}
+	}
+	return fmt.Errorf(text.AllHostsFailed, strings.Join(status, "; "))
+}
+
+// checkSessionAttrs checks that the session of the open database connection
+// matches attrs.
+func (h *Handler) checkSessionAttrs(ctx context.Context, attrs string) error {
+	if attrs == "" || attrs == "any" {
+		return nil
+	}
+	ro, err := drivers.ReadOnly(ctx, h.u, h.DB())
+	switch {
+	case err != nil:
+		return err
+	case ro != (attrs == "read-only" || attrs == "standby"):
+		return fmt.Errorf(text.SessionAttrsMismatch, attrs)
+	}
+	return nil
+}
+
+// HostStatus returns the status of each host tried when opening the active
+// multi-host connection.
+func (h *Handler) HostStatus() []string {
+	return h.hostStatus
+}
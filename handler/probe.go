@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+// Probe probes the open database connection, writing a report of the server's
+// capabilities (and the features usql is able to provide for it) to w. The
+// report is written as JSON when the print format is json.
+func (h *Handler) Probe(ctx context.Context, w io.Writer) error {
+	if h.db == nil {
+		return text.ErrNotConnected
+	}
+	d := drivers.Available()[h.u.Driver]
+	var keys []string
+	report := make(map[string]string)
+	add := func(key, value string) {
+		keys, report[key] = append(keys, key), value
+	}
+	add("driver", h.u.Driver)
+	ver, err := drivers.Version(ctx, h.u, h.DB())
+	if err != nil {
+		ver = fmt.Sprintf("<unknown, error: %v>", err)
+	}
+	add("version", ver)
+	user, _ := drivers.User(ctx, h.u, h.DB())
+	add("user", user)
+	// metadata
+	switch {
+	case d.NewMetadataWriter != nil:
+		add("metadata", "custom")
+	case d.NewMetadataReader != nil:
+		add("metadata", "full")
+	default:
+		add("metadata", "none")
+	}
+	add("completion", yesNo(d.NewCompleter != nil || d.NewMetadataReader != nil))
+	add("tls", probeTLS(h.u.Query()))
+	add("copy", yesNo(d.Copy != nil))
+	add("change_password", yesNo(drivers.CanChangePassword(h.u) == nil))
+	// transactions
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err == nil {
+		err = tx.Rollback()
+	}
+	add("transactions", yesNo(err == nil))
+	// cancel
+	cancel := false
+	if conn, err := h.db.Conn(ctx); err == nil {
+		_ = conn.Raw(func(dc interface{}) error {
+			_, q := dc.(driver.QueryerContext)
+			_, e := dc.(driver.ExecerContext)
+			cancel = q || e
+			return nil
+		})
+		conn.Close()
+	}
+	add("cancel", yesNo(cancel))
+	// latency
+	var pings []time.Duration
+	for range 3 {
+		start := time.Now()
+		if err := drivers.Ping(ctx, h.u, h.db); err != nil {
+			break
+		}
+		pings = append(pings, time.Since(start))
+	}
+	latency := "<unknown>"
+	if len(pings) != 0 {
+		slices.Sort(pings)
+		latency = fmt.Sprintf("%0.3f", float64(pings[len(pings)/2].Microseconds())/1000)
+	}
+	add("latency_ms", latency)
+	// write
+	if format, _ := env.Vars().GetPrint("format"); format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", key, report[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// probeTLS returns the TLS mode specified by the connection's query
+// parameters.
+func probeTLS(q map[string][]string) string {
+	for _, k := range []string{"sslmode", "tls", "encrypt", "secure", "ssl"} {
+		if v, ok := q[k]; ok && len(v) != 0 {
+			return k + "=" + v[0]
+		}
+	}
+	return "<unknown>"
+}
+
+// yesNo returns "yes" or "no" for b.
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"os"
+
+	"github.com/xo/usql/env"
+)
+
+// loadRecovered loads the query buffer saved by a previous session that did
+// not exit cleanly, if any.
+//
+// Only recovery files of instances that are no longer running are considered.
+// The most recent one is claimed by renaming it to this instance's recovery
+// file, so that it is not offered to other instances, and is replaced once
+// the query buffer is autosaved.
+func (h *Handler) loadRecovered() {
+	name := env.RecoverFile(h.user)
+	for _, stale := range env.StaleRecoverFiles(h.user) {
+		if err := os.Rename(stale, name); err != nil {
+			// claimed by another instance
+			continue
+		}
+		if buf, err := os.ReadFile(name); err == nil && len(buf) != 0 {
+			h.recovered, h.claimed = string(buf), name
+			return
+		}
+		_ = os.Remove(name)
+	}
+}
+
+// autosave writes the query buffer to the recovery file when it has changed
+// since last written, removing the file once the buffer is empty. The file is
+// written to a temporary file first, so that a crash while writing never
+// leaves a partial file in place.
+//
+// Errors are ignored, as autosave is only best effort.
+func (h *Handler) autosave() {
+	s := h.buf.RawString()
+	if s == h.autosaved {
+		return
+	}
+	h.autosaved = s
+	name := env.RecoverFile(h.user)
+	if s == "" {
+		_ = os.Remove(name)
+		return
+	}
+	if err := os.WriteFile(name+".tmp", []byte(s), 0o600); err == nil {
+		_ = os.Rename(name+".tmp", name)
+	}
+}
+
+// clearAutosave removes the recovery file written by autosave, or claimed
+// from a previous session (which is kept until then, in case this session
+// does not exit cleanly either), if any.
+func (h *Handler) clearAutosave() {
+	if h.claimed != "" {
+		_ = os.Remove(h.claimed)
+	}
+	if h.autosaved != "" {
+		_ = os.Remove(env.RecoverFile(h.user))
+	}
+	h.autosaved, h.claimed = "", ""
+}
+
+// Recovered returns the query buffer saved by a previous session that did not
+// exit cleanly, if any. The buffer is only returned once.
+func (h *Handler) Recovered() string {
+	s := h.recovered
+	h.recovered = ""
+	return s
+}
@@ -0,0 +1,400 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xo/usql/drivers"
+)
+
+// autoResultSet wraps a result set, buffering the rows read ahead by the
+// display heuristics, counting the rows read, summarizing the values of
+// vector columns, casting the values of columns with a display type, and
+// padding the values of columns with a fixed width.
+type autoResultSet struct {
+	*sql.Rows
+	buf      [][]interface{}
+	row      []interface{}
+	count    int
+	vectors  []bool
+	preview  int
+	coltypes []string
+	types    map[string]string
+	// set is the index of the current result set
+	set int
+	// widths are the fixed column widths, nil when disabled
+	widths *colWidths
+	// imageSize is the maximum size of image previews, 0 when disabled
+	imageSize int
+	images    []imagePreview
+}
+
+// Next satisfies the tblfmt.ResultSet interface.
+func (rs *autoResultSet) Next() bool {
+	if len(rs.buf) != 0 {
+		rs.row, rs.buf = rs.buf[0], rs.buf[1:]
+		rs.count++
+		return true
+	}
+	rs.row = nil
+	if rs.Rows.Next() {
+		rs.count++
+		return true
+	}
+	return false
+}
+
+// Scan satisfies the tblfmt.ResultSet interface.
+func (rs *autoResultSet) Scan(dest ...interface{}) error {
+	row := rs.row
+	switch {
+	case row == nil && rs.vectors == nil && rs.coltypes == nil && rs.imageSize == 0 && rs.widths == nil:
+		return rs.Rows.Scan(dest...)
+	case row == nil:
+		row = make([]interface{}, len(dest))
+		ptrs := make([]interface{}, len(dest))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rs.Rows.Scan(ptrs...); err != nil {
+			return err
+		}
+	}
+	if len(dest) != len(row) {
+		return fmt.Errorf("expected %d destination arguments in Scan, not %d", len(row), len(dest))
+	}
+	for i, d := range dest {
+		v := row[i]
+		if rs.vectors != nil && rs.vectors[i] {
+			v = formatVector(v, rs.preview)
+		}
+		if rs.imageSize != 0 {
+			v = rs.previewImage(v, rs.imageSize)
+		}
+		if rs.coltypes != nil && rs.coltypes[i] != "" {
+			v = castValue(v, rs.coltypes[i])
+		}
+		if rs.widths != nil {
+			var err error
+			if v, err = rs.widths.value(i, v); err != nil {
+				return err
+			}
+		}
+		if err := assign(d, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NextResultSet satisfies the tblfmt.ResultSet interface.
+func (rs *autoResultSet) NextResultSet() bool {
+	if !rs.Rows.NextResultSet() {
+		return false
+	}
+	rs.set++
+	rs.setVectors(rs.preview)
+	rs.setColTypes(rs.types)
+	rs.setColWidths(rs.widths)
+	return true
+}
+
+// setVectors determines the vector columns of the current result set, whose
+// values are displayed as a summary with the first n elements.
+func (rs *autoResultSet) setVectors(n int) {
+	rs.vectors, rs.preview = nil, n
+	if n <= 0 {
+		return
+	}
+	cols, err := rs.Rows.ColumnTypes()
+	if err != nil {
+		return
+	}
+	for i, col := range cols {
+		if isVectorType(col.DatabaseTypeName()) {
+			if rs.vectors == nil {
+				rs.vectors = make([]bool, len(cols))
+			}
+			rs.vectors[i] = true
+		}
+	}
+}
+
+// setColTypes determines the display type of the columns of the current
+// result set from the column names in types (see env.ParseColTypes).
+func (rs *autoResultSet) setColTypes(types map[string]string) {
+	rs.coltypes, rs.types = nil, types
+	if len(types) == 0 {
+		return
+	}
+	cols, err := rs.Rows.Columns()
+	if err != nil {
+		return
+	}
+	rs.coltypes = make([]string, len(cols))
+	for i, col := range cols {
+		typ, ok := types[col]
+		if !ok {
+			for k, v := range types {
+				if strings.EqualFold(k, col) {
+					typ, ok = v, true
+					break
+				}
+			}
+		}
+		if !ok {
+			typ = types["*"]
+		}
+		rs.coltypes[i] = typ
+	}
+}
+
+// setColWidths sets the fixed column widths of the current result set.
+func (rs *autoResultSet) setColWidths(widths *colWidths) {
+	if rs.widths = widths; widths == nil {
+		return
+	}
+	cols, err := rs.Rows.Columns()
+	if err != nil {
+		rs.widths = nil
+		return
+	}
+	widths.start(rs.set, cols)
+}
+
+// readAhead buffers up to n rows of the current result set.
+func (rs *autoResultSet) readAhead(n int) error {
+	cols, err := rs.Rows.Columns()
+	if err != nil {
+		return err
+	}
+	for len(rs.buf) < n && rs.Rows.Next() {
+		row := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rs.Rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		rs.buf = append(rs.buf, row)
+	}
+	return rs.Rows.Err()
+}
+
+// assign assigns a buffered value to the scan destination d.
+func assign(d, v interface{}) error {
+	switch x := d.(type) {
+	case *interface{}:
+		*x = v
+		return nil
+	case sql.Scanner:
+		return x.Scan(v)
+	}
+	dv := reflect.ValueOf(d)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return fmt.Errorf("destination not a pointer: %T", d)
+	}
+	e, src := dv.Elem(), reflect.ValueOf(v)
+	switch {
+	case v == nil:
+		e.Set(reflect.Zero(e.Type()))
+	case src.Type().AssignableTo(e.Type()):
+		e.Set(src)
+	case e.Kind() == reflect.String && src.Kind() != reflect.Slice:
+		e.SetString(fmt.Sprint(v))
+	case src.Type().ConvertibleTo(e.Type()):
+		e.Set(src.Convert(e.Type()))
+	default:
+		return fmt.Errorf("unsupported Scan, storing %T into type %T", v, d)
+	}
+	return nil
+}
+
+// autoFormat applies the display heuristics enabled by the auto_* print
+// variables to the current result set of rs, returning true when the result
+// set was written to w.
+func (h *Handler) autoFormat(w io.Writer, rs *autoResultSet, params map[string]string) (bool, error) {
+	if params["auto_expanded"] == "on" && params["expanded"] == "off" {
+		params["expanded"] = "auto"
+	}
+	cols, err := rs.ColumnTypes()
+	if err != nil || len(cols) != 1 {
+		return false, nil
+	}
+	isJSON := params["auto_json"] == "on" && isJSONType(cols[0].DatabaseTypeName())
+	if params["auto_value"] != "on" && !isJSON {
+		return false, nil
+	}
+	if err := rs.readAhead(2); err != nil {
+		return false, err
+	}
+	if !isJSON && len(rs.buf) != 1 {
+		return false, nil
+	}
+	for rs.Next() {
+		var v interface{}
+		if err := rs.Scan(&v); err != nil {
+			return true, err
+		}
+		s, err := h.convert(v, params["time"])
+		switch {
+		case err != nil:
+			return true, err
+		case v == nil:
+			s = params["null"]
+		case isJSON:
+			buf := new(bytes.Buffer)
+			if json.Indent(buf, []byte(s), "", "  ") == nil {
+				s = buf.String()
+			}
+		}
+		fmt.Fprintln(w, s)
+	}
+	return true, rs.Err()
+}
+
+// isVectorType returns true when typ is a vector database type name.
+func isVectorType(typ string) bool {
+	switch typ = strings.ToUpper(typ); {
+	case typ == "VECTOR", typ == "HALFVEC", strings.HasPrefix(typ, "VECTOR("):
+		// pgvector, oracle, mariadb
+		return true
+	case typ == "ARRAY(FLOAT32)", typ == "ARRAY(FLOAT64)":
+		// clickhouse
+		return true
+	case strings.HasPrefix(typ, "FLOAT[") && strings.HasSuffix(typ, "]"):
+		// duckdb
+		return true
+	}
+	return false
+}
+
+// formatVector formats a vector value v as a summary of its dimensions, norm,
+// and first n elements, returning v unchanged when it is not a vector.
+func formatVector(v interface{}, n int) interface{} {
+	var vec []float64
+	switch x := v.(type) {
+	case []byte:
+		if json.Unmarshal(x, &vec) != nil {
+			return v
+		}
+	case string:
+		if json.Unmarshal([]byte(x), &vec) != nil {
+			return v
+		}
+	case []float64:
+		vec = x
+	case []float32:
+		for _, f := range x {
+			vec = append(vec, float64(f))
+		}
+	case []interface{}:
+		for _, z := range x {
+			switch f := z.(type) {
+			case float32:
+				vec = append(vec, float64(f))
+			case float64:
+				vec = append(vec, f)
+			default:
+				return v
+			}
+		}
+	default:
+		return v
+	}
+	var sum float64
+	elems := make([]string, 0, n+1)
+	for i, f := range vec {
+		sum += f * f
+		if i < n {
+			elems = append(elems, strconv.FormatFloat(f, 'g', 4, 64))
+		}
+	}
+	if len(vec) > n {
+		elems = append(elems, "...")
+	}
+	return fmt.Sprintf("[%s] (%d dims, norm %.4g)", strings.Join(elems, ", "), len(vec), math.Sqrt(sum))
+}
+
+// castValue casts the string value v to the display type typ (see
+// env.ColTypes), returning v unchanged when it cannot be cast. Values of
+// other types are only cast to text.
+func castValue(v interface{}, typ string) interface{} {
+	var s string
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case string:
+		s = x
+	case []byte:
+		s = string(x)
+	default:
+		if typ == "text" {
+			return fmt.Sprint(v)
+		}
+		return v
+	}
+	z := strings.TrimSpace(s)
+	switch typ {
+	case "text":
+		return s
+	case "int":
+		if i, err := strconv.ParseInt(z, 10, 64); err == nil {
+			return i
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(z, 64); err == nil {
+			return f
+		}
+	case "bool":
+		if b, ok := drivers.ParseBoolValue(z); ok {
+			return b
+		}
+	case "timestamp":
+		if t, ok := drivers.ParseTimeValue(z); ok {
+			return t
+		}
+		// unix epoch seconds or milliseconds
+		if i, err := strconv.ParseInt(z, 10, 64); err == nil {
+			if i > 1e12 || i < -1e12 {
+				return time.UnixMilli(i)
+			}
+			return time.Unix(i, 0)
+		}
+	case "auto":
+		if i, err := strconv.ParseInt(z, 10, 64); err == nil {
+			return i
+		}
+		if f, err := strconv.ParseFloat(z, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return f
+		}
+		switch strings.ToLower(z) {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+		if t, ok := drivers.ParseTimeValue(z); ok {
+			return t
+		}
+	}
+	return v
+}
+
+// isJSONType returns true when typ is a JSON database type name.
+func isJSONType(typ string) bool {
+	switch strings.ToUpper(typ) {
+	case "JSON", "JSONB":
+		return true
+	}
+	return false
+}
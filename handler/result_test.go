@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCastValue(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		v   interface{}
+		typ string
+		exp interface{}
+	}{
+		{nil, "int", nil},
+		{"42", "int", int64(42)},
+		{[]byte(" 42 "), "int", int64(42)},
+		{"4.2", "int", "4.2"},
+		{"4.2", "float", 4.2},
+		{"abc", "float", "abc"},
+		{"yes", "bool", true},
+		{"0", "bool", false},
+		{"maybe", "bool", "maybe"},
+		{" 42 ", "text", " 42 "},
+		{int64(42), "text", "42"},
+		{int64(42), "float", int64(42)},
+		{"2026-01-02T03:04:05Z", "timestamp", ts},
+		{"2026-01-02 03:04:05", "timestamp", ts},
+		{"1767323045", "timestamp", time.Unix(1767323045, 0)},
+		{"1767323045000", "timestamp", time.UnixMilli(1767323045000)},
+		{"now", "timestamp", "now"},
+		{"42", "auto", int64(42)},
+		{"4.2", "auto", 4.2},
+		{"Inf", "auto", "Inf"},
+		{"TRUE", "auto", true},
+		{"false", "auto", false},
+		{"2026-01-02T03:04:05Z", "auto", ts},
+		{"abc", "auto", "abc"},
+		{[]byte("abc"), "auto", []byte("abc")},
+	}
+	for i, test := range tests {
+		if v := castValue(test.v, test.typ); !reflect.DeepEqual(v, test.exp) {
+			t.Errorf("test %d expected %#v, got: %#v", i, test.exp, v)
+		}
+	}
+}
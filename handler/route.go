@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/stmtclass"
+	"github.com/xo/usql/text"
+)
+
+// Route sets the routing mode of statements between the primary and a
+// replica of a multi-host connection, returning the routing mode. When mode
+// is empty, the routing mode is returned as is.
+//
+// The routing modes are:
+//
+//	primary - execute all statements on the primary (default)
+//	replica - execute all statements on a replica
+//	auto    - execute read-only queries on a replica, and all other statements on the primary
+//
+// Statements within a transaction, or with session state set on a pinned
+// session connection (see Su and SetOpt), are always executed on the primary.
+func (h *Handler) Route(mode string) (string, error) {
+	switch mode {
+	case "":
+	case "primary", "replica", "auto":
+		h.route, h.replicaErr = mode, nil
+	default:
+		return "", fmt.Errorf(text.InvalidRoute, mode)
+	}
+	if h.route == "" {
+		return "primary", nil
+	}
+	return h.route, nil
+}
+
+// routeReplica returns whether the statement sqlstr should be executed on a
+// replica, opening the replica connection when needed.
+func (h *Handler) routeReplica(ctx context.Context, sqlstr string) (bool, error) {
+	switch {
+	case h.route == "" || h.route == "primary" || h.tx != nil:
+		return false, nil
+	case h.conn != nil || h.su != nil:
+		// the replica has none of the impersonation or session options of
+		// the primary's session
+		return false, nil
+	case h.route == "auto":
+		if c := stmtclass.Classify(sqlstr); c.Kind != stmtclass.Select || !c.ReadOnly() {
+			return false, nil
+		}
+	}
+	if h.replica == nil {
+		// in auto mode, fallback to the primary, without retrying to open
+		// the replica for each statement
+		if h.route == "auto" && h.replicaErr != nil {
+			return false, nil
+		}
+		err := h.openReplica(ctx)
+		switch {
+		case err != nil && h.route == "auto":
+			h.replicaErr = err
+			return false, nil
+		case err != nil:
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// openReplica opens a connection to the first read-only host of the active
+// multi-host connection, using the same credentials and parameters as the
+// primary. As with the primary, the replica must satisfy the TLS policy,
+// unless plaintext is allowed for the connection.
+func (h *Handler) openReplica(ctx context.Context) error {
+	plaintext := env.AllowPlaintext(h.bannerName)
+	for _, urlstr := range h.hosts {
+		v, err := url.Parse(urlstr)
+		if err != nil || v.Host == h.u.Host {
+			continue
+		}
+		z := h.u.URL
+		z.Host = v.Host
+		u, err := dburl.Parse(z.String())
+		if err != nil {
+			continue
+		}
+		if !plaintext {
+			if err := drivers.CheckTLS(u, env.TLSPolicy()); err != nil {
+				return err
+			}
+		}
+		db, err := drivers.Open(ctx, u, h.GetOutput, h.l.Stderr)
+		if err != nil {
+			continue
+		}
+		if ro, err := drivers.ReadOnly(ctx, u, db); err != nil || !ro {
+			db.Close()
+			continue
+		}
+		h.replicaURL, h.replica = u, db
+		return nil
+	}
+	return text.ErrNoReplica
+}
+
+// closeReplica closes the replica connection.
+func (h *Handler) closeReplica() {
+	if h.replica != nil {
+		h.replica.Close()
+	}
+	h.replicaURL, h.replica, h.replicaErr, h.routed = nil, nil, nil, ""
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xo/usql/text"
+)
+
+func TestRouteReplicaSu(t *testing.T) {
+	ctx := context.Background()
+	for _, mode := range []string{"replica", "auto"} {
+		h := &Handler{route: mode}
+		if _, err := h.routeReplica(ctx, "select 1"); mode == "replica" && err != text.ErrNoReplica {
+			t.Errorf("mode %q expected error %v, got: %v", mode, text.ErrNoReplica, err)
+		}
+		h.replicaErr = nil
+		h.su = &impersonation{name: "alice", reset: "RESET ROLE"}
+		switch ok, err := h.routeReplica(ctx, "select 1"); {
+		case err != nil:
+			t.Errorf("mode %q expected no error, got: %v", mode, err)
+		case ok:
+			t.Errorf("mode %q expected statement to not be routed to a replica while impersonating", mode)
+		}
+	}
+}
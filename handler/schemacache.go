@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/drivers/completer"
+	"github.com/xo/usql/drivers/metadata"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+// openCache opens the on-disk metadata cache for the open database connection
+// when the SCHEMA_CACHE variable is on, and sets the completer to complete
+// using the cached metadata, refreshing expired metadata in the background.
+//
+// When the SCHEMA_CACHE_LISTEN variable is set, cached metadata is
+// invalidated on notifications received on the channel. The payload of a
+// notification is a comma-separated list of the changed schemas, or empty
+// when all cached metadata should be invalidated.
+func (h *Handler) openCache(ctx context.Context) {
+	h.closeCache()
+	if env.Get("SCHEMA_CACHE") != "on" {
+		return
+	}
+	ttl, _ := time.ParseDuration(env.Get("SCHEMA_CACHE_TTL"))
+	hash := sha256.Sum256([]byte(h.u.Driver + ":" + h.u.DSN))
+	h.cache = metadata.NewCache(filepath.Join(env.SchemaCacheDir(h.user), hex.EncodeToString(hash[:])+".json"), ttl)
+	if channel := env.Get("SCHEMA_CACHE_LISTEN"); channel != "" {
+		var listenCtx context.Context
+		listenCtx, h.cacheCancel = context.WithCancel(context.Background())
+		go func(u *dburl.URL, db *sql.DB, cache *metadata.Cache) {
+			err := drivers.Listen(listenCtx, u, db, channel, func(payload string) {
+				_ = cache.Invalidate(strings.FieldsFunc(payload, func(r rune) bool {
+					return r == ',' || unicode.IsSpace(r)
+				})...)
+			})
+			if err != nil && listenCtx.Err() == nil {
+				fmt.Fprintln(h.l.Stderr(), "error:", err)
+			}
+		}(h.u, h.db, h.cache)
+	}
+	if !h.l.Interactive() {
+		return
+	}
+	// same as the completer defaults, as completion is very interactive
+	opts := append([]metadata.ReaderOption{
+		metadata.WithTimeout(3 * time.Second),
+		metadata.WithLimit(1000),
+	}, h.readerOpts()...)
+	r, err := drivers.NewMetadataReader(ctx, h.u, h.db, h.l.Stdout(), opts...)
+	if err != nil {
+		return
+	}
+	if c := drivers.NewCompleter(ctx, h.u, h.db, h.readerOpts(), completer.WithConnStrings(h.connStrings()), completer.WithReader(h.cache.BackgroundReader(r))); c != nil {
+		h.l.Completer(c)
+	}
+}
+
+// RefreshMetadata clears the on-disk metadata cache for the open database
+// connection, forcing metadata to be read again.
+func (h *Handler) RefreshMetadata() error {
+	if h.db == nil {
+		return text.ErrNotConnected
+	}
+	if h.cache == nil {
+		return nil
+	}
+	return h.cache.Refresh()
+}
+
+// closeCache stops listening for metadata changes, and closes the metadata
+// cache.
+func (h *Handler) closeCache() {
+	if h.cacheCancel != nil {
+		h.cacheCancel()
+		h.cacheCancel = nil
+	}
+	if h.cache != nil && h.l.Interactive() {
+		h.l.Completer(completer.NewDefaultCompleter(completer.WithConnStrings(h.connStrings())))
+	}
+	h.cache = nil
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/formats"
+	"github.com/xo/usql/metacmd"
+	"github.com/xo/usql/stmt"
+	"github.com/xo/usql/text"
+)
+
+// scratchDrivers are the drivers usable for the local scratchpad database, in
+// order of preference.
+var scratchDrivers = []string{"duckdb", "sqlite3", "moderncsqlite"}
+
+// openScratch opens the local scratchpad database, if not already open.
+func (h *Handler) openScratch(ctx context.Context) error {
+	if h.scratch != nil {
+		return nil
+	}
+	available := drivers.Available()
+	for _, name := range scratchDrivers {
+		if _, ok := available[name]; !ok {
+			continue
+		}
+		u, err := dburl.Parse(name + ":" + env.ScratchFile(h.user))
+		if err != nil {
+			return err
+		}
+		db, err := drivers.Open(ctx, u, h.l.Stdout, h.l.Stderr)
+		if err != nil {
+			return err
+		}
+		h.scratchURL, h.scratch = u, db
+		return nil
+	}
+	return text.ErrNoScratchDriver
+}
+
+// doExecStash executes a SQL query, storing the results as a table in the
+// local scratchpad database.
+func (h *Handler) doExecStash(ctx context.Context, w io.Writer, opt metacmd.Option, _, sqlstr string, _ bool, bind []interface{}) error {
+	if err := h.openScratch(ctx); err != nil {
+		return err
+	}
+	// query
+	rows, err := h.DB().QueryContext(ctx, sqlstr, bind...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	// (re)create table
+	table := opt.Params["name"]
+	defs := make([]string, len(cols))
+	for i, col := range cols {
+		defs[i] = scratchQuote(col.Name()) + " " + scratchType(col.ScanType())
+	}
+	if _, err := h.scratch.ExecContext(ctx, "DROP TABLE IF EXISTS "+scratchQuote(table)); err != nil {
+		return drivers.WrapErr(h.scratchURL.Driver, err)
+	}
+	if _, err := h.scratch.ExecContext(ctx, "CREATE TABLE "+scratchQuote(table)+" ("+strings.Join(defs, ", ")+")"); err != nil {
+		return drivers.WrapErr(h.scratchURL.Driver, err)
+	}
+	// copy
+	query := "INSERT INTO " + scratchQuote(table) + " VALUES (" + strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ") + ")"
+	count, err := drivers.Available()[h.scratchURL.Driver].Copy(ctx, h.scratch, rows, query)
+	if err != nil {
+		return drivers.WrapErr(h.scratchURL.Driver, err)
+	}
+	if env.Get("QUIET") == "off" {
+		fmt.Fprintln(w, "STASH", count)
+	}
+	return env.Vars().Set("ROW_COUNT", strconv.FormatInt(count, 10))
+}
+
+// Scratch executes a query against the local scratchpad database, writing the
+// results to the handler's output.
+func (h *Handler) Scratch(ctx context.Context, sqlstr string) error {
+	if err := h.openScratch(ctx); err != nil {
+		return err
+	}
+	w := h.GetOutput()
+	prefix, sqlstr, qtyp, err := drivers.Process(h.scratchURL, stmt.FindPrefix(sqlstr, true, true, true), sqlstr)
+	if err != nil {
+		return err
+	}
+	if !qtyp {
+		res, err := h.scratch.ExecContext(ctx, sqlstr)
+		if err != nil {
+			return drivers.WrapErr(h.scratchURL.Driver, err)
+		}
+		count, err := drivers.RowsAffected(h.scratchURL, res)
+		if err != nil {
+			return err
+		}
+		if env.Get("QUIET") == "off" {
+			fmt.Fprint(w, prefix)
+			if count > 0 {
+				fmt.Fprint(w, " ", count)
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	}
+	rows, err := h.scratch.QueryContext(ctx, sqlstr)
+	if err != nil {
+		return drivers.WrapErr(h.scratchURL.Driver, err)
+	}
+	defer rows.Close()
+	params := env.Vars().Print()
+	params["time"] = env.Vars().PrintTimeFormat()
+	return formats.EncodeAll(w, rows, params)
+}
+
+// scratchQuote quotes an identifier for the local scratchpad database.
+func scratchQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// scratchType returns the local scratchpad database column type for a scan
+// type.
+func scratchType(typ reflect.Type) string {
+	if typ == nil {
+		return "VARCHAR"
+	}
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch typ {
+	case reflect.TypeOf(time.Time{}), reflect.TypeOf(sql.NullTime{}):
+		return "TIMESTAMP"
+	case reflect.TypeOf(sql.NullBool{}):
+		return "BOOLEAN"
+	case reflect.TypeOf(sql.NullInt16{}), reflect.TypeOf(sql.NullInt32{}), reflect.TypeOf(sql.NullInt64{}):
+		return "BIGINT"
+	case reflect.TypeOf(sql.NullFloat64{}):
+		return "DOUBLE"
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "BIGINT"
+	case reflect.Float32, reflect.Float64:
+		return "DOUBLE"
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "BLOB"
+		}
+	}
+	return "VARCHAR"
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/text"
+)
+
+// session is a connection pinned from the database pool, on which session
+// state (impersonation, session options) is set and all later statements are
+// executed.
+//
+// The pool otherwise hands statements to any of its connections, and other
+// connections are kept open by schema cache listeners and background metadata
+// reads, so session state set on one connection would not reliably apply to
+// later statements.
+type session struct {
+	*sql.Conn
+}
+
+// Exec satisfies the drivers.DB interface.
+func (s session) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return s.ExecContext(context.Background(), query, args...)
+}
+
+// Query satisfies the drivers.DB interface.
+func (s session) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return s.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRow satisfies the drivers.DB interface.
+func (s session) QueryRow(query string, args ...interface{}) *sql.Row {
+	return s.QueryRowContext(context.Background(), query, args...)
+}
+
+// Prepare satisfies the drivers.DB interface.
+func (s session) Prepare(query string) (*sql.Stmt, error) {
+	return s.PrepareContext(context.Background(), query)
+}
+
+// pin pins a session connection from the database pool, if not already
+// pinned, returning the connection to set session state on. Session state
+// cannot be set in a transaction started before the connection was pinned, as
+// the transaction's connection is returned to the pool when it ends.
+func (h *Handler) pin(ctx context.Context) (drivers.DB, error) {
+	switch {
+	case h.tx != nil && h.conn == nil:
+		return nil, text.ErrPreviousTransactionExists
+	case h.tx != nil:
+		return h.tx, nil
+	case h.conn == nil:
+		conn, err := h.db.Conn(ctx)
+		if err != nil {
+			return nil, drivers.WrapErr(h.u.Driver, err)
+		}
+		h.conn = &session{conn}
+	}
+	return h.conn, nil
+}
+
+// unpin returns the pinned session connection, if any, to the pool.
+func (h *Handler) unpin() error {
+	if h.conn == nil {
+		return nil
+	}
+	err := h.conn.Close()
+	h.conn = nil
+	return err
+}
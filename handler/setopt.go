@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/text"
+)
+
+// SetOpt sets the session option name to value on the open database, using
+// the statement of the driver's dialect (see drivers.SetOption). The option is
+// tracked, and is set again when (re)connecting. An empty value stops
+// tracking the option, leaving the session as is.
+//
+// Options are set on the pinned session connection (see pin), on which all
+// later statements are executed.
+func (h *Handler) SetOpt(ctx context.Context, name, value string) error {
+	name = drivers.OptionName(name)
+	i := slices.IndexFunc(h.opts, func(opt [2]string) bool {
+		return opt[0] == name
+	})
+	if value == "" {
+		if i != -1 {
+			h.opts = slices.Delete(h.opts, i, i+1)
+		}
+		return nil
+	}
+	if h.db == nil {
+		return text.ErrNotConnected
+	}
+	if err := h.setOpt(ctx, name, value); err != nil {
+		return err
+	}
+	if i != -1 {
+		h.opts[i][1] = value
+	} else {
+		h.opts = append(h.opts, [2]string{name, value})
+	}
+	return nil
+}
+
+// Opts returns the tracked session options, in the order first set.
+func (h *Handler) Opts() [][2]string {
+	return h.opts
+}
+
+// setOpt sets the session option name to value.
+func (h *Handler) setOpt(ctx context.Context, name, value string) error {
+	sqlstr, err := drivers.SetOption(h.u, name, value)
+	if err != nil {
+		return err
+	}
+	db, err := h.pin(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, sqlstr)
+	return drivers.WrapErr(h.u.Driver, err)
+}
+
+// replayOpts sets the tracked session options on a newly opened connection,
+// writing a warning for each option that could not be set.
+func (h *Handler) replayOpts(ctx context.Context) {
+	for _, opt := range h.opts {
+		if err := h.setOpt(ctx, opt[0], opt[1]); err != nil {
+			fmt.Fprintln(h.l.Stderr(), fmt.Sprintf(text.SetOptFailed, opt[0], err))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+// impersonation is an active impersonation of a principal (see Su).
+type impersonation struct {
+	// name is the impersonated principal.
+	name string
+	// reset is the statement reverting the impersonation.
+	reset string
+	// dsn is the database URL to reconnect with to revert the impersonation,
+	// when impersonating using proxy authentication.
+	dsn string
+}
+
+// Su impersonates the principal name on the open database (see
+// drivers.Impersonate), to test row-level security and permissions as another
+// principal. The name - reverts the impersonation.
+//
+// Principals impersonated using a statement are impersonated on the pinned
+// session connection (see pin), on which all later statements are executed.
+//
+// Principals impersonated using proxy authentication reconnect to the
+// database, and the impersonation is otherwise reverted when disconnecting.
+func (h *Handler) Su(ctx context.Context, name string) error {
+	if h.db == nil {
+		return text.ErrNotConnected
+	}
+	if err := h.revert(ctx); err != nil || name == "-" {
+		return err
+	}
+	imp, err := drivers.Impersonate(h.u, name)
+	if err != nil {
+		return err
+	}
+	if imp.URL == "" {
+		db, err := h.pin(ctx)
+		if err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, imp.Set); err != nil {
+			return drivers.WrapErr(h.u.Driver, err)
+		}
+		h.su = &impersonation{name: name, reset: imp.Reset}
+		return nil
+	}
+	dsn := h.u.String()
+	if err := h.reconnect(ctx, imp.URL); err != nil {
+		// restore the original connection
+		if err := h.reconnect(ctx, dsn); err != nil {
+			return err
+		}
+		return err
+	}
+	h.su = &impersonation{name: name, dsn: dsn}
+	return nil
+}
+
+// Impersonating returns the impersonated principal, or an empty string when
+// not impersonating.
+func (h *Handler) Impersonating() string {
+	if h.su == nil {
+		return ""
+	}
+	return h.su.name
+}
+
+// revert reverts the active impersonation, if any.
+func (h *Handler) revert(ctx context.Context) error {
+	su := h.su
+	switch {
+	case su == nil:
+		return nil
+	case su.dsn != "":
+		return h.reconnect(ctx, su.dsn)
+	}
+	db, err := h.pin(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, su.reset); err != nil {
+		return drivers.WrapErr(h.u.Driver, err)
+	}
+	h.su = nil
+	return nil
+}
+
+// reconnect closes the open database and opens the database URL dsn as the
+// connection name it was opened with (see Open), so that the banner and the
+// options of a named connection (ie, allow_plaintext) still apply.
+func (h *Handler) reconnect(ctx context.Context, dsn string) error {
+	if err := h.Close(); err != nil {
+		return err
+	}
+	return h.open(ctx, []string{dsn}, h.bannerName, env.AllowPlaintext(h.bannerName), "")
+}
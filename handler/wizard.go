@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/xo/dburl"
+	"github.com/xo/usql/drivers"
+	"github.com/xo/usql/env"
+	"github.com/xo/usql/text"
+)
+
+// wizardTLS are the query parameters used by the connect wizard for the
+// require, verify, and disable TLS modes of a driver.
+var wizardTLS = map[string][3]string{
+	"clickhouse": {"secure=true&skip_verify=true", "secure=true", "secure=false"},
+	"mysql":      {"tls=skip-verify", "tls=true", "tls=false"},
+	"pgx":        {"sslmode=require", "sslmode=verify-full", "sslmode=disable"},
+	"postgres":   {"sslmode=require", "sslmode=verify-full", "sslmode=disable"},
+	"sqlserver":  {"encrypt=true&TrustServerCertificate=true", "encrypt=true", "encrypt=disable"},
+}
+
+// wizardKeys are the ordered keys of the connection components written by
+// the connect wizard.
+var wizardKeys = []string{"protocol", "hostname", "port", "database", "username", "file", "params", "auth_command"}
+
+// Wizard interactively builds a database connection, prompting for the
+// driver, host, port, database, user, authentication method, and TLS mode.
+// The connection is then opened, and can be saved as a named connection.
+func (h *Handler) Wizard(ctx context.Context) error {
+	if !h.l.Interactive() {
+		return text.ErrNotInteractive
+	}
+	env.WaitTermGraphics()
+	stdout := h.l.Stdout()
+	fmt.Fprintf(stdout, text.WizardDrivers+"\n", strings.Join(slices.Sorted(maps.Keys(drivers.Available())), ", "))
+	name, err := h.ask(text.WizardDriver, "")
+	if err != nil {
+		return err
+	}
+	drv, _ := dburl.SchemeDriverAndAliases(name)
+	if drv == "" || !drivers.Registered(drv) {
+		return drivers.WrapErr(name, text.ErrDriverNotAvailable)
+	}
+	m := map[string]interface{}{"protocol": name}
+	if slices.Contains(dburl.FileTypes(), drv) {
+		if m["file"], err = h.ask(text.WizardFile, ""); err != nil {
+			return err
+		}
+	} else {
+		for _, v := range []struct {
+			key, prompt, def string
+		}{
+			{"hostname", text.WizardHost, "localhost"},
+			{"port", text.WizardPort, ""},
+			{"database", text.WizardDatabase, ""},
+			{"username", text.WizardUser, h.user.Username},
+		} {
+			if m[v.key], err = h.ask(v.prompt, v.def); err != nil {
+				return err
+			}
+		}
+	}
+	// authentication
+	auth, err := h.choose(text.WizardAuth, "password", "none", "command")
+	if err != nil {
+		return err
+	}
+	if auth == "command" {
+		fmt.Fprintln(stdout, text.WizardAuthExamples)
+		if m["auth_command"], err = h.ask(text.WizardAuthCommand, ""); err != nil {
+			return err
+		}
+	}
+	// tls
+	mode, err := h.choose(text.WizardTLS, "default", "require", "verify", "disable")
+	if err != nil {
+		return err
+	}
+	if mode != "default" {
+		params, ok := wizardTLS[drv]
+		switch {
+		case !ok:
+			fmt.Fprintf(stdout, text.WizardTLSUnknown+"\n", drv)
+		case mode == "require":
+			m["params"] = params[0]
+		case mode == "verify":
+			m["params"] = params[1]
+		default:
+			m["params"] = params[2]
+		}
+	}
+	urlstr, err := dburl.BuildURL(m)
+	if err != nil {
+		return err
+	}
+	// test
+	u, err := dburl.Parse(urlstr)
+	if err != nil {
+		return err
+	}
+	switch auth {
+	case "password":
+		pass, err := h.l.Password(text.EnterPassword)
+		if err != nil {
+			return err
+		}
+		var username string
+		if u.User != nil {
+			username = u.User.Username()
+		}
+		u.User = url.UserPassword(username, pass)
+	case "command":
+		if err := h.authenticate(u, m["auth_command"].(string)); err != nil {
+			return err
+		}
+	}
+	if err := h.Open(ctx, u.String()); err != nil {
+		return err
+	}
+	// save
+	if name, err = h.ask(text.WizardSave, ""); err != nil || name == "" {
+		return err
+	}
+	if err := env.Vars().SetConn(name, urlstr); err != nil {
+		return err
+	}
+	if auth == "command" {
+		env.Vars().SetConnOpt(name, "auth_command", m["auth_command"].(string))
+	}
+	fmt.Fprintf(stdout, text.WizardSaved+"\n", name)
+	fmt.Fprintf(stdout, "connections:\n  %s:\n", name)
+	for _, k := range wizardKeys {
+		if s, _ := m[k].(string); s != "" {
+			fmt.Fprintf(stdout, "    %s: %q\n", k, s)
+		}
+	}
+	return nil
+}
+
+// ask prompts for a value, returning def when no value is entered.
+func (h *Handler) ask(prompt, def string) (string, error) {
+	if def != "" {
+		prompt += " [" + def + "]"
+	}
+	s, err := h.ReadVar("string", prompt+": ")
+	switch s = strings.TrimSpace(s); {
+	case err != nil:
+		return "", err
+	case s == "":
+		return def, nil
+	}
+	return s, nil
+}
+
+// choose prompts for one of the choices, returning the first choice when no
+// value is entered.
+func (h *Handler) choose(prompt string, choices ...string) (string, error) {
+	s, err := h.ask(prompt+" ("+strings.Join(choices, ", ")+")", choices[0])
+	switch s = strings.ToLower(s); {
+	case err != nil:
+		return "", err
+	case !slices.Contains(choices, s):
+		return "", fmt.Errorf(text.InvalidWizardChoice, s, strings.Join(choices, ", "))
+	}
+	return s, nil
+}
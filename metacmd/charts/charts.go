@@ -4,11 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"image/color"
+	"io"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kenshaw/colors"
+	"github.com/mattn/go-runewidth"
 	"github.com/xo/usql/text"
 )
 
@@ -19,10 +23,19 @@ type ChartConfig struct {
 	Background color.Color
 	Type       string
 	Prec       int
+	Bucket     time.Duration
+	Agg        string
+	X          string
+	Y          []string
+	// Cols, Rows are the size of the chart in terminal cells, when set.
+	Cols, Rows int
 
 	File string
 }
 
+// Types are the chart types.
+var Types = []string{"bar", "line", "scatter", "pie"}
+
 func ParseArgs(opts map[string]string) (ChartConfig, error) {
 	cfg := ChartConfig{
 		Title:      opts["title"],
@@ -47,6 +60,28 @@ func ParseArgs(opts map[string]string) (ChartConfig, error) {
 			return ChartConfig{}, fmt.Errorf(text.ChartParseFailed, "size", err)
 		}
 	}
+	if cells, ok := opts["cells"]; ok {
+		b, a, ok := strings.Cut(cells, "x")
+		if !ok {
+			return ChartConfig{}, fmt.Errorf(text.ChartParseFailed, "cells", "provide cells as NxN")
+		}
+		var err error
+		if cfg.Cols, err = strconv.Atoi(b); err != nil || cfg.Cols <= 0 {
+			return ChartConfig{}, fmt.Errorf(text.ChartParseFailed, "cells", "provide cells as NxN")
+		}
+		if cfg.Rows, err = strconv.Atoi(a); err != nil || cfg.Rows <= 0 {
+			return ChartConfig{}, fmt.Errorf(text.ChartParseFailed, "cells", "provide cells as NxN")
+		}
+	}
+	if typ := cfg.Type; typ != "" && !slices.Contains(Types, typ) {
+		return ChartConfig{}, fmt.Errorf(text.ChartParseFailed, "type", "provide type as "+strings.Join(Types, ", "))
+	}
+	cfg.X = opts["x"]
+	if y := opts["y"]; y != "" {
+		for _, s := range strings.Split(y, ",") {
+			cfg.Y = append(cfg.Y, strings.TrimSpace(s))
+		}
+	}
 	if c, ok := opts["bg"]; ok {
 		var err error
 		cfg.Background, err = colors.Parse(c)
@@ -64,6 +99,21 @@ func ParseArgs(opts map[string]string) (ChartConfig, error) {
 	if file, ok := opts["file"]; ok {
 		cfg.File = file
 	}
+	if bucket, ok := opts["bucket"]; ok {
+		d, err := time.ParseDuration(bucket)
+		if err != nil || d <= 0 {
+			return ChartConfig{}, fmt.Errorf(text.ChartParseFailed, "bucket", "provide bucket as a duration (ie, 5m)")
+		}
+		cfg.Bucket, cfg.Agg = d, "avg"
+	}
+	if agg, ok := opts["agg"]; ok {
+		switch agg {
+		case "avg", "sum", "min", "max", "count":
+		default:
+			return ChartConfig{}, fmt.Errorf(text.ChartParseFailed, "agg", "provide agg as avg, sum, min, max, or count")
+		}
+		cfg.Agg = agg
+	}
 	return cfg, nil
 }
 
@@ -74,6 +124,11 @@ type Chart struct {
 	XAxis    Series
 	YAxis    Series
 	Series   []Series
+
+	// type, labels, and values are used when writing the chart as text.
+	typ    string
+	labels []string
+	values [][]float64
 }
 
 type Series struct {
@@ -126,28 +181,238 @@ func MakeChart(cfg ChartConfig, cols []string, transposed [][]string) (*Chart, e
 	if cfg.Type != "" {
 		chartType = cfg.Type
 	}
-	c.XAxis = Series{
-		Name: cols[x],
-		Type: "category",
-		Data: transposed[x],
+	if cfg.X != "" {
+		if x = columnIndex(cols, cfg.X); x == -1 {
+			return nil, fmt.Errorf(text.ChartParseFailed, "x", fmt.Sprintf("no column named %q", cfg.X))
+		}
+	}
+	ys := make([]int, 0, len(cols))
+	for _, y := range cfg.Y {
+		i := columnIndex(cols, y)
+		switch {
+		case i == -1:
+			return nil, fmt.Errorf(text.ChartParseFailed, "y", fmt.Sprintf("no column named %q", y))
+		case numCols[i] == nil:
+			return nil, fmt.Errorf(text.ChartParseFailed, "y", fmt.Sprintf("column %q is not numeric", y))
+		}
+		ys = append(ys, i)
+	}
+	if len(ys) == 0 {
+		for i := range cols {
+			if i != x && numCols[i] != nil {
+				ys = append(ys, i)
+			}
+		}
+	}
+	if len(ys) == 0 {
+		return nil, text.ErrNoNumericColumns
+	}
+	xdata := transposed[x]
+	if cfg.Bucket != 0 {
+		var err error
+		if xdata, numCols, err = bucket(cfg, x, xdata, numCols); err != nil {
+			return nil, err
+		}
+	}
+	c.typ, c.labels = chartType, xdata
+	for _, i := range ys {
+		c.values = append(c.values, numCols[i])
+	}
+	switch chartType {
+	case "pie":
+		// the first y column, named by the x column
+		data := make([]pieValue, len(xdata))
+		for i, name := range xdata {
+			data[i] = pieValue{name, numCols[ys[0]][i]}
+		}
+		c.Legend = xdata
+		c.Series = []Series{{Name: cols[ys[0]], Type: chartType, Data: data}}
+		return c, nil
+	case "scatter":
+		if numCols[x] == nil {
+			return nil, fmt.Errorf(text.ChartParseFailed, "x", fmt.Sprintf("column %q is not numeric", cols[x]))
+		}
+		c.XAxis = Series{Name: cols[x], Type: "value"}
+	default:
+		c.XAxis = Series{Name: cols[x], Type: "category", Data: xdata}
 	}
 	c.YAxis = Series{
 		Type: "value",
 	}
-	for i, col := range cols {
-		if i == x {
-			continue
+	for _, i := range ys {
+		var data any = numCols[i]
+		if chartType == "scatter" {
+			points := make([][2]float64, len(numCols[i]))
+			for j, f := range numCols[i] {
+				points[j] = [2]float64{numCols[x][j], f}
+			}
+			data = points
 		}
-		c.Legend = append(c.Legend, col)
+		c.Legend = append(c.Legend, cols[i])
 		c.Series = append(c.Series, Series{
-			Name: col,
+			Name: cols[i],
 			Type: chartType,
-			Data: numCols[i],
+			Data: data,
 		})
 	}
 	return c, nil
 }
 
+// pieValue is a value of a pie chart.
+type pieValue struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// columnIndex returns the index of the column named name, or -1.
+func columnIndex(cols []string, name string) int {
+	return slices.IndexFunc(cols, func(col string) bool {
+		return strings.EqualFold(col, name)
+	})
+}
+
+// bucket groups the values of the numeric columns into time buckets of the x
+// column, aggregating the values in each bucket.
+func bucket(cfg ChartConfig, x int, xs []string, numCols [][]float64) ([]string, [][]float64, error) {
+	keys := make([]int64, len(xs))
+	for i, v := range xs {
+		t, err := parseTime(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf(text.ChartParseFailed, "bucket", err)
+		}
+		keys[i] = t.Truncate(cfg.Bucket).UnixNano()
+	}
+	order := slices.Clone(keys)
+	slices.Sort(order)
+	order = slices.Compact(order)
+	xdata := make([]string, len(order))
+	for i, k := range order {
+		xdata[i] = time.Unix(0, k).UTC().Format("2006-01-02 15:04:05")
+	}
+	bucketed := make([][]float64, len(numCols))
+	for i, col := range numCols {
+		if i == x || col == nil {
+			continue
+		}
+		vals := make([][]float64, len(order))
+		for j, f := range col {
+			n, _ := slices.BinarySearch(order, keys[j])
+			vals[n] = append(vals[n], f)
+		}
+		bucketed[i] = make([]float64, len(order))
+		for j, v := range vals {
+			bucketed[i][j] = aggregate(cfg.Agg, v)
+		}
+	}
+	return xdata, bucketed, nil
+}
+
+// aggregate aggregates the values v.
+func aggregate(agg string, v []float64) float64 {
+	switch agg {
+	case "count":
+		return float64(len(v))
+	case "min":
+		return slices.Min(v)
+	case "max":
+		return slices.Max(v)
+	}
+	var sum float64
+	for _, f := range v {
+		sum += f
+	}
+	if agg == "sum" {
+		return sum
+	}
+	return sum / float64(len(v))
+}
+
+// timeLayouts are the layouts used to parse time values.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// parseTime parses a time value.
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a time", s)
+}
+
+// WriteText writes the chart as text to w, using at most width columns.
+// Bar and pie charts are written as horizontal bars for each value, and line
+// and scatter charts are written as a sparkline for each series.
+func (c Chart) WriteText(w io.Writer, width int) error {
+	if c.Title != "" {
+		fmt.Fprintln(w, c.Title)
+	}
+	if c.Subtitle != "" {
+		fmt.Fprintln(w, c.Subtitle)
+	}
+	for i, vals := range c.values {
+		if c.typ == "pie" && i != 0 {
+			break
+		}
+		fmt.Fprintln(w, c.Series[min(i, len(c.Series)-1)].Name+":")
+		switch c.typ {
+		case "line", "scatter":
+			lo, hi := slices.Min(vals), slices.Max(vals)
+			fmt.Fprintf(w, "  %s  %g .. %g\n", sparkline(vals, lo, hi, width-4), lo, hi)
+			continue
+		}
+		n := 0
+		for _, label := range c.labels {
+			n = max(n, runewidth.StringWidth(label))
+		}
+		n = min(n, width/3)
+		var total float64
+		for _, f := range vals {
+			total += f
+		}
+		hi := max(slices.Max(vals), 0)
+		for j, f := range vals {
+			label := runewidth.Truncate(c.labels[j], n, "…")
+			value := strconv.FormatFloat(f, 'f', -1, 64)
+			if c.typ == "pie" && total != 0 {
+				value = fmt.Sprintf("%.1f%%", f/total*100)
+			}
+			size := width - n - len(value) - 6
+			if hi > 0 && f > 0 {
+				size = int(math.Round(f / hi * float64(size)))
+			} else {
+				size = 0
+			}
+			fmt.Fprintf(w, "  %s  %s %s\n", runewidth.FillRight(label, n), strings.Repeat("█", size), value)
+		}
+	}
+	return nil
+}
+
+// sparkline returns the values as a sparkline of at most width runes,
+// sampling the values when there are more values than width.
+func sparkline(vals []float64, lo, hi float64, width int) string {
+	const ticks = "▁▂▃▄▅▆▇█"
+	runes := []rune(ticks)
+	n := min(len(vals), max(width, 1))
+	var b strings.Builder
+	for i := range n {
+		f := vals[i*len(vals)/n]
+		j := len(runes) - 1
+		if hi > lo {
+			j = int((f - lo) / (hi - lo) * float64(len(runes)-1))
+		}
+		b.WriteRune(runes[j])
+	}
+	return b.String()
+}
+
 /* echarts */
 
 type echarts struct {
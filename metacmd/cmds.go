@@ -2,21 +2,33 @@ package metacmd
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"database/sql"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
+	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/xo/dburl"
+	"github.com/xo/dburl/passfile"
 	"github.com/xo/usql/drivers"
 	"github.com/xo/usql/env"
+	"github.com/xo/usql/formats"
+	"github.com/xo/usql/metacmd/charts"
+	"github.com/xo/usql/sqlhelp"
+	"github.com/xo/usql/stmt"
+	"github.com/xo/usql/stmtclass"
 	"github.com/xo/usql/text"
 )
 
@@ -39,8 +51,8 @@ func Quit(p *Params) error {
 //	copyright	show usage and distribution terms for {{CommandName}}
 func Copyright(p *Params) error {
 	stdout := p.Handler.IO().Stdout()
-	if typ := env.TermGraphics(); typ.Available() {
-		typ.Encode(stdout, text.Logo)
+	if typ, ok := env.TermGraphicsAvailable(); ok {
+		env.EncodeGraphics(typ, stdout, text.Logo)
 	}
 	fmt.Fprintln(stdout, text.Copyright)
 	return nil
@@ -130,6 +142,76 @@ func Help(p *Params) error {
 	return nil
 }
 
+// SQLHelp is a Help meta command (\h, \help). Writes the help on the syntax of
+// a SQL statement or function of the connected database's dialect, or on a
+// meta command, to the output.
+//
+// Descs:
+//
+//	h	[NAME]	help on syntax of SQL commands and functions, or meta commands
+//	help
+func SQLHelp(p *Params) error {
+	vals, err := p.All(true)
+	if err != nil {
+		return err
+	}
+	name, dialect := strings.TrimSpace(strings.Join(vals, " ")), "sql"
+	if u := p.Handler.URL(); u != nil {
+		dialect = sqlhelp.Dialect(u.Driver)
+	}
+	var entries []sqlhelp.Entry
+	if name != "" && !strings.HasPrefix(name, `\`) {
+		if entries, err = sqlhelp.Lookup(dialect, name); err != nil {
+			return err
+		}
+	}
+	var cmds []desc
+	if name != "" && len(entries) == 0 {
+		if cmds = lookupDescs(strings.TrimPrefix(strings.Fields(name)[0], `\`)); len(cmds) == 0 {
+			fmt.Fprintf(p.Handler.IO().Stdout(), text.NoHelpAvailable+"\n", name)
+			return nil
+		}
+	}
+	stdout, stderr := p.Handler.IO().Stdout(), p.Handler.IO().Stderr()
+	var cmd *exec.Cmd
+	var wc io.WriteCloser
+	if pager := env.Get("PAGER"); p.Handler.IO().Interactive() && pager != "" {
+		if wc, cmd, err = env.Pipe(stdout, stderr, pager); err != nil {
+			return err
+		}
+		stdout = wc
+	}
+	switch {
+	case name == "":
+		stmts, funcs, err := sqlhelp.Names(dialect)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Available help (%s):\n", sqlhelp.Title(dialect))
+		writeColumns(stdout, stmts)
+		fmt.Fprintln(stdout, "\nFunctions:")
+		writeColumns(stdout, funcs)
+	case len(cmds) != 0:
+		dumpDescs(stdout, cmds)
+	default:
+		for i, entry := range entries {
+			if i != 0 {
+				fmt.Fprintln(stdout)
+			}
+			if err := entry.Write(stdout); err != nil {
+				return err
+			}
+		}
+	}
+	if cmd != nil {
+		if err := wc.Close(); err != nil {
+			return err
+		}
+		return cmd.Wait()
+	}
+	return nil
+}
+
 // Execute is a Query Execute meta command (\g and variants). Executes the
 // active query on the open database connection.
 //
@@ -224,9 +306,216 @@ func Timing(p *Params) error {
 	return nil
 }
 
+// Stash is a Query Execute meta command (\stash). Executes the active query on
+// the open database connection and stores the results as a table in the local
+// scratchpad database, replacing any existing table with the same name.
+//
+// Descs:
+//
+//	stash	NAME	execute query and store results in the local scratchpad
+func Stash(p *Params) error {
+	name, err := p.Next(true)
+	switch {
+	case err != nil:
+		return err
+	case name == "":
+		return text.ErrMissingRequiredArgument
+	}
+	if err := env.ValidIdentifier(name); err != nil {
+		return err
+	}
+	p.Option.Exec = ExecStash
+	p.Option.Params = map[string]string{"name": name}
+	return nil
+}
+
+// Scratch is a Query Execute meta command (\scratch). Executes a query against
+// the local scratchpad database containing the results stored by \stash.
+//
+// Descs:
+//
+//	scratch	QUERY	execute query on the local scratchpad
+func Scratch(p *Params) error {
+	sqlstr := strings.TrimSpace(p.Raw())
+	if sqlstr == "" {
+		return text.ErrMissingRequiredArgument
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	return p.Handler.Scratch(ctx, sqlstr)
+}
+
+// TimeSeries is a Query Execute meta command (\ts). Expands a time-series
+// shorthand into a query, and executes it on the open database connection,
+// leaving the query buffer unchanged.
+//
+// The only supported shorthand is last, which selects the rows of a table
+// whose time column (default time) is within the duration before now.
+//
+// Descs:
+//
+//	ts	last DURATION TABLE [COLUMN]	execute query for the rows of a table in the last duration
+func TimeSeries(p *Params) error {
+	params, err := p.All(true)
+	switch {
+	case err != nil:
+		return err
+	case len(params) < 3 || len(params) > 4:
+		return text.ErrWrongNumberOfArguments
+	case params[0] != "last":
+		return fmt.Errorf(text.InvalidTSShorthand, params[0])
+	}
+	d, err := parseTimeSeriesDuration(params[1])
+	if err != nil {
+		return err
+	}
+	col := "time"
+	if len(params) == 4 {
+		col = params[3]
+	}
+	u := p.Handler.URL()
+	if u == nil {
+		return text.ErrNotConnected
+	}
+	sqlstr := fmt.Sprintf(
+		"SELECT * FROM %s WHERE %s >= %s ORDER BY %s",
+		params[2], col, drivers.Placeholder(u, 1), col,
+	)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	opt := Option{Exec: ExecOnly}
+	return p.Handler.Execute(ctx, p.Handler.GetOutput(), opt, stmt.FindPrefix(sqlstr, true, true, true), sqlstr, false, time.Now().UTC().Add(-d))
+}
+
+// Assist is a Query Execute meta command (\ai). Generates a query from a
+// natural language prompt using the language model endpoint specified by the
+// AI_URL variable, placing it on the query buffer. The query is only executed
+// once confirmed by the user.
+//
+// Descs:
+//
+//	ai	PROMPT	generate query from prompt with a language model (see AI_URL), executing once confirmed
+func Assist(p *Params) error {
+	params, err := p.All(true)
+	switch {
+	case err != nil:
+		return err
+	case len(params) == 0:
+		return text.ErrMissingRequiredArgument
+	case !p.Handler.IO().Interactive():
+		return text.ErrNotInteractive
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	sqlstr, err := p.Handler.Assist(ctx, strings.Join(params, " "))
+	if err != nil {
+		return err
+	}
+	buf := p.Handler.Buf()
+	buf.Reset(nil)
+	buf.AppendString(sqlstr, "")
+	buf.Prefix = stmt.FindPrefix(sqlstr, true, true, true)
+	writeSQL(p.Handler, p.Handler.IO().Stdout(), sqlstr)
+	v, err := p.readVar(text.AIExecutePrompt)
+	if err != nil {
+		return err
+	}
+	if v = strings.ToLower(strings.TrimSpace(v)); v != "y" && v != "yes" {
+		p.Handler.Print(text.AIQueryNotExecuted)
+		return nil
+	}
+	p.Option.Exec = ExecOnly
+	return nil
+}
+
+// NearestNeighbors is a Query Execute meta command (\knn). Builds a query on
+// the query buffer selecting the nearest neighbors of a vector in a table's
+// vector column, and executes it on the open database connection.
+//
+// The vector is either a JSON array of numbers, or the name of a file
+// containing one (for example, an embedding exported from another system).
+//
+// Descs:
+//
+//	knn	TABLE COLUMN VECTOR [K]	execute query for the K nearest neighbors of a vector (or JSON file)
+func NearestNeighbors(p *Params) error {
+	params, err := p.All(true)
+	switch {
+	case err != nil:
+		return err
+	case len(params) < 3 || len(params) > 4:
+		return text.ErrWrongNumberOfArguments
+	}
+	k := 10
+	if len(params) == 4 {
+		if k, err = strconv.Atoi(params[3]); err != nil || k <= 0 {
+			return fmt.Errorf(text.InvalidKnnCount, params[3])
+		}
+	}
+	vec, err := readVector(params[2])
+	if err != nil {
+		return err
+	}
+	sqlstr, err := drivers.NearestNeighbors(p.Handler.URL(), params[0], params[1], vec, k)
+	if err != nil {
+		return err
+	}
+	buf := p.Handler.Buf()
+	buf.Reset(nil)
+	buf.AppendString(sqlstr, "")
+	buf.Prefix = stmt.FindPrefix(sqlstr, true, true, true)
+	p.Option.Exec = ExecOnly
+	return nil
+}
+
+// readVector reads a vector from s, or the file named s, as a JSON array of
+// numbers, returning it formatted as a vector literal (ie, [1,2,3]).
+func readVector(s string) (string, error) {
+	buf := []byte(strings.TrimSpace(s))
+	if !bytes.HasPrefix(buf, []byte("[")) {
+		var err error
+		if buf, err = os.ReadFile(s); err != nil {
+			return "", err
+		}
+	}
+	vec, ok := drivers.ParseVector(string(buf))
+	if !ok {
+		return "", text.ErrInvalidVector
+	}
+	return vec, nil
+}
+
+// parseTimeSeriesDuration parses a time-series duration, which additionally
+// allows day (d) and week (w) units.
+func parseTimeSeriesDuration(s string) (time.Duration, error) {
+	var m time.Duration
+	switch {
+	case strings.HasSuffix(s, "d"):
+		m = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		m = 7 * 24 * time.Hour
+	}
+	if m != 0 {
+		f, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err != nil || f <= 0 {
+			return 0, fmt.Errorf(text.InvalidTSDuration, s)
+		}
+		return time.Duration(f * float64(m)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf(text.InvalidTSDuration, s)
+	}
+	return d, nil
+}
+
 // Crosstab is a Query View meta command (\crosstab). Executes the active query
 // on the open database connection and displays results in a crosstab view.
 //
+// The columns are the vertical header column, the horizontal header column,
+// the data column, and the column sorting the horizontal header (as psql's
+// \crosstabview colV colH colD sortcolH), by name or by number.
+//
 // Descs:
 //
 //	crosstab	[(OPTIONS)] [COLUMNS]	execute query and display results in crosstab
@@ -234,25 +523,35 @@ func Timing(p *Params) error {
 //	xtab
 func Crosstab(p *Params) error {
 	p.Option.Exec = ExecCrosstab
-	for i := 0; i < 4; i++ {
-		col, ok, err := p.NextOK(true)
-		if err != nil {
-			return err
+	params, err := p.All(true)
+	if err != nil {
+		return err
+	}
+	i := 0
+	if len(params) != 0 && strings.HasPrefix(params[0], "(") {
+		for i < len(params) {
+			if i++; strings.HasSuffix(params[i-1], ")") {
+				break
+			}
 		}
-		p.Option.Crosstab = append(p.Option.Crosstab, col)
-		if !ok {
-			break
+		if err := p.Option.ParseParams(params[:i], "pipe"); err != nil {
+			return err
 		}
 	}
+	if len(params[i:]) > 4 {
+		return text.ErrCrosstabTooManyColumns
+	}
+	p.Option.Crosstab = params[i:]
 	return nil
 }
 
 // Chart is a Query View meta command (\chart). Executes the active query on
-// the open database connection and displays results in a chart view.
+// the open database connection and displays results in a chart view. The
+// chart type can be passed as the first parameter (ie, \chart pie).
 //
 // Descs:
 //
-//	chart	CHART [(OPTIONS)]	execute query and display results as a chart
+//	chart	[TYPE] [OPTION VALUE ...]	execute query and display results as a chart
 func Chart(p *Params) error {
 	p.Option.Exec = ExecChart
 	if p.Option.Params == nil {
@@ -270,6 +569,8 @@ func Chart(p *Params) error {
 		}
 		equal := strings.IndexByte(param, '=')
 		switch {
+		case i == 0 && equal == -1 && slices.Contains(charts.Types, param):
+			p.Option.Params["type"] = param
 		case equal == -1 && i >= len(params)-1:
 			return text.ErrWrongNumberOfArguments
 		case equal == -1:
@@ -285,28 +586,67 @@ func Chart(p *Params) error {
 // Watch is a Query View meta command (\watch). Executes (and re-executes) the
 // active query on the open database connection until canceled by the user.
 //
+// When -align is specified, the query is executed at each multiple of the
+// alignment since midnight (in the display timezone), instead of at every
+// interval. When -jitter is specified, a random delay up to the jitter is
+// added before each execution. When -max-runtime is specified, watching stops
+// after the specified duration.
+//
 // Descs:
 //
-//	watch	[(OPTIONS)] [INTERVAL]	execute query every specified interval
+//	watch	[-OPTION D]... [INTERVAL]	execute query every specified interval (options: -align, -jitter, -max-runtime)
 func Watch(p *Params) error {
 	p.Option.Exec = ExecWatch
 	p.Option.Watch = 2 * time.Second
-	switch s, ok, err := p.NextOK(true); {
-	case err != nil:
-		return err
-	case ok:
-		d, err := time.ParseDuration(s)
-		if err != nil {
-			if f, err := strconv.ParseFloat(s, 64); err == nil {
-				d = time.Duration(f * float64(time.Second))
+	for {
+		s, ok, err := p.NextOK(true)
+		switch {
+		case err != nil:
+			return err
+		case !ok:
+			return nil
+		}
+		name := strings.TrimLeft(s, "-")
+		if name == s {
+			if p.Option.Watch, err = parseWatchDuration(s); err != nil {
+				return err
 			}
+			continue
+		}
+		v, err := p.Next(true)
+		if err != nil {
+			return err
 		}
-		if d == 0 {
-			return text.ErrInvalidWatchDuration
+		d, err := parseWatchDuration(v)
+		if err != nil {
+			return err
+		}
+		switch name {
+		case "align":
+			p.Option.WatchAlign = d
+		case "jitter":
+			p.Option.WatchJitter = d
+		case "max-runtime":
+			p.Option.WatchMaxRuntime = d
+		default:
+			return fmt.Errorf(text.InvalidOption, s)
 		}
-		p.Option.Watch = d
 	}
-	return nil
+}
+
+// parseWatchDuration parses a watch duration, either as a Go duration (ie,
+// 1m30s) or as a number of seconds.
+func parseWatchDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			d = time.Duration(f * float64(time.Second))
+		}
+	}
+	if d <= 0 {
+		return 0, text.ErrInvalidWatchDuration
+	}
+	return d, nil
 }
 
 // Connect is a Connection meta command (\c, \connect). Opens (connects) a
@@ -316,6 +656,7 @@ func Watch(p *Params) error {
 //
 //	c	DSN or \c NAME	connect to dsn or named database connection
 //	c	DRIVER PARAMS...	connect to database with driver and parameters
+//	c	--wizard	connect using the interactive connection wizard
 //	connect
 func Connect(p *Params) error {
 	vals, err := p.All(true)
@@ -324,6 +665,9 @@ func Connect(p *Params) error {
 	}
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
+	if len(vals) == 1 && vals[0] == "--wizard" {
+		return p.Handler.Wizard(ctx)
+	}
 	return p.Handler.Open(ctx, vals...)
 }
 
@@ -368,14 +712,133 @@ func Password(p *Params) error {
 //
 //	conninfo	display information about the current database connection
 func ConnectionInfo(p *Params) error {
-	s := text.NotConnected
-	if db, u := p.Handler.DB(), p.Handler.URL(); db != nil && u != nil {
-		s = fmt.Sprintf(text.ConnInfo, u.Driver, u.DSN)
+	db, u := p.Handler.DB(), p.Handler.URL()
+	if db == nil || u == nil {
+		fmt.Fprintln(p.Handler.IO().Stdout(), text.NotConnected)
+		return nil
+	}
+	mode, _ := drivers.TLSMode(u)
+	if mode == "" {
+		mode = "<unknown>"
+	}
+	fips := "no"
+	if drivers.FIPS() {
+		fips = "yes"
+	}
+	fmt.Fprintln(p.Handler.IO().Stdout(), fmt.Sprintf(text.ConnInfo, u.Driver, u.DSN))
+	fmt.Fprintln(p.Handler.IO().Stdout(), fmt.Sprintf(text.TLSPolicyInfo, env.TLSPolicy(), fips, mode))
+	if status := p.Handler.HostStatus(); len(status) != 0 {
+		fmt.Fprintln(p.Handler.IO().Stdout(), text.ConnHosts)
+		for _, s := range status {
+			fmt.Fprintln(p.Handler.IO().Stdout(), "  "+s)
+		}
+	}
+	return nil
+}
+
+// Route is a Connection meta command (\route). Sets the routing of statements
+// between the primary and a replica of a multi-host connection.
+//
+// Descs:
+//
+//	route	[primary|replica|auto]	route statements to the primary, a replica, or read-only queries to a replica
+func Route(p *Params) error {
+	mode, err := p.Next(true)
+	if err != nil {
+		return err
+	}
+	if mode, err = p.Handler.Route(mode); err != nil {
+		return err
+	}
+	p.Handler.Print(text.RouteIs, mode)
+	return nil
+}
+
+// SetOpt is a Connection meta command (\setopt). Sets session options using
+// the statement of the database's dialect, setting them again when
+// reconnecting.
+//
+// Descs:
+//
+//	setopt	[NAME=VALUE ...]	set (or list) session options, such as search_path, time_zone, and statement_timeout
+func SetOpt(p *Params) error {
+	params, err := p.All(true)
+	if err != nil {
+		return err
+	}
+	if len(params) == 0 {
+		for _, opt := range p.Handler.Opts() {
+			fmt.Fprintf(p.Handler.IO().Stdout(), "%s = %s\n", opt[0], env.Quote(opt[1]))
+		}
+		return nil
+	}
+	// \setopt NAME VALUE
+	if len(params) == 2 && !strings.Contains(params[0], "=") {
+		params = []string{params[0] + "=" + params[1]}
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	for _, param := range params {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return fmt.Errorf(text.InvalidOption, param)
+		}
+		if err := p.Handler.SetOpt(ctx, name, value); err != nil {
+			return err
+		}
 	}
-	fmt.Fprintln(p.Handler.IO().Stdout(), s)
 	return nil
 }
 
+// Su is a Connection meta command (\su). Impersonates a principal to test
+// row-level security and permissions, or reverts the impersonation.
+//
+// Descs:
+//
+//	su	[NAME|-]	impersonate a role or user (SET ROLE, EXECUTE AS, or proxy authentication), or revert with -
+func Su(p *Params) error {
+	name, err := p.Next(true)
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		if err := p.Handler.Su(ctx, name); err != nil {
+			return err
+		}
+	}
+	if s := p.Handler.Impersonating(); s != "" {
+		p.Handler.Print(text.Impersonating, s)
+	} else {
+		p.Handler.Print(text.NotImpersonating)
+	}
+	return nil
+}
+
+// Tag is a Connection meta command (\tag). Sets the tags added as a comment to
+// every statement (the QUERY_TAG variable). Tags without a key are tagged as
+// a ticket (ie, \tag OPS-123 tags statements with ticket=OPS-123).
+//
+// Descs:
+//
+//	tag	[[KEY=]VALUE ...]	tag statements for server-side attribution, or stop tagging statements
+func Tag(p *Params) error {
+	params, err := p.All(true)
+	if err != nil {
+		return err
+	}
+	for i, param := range params {
+		if !strings.Contains(param, "=") {
+			params[i] = "ticket=" + param
+		}
+	}
+	if len(params) == 0 {
+		return env.Vars().Unset("QUERY_TAG")
+	}
+	return env.Vars().Set("QUERY_TAG", strings.Join(params, " "))
+}
+
 // Edit is a Query Buffer meta command (\e \edit). Opens the query buffer for
 // editing in an external application.
 //
@@ -448,16 +911,11 @@ func Print(p *Params) error {
 	default:
 		s = p.Handler.LastPrint()
 	}
-	switch {
-	case s == "":
-		s = text.QueryBufferEmpty
-	case p.Handler.IO().Interactive() && env.Get("SYNTAX_HL") == "true":
-		b := new(bytes.Buffer)
-		if p.Handler.Highlight(b, s) == nil {
-			s = b.String()
-		}
+	if s == "" {
+		fmt.Fprintln(p.Handler.IO().Stdout(), text.QueryBufferEmpty)
+		return nil
 	}
-	fmt.Fprintln(p.Handler.IO().Stdout(), s)
+	writeSQL(p.Handler, p.Handler.IO().Stdout(), s)
 	return nil
 }
 
@@ -478,7 +936,15 @@ func Write(p *Params) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(name, []byte(strings.TrimSuffix(s, "\n")+"\n"), 0o644)
+	f, err := env.OpenOutput(name, false, p.readVar)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, strings.TrimSuffix(s, "\n")+"\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
 
 // Reset is a Query Buffer meta command (\r, \reset). Clears (resets) the query
@@ -494,6 +960,104 @@ func Reset(p *Params) error {
 	return nil
 }
 
+// Recover is a Query Buffer meta command (\recover). Restores the query
+// buffer of a previous session that did not exit cleanly (such as after a
+// terminal crash or dropped SSH connection), as autosaved to the recovery file.
+//
+// Descs:
+//
+//	recover	restore the query buffer of a previous session that did not exit cleanly
+func Recover(p *Params) error {
+	s := p.Handler.Recovered()
+	if s == "" {
+		return text.ErrNothingToRecover
+	}
+	fmt.Fprintln(p.Handler.IO().Stdout(), s)
+	p.Handler.Buf().Reset([]rune(s))
+	return nil
+}
+
+// ExplainQuery is a Query Buffer meta command (\explainq). Writes a plain
+// language explanation of the query buffer (or the last executed query) to the
+// output, using the language model endpoint specified by the AI_URL variable.
+// Data values in the query are redacted before being sent.
+//
+// Descs:
+//
+//	explainq	explain the query buffer in plain language with a language model (see AI_URL)
+func ExplainQuery(p *Params) error {
+	s, buf := p.Handler.LastExec(), p.Handler.Buf()
+	if buf.Len != 0 {
+		s = buf.String()
+	}
+	if s == "" {
+		p.Handler.Print(text.QueryBufferEmpty)
+		return nil
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	explanation, err := p.Handler.Explain(ctx, s)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(p.Handler.IO().Stdout(), explanation)
+	return nil
+}
+
+// Preview is a Query Buffer meta command (\preview). Writes the statement
+// classification of the query buffer (or the last executed query) to the
+// output, without executing it, showing whether it would be refused in
+// read-only mode (see READ_ONLY) or need confirmation (see
+// CONFIRM_DESTRUCTIVE).
+//
+// Descs:
+//
+//	preview	show the kind and tables of the query buffer, and whether it is read-only or destructive, without executing it
+func Preview(p *Params) error {
+	s, buf := p.Handler.LastExec(), p.Handler.Buf()
+	if buf.Len != 0 {
+		s = buf.String()
+	}
+	if s == "" {
+		p.Handler.Print(text.QueryBufferEmpty)
+		return nil
+	}
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	c := stmtclass.Classify(s)
+	fmt.Fprintln(p.Handler.IO().Stdout(), fmt.Sprintf(text.PreviewStatement, cmp.Or(c.Verb, c.Kind.String()), strings.Join(c.Tables, ", "), yesNo(c.ReadOnly()), yesNo(c.Destructive())))
+	return nil
+}
+
+// Fix is a Query Buffer meta command (\fix). Places a correction of the last
+// failed query, suggested by the language model endpoint specified by the
+// AI_URL variable using the query's error and the database schema, on the
+// query buffer. Data values in the query and error are redacted before being
+// sent.
+//
+// Descs:
+//
+//	fix	suggest a correction of the last failed query with a language model (see AI_URL)
+func Fix(p *Params) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	sqlstr, err := p.Handler.Fix(ctx)
+	if err != nil {
+		return err
+	}
+	buf := p.Handler.Buf()
+	buf.Reset(nil)
+	buf.AppendString(sqlstr, "")
+	buf.Prefix = stmt.FindPrefix(sqlstr, true, true, true)
+	writeSQL(p.Handler, p.Handler.IO().Stdout(), sqlstr)
+	p.Handler.Print(text.AIQueryNotExecuted)
+	return nil
+}
+
 // Echo is a Input/Output meta command (\echo, \warn, \qecho). Writes a message
 // to the output.
 //
@@ -537,7 +1101,7 @@ func Echo(p *Params) error {
 //
 // Descs:
 //
-//	o	[FILE]	send all query results to file or |pipe
+//	o	[-atomic] [FILE]	send all query results to file or |pipe
 //	out
 func Out(p *Params) error {
 	p.Handler.SetOutput(nil)
@@ -545,6 +1109,10 @@ func Out(p *Params) error {
 	if err != nil {
 		return err
 	}
+	atomic := len(params) != 0 && params[0] == "-atomic"
+	if atomic {
+		params = params[1:]
+	}
 	pipe := strings.Join(params, " ")
 	if pipe == "" {
 		return nil
@@ -553,71 +1121,434 @@ func Out(p *Params) error {
 	if pipe[0] == '|' {
 		out, _, err = env.Pipe(p.Handler.IO().Stdout(), p.Handler.IO().Stderr(), pipe[1:])
 	} else {
-		out, err = os.OpenFile(pipe, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0o644)
+		out, err = env.OpenOutput(pipe, atomic, p.readVar)
+		// results are written with the format of the file's extension
+		if f := formats.OutputFormat(pipe); err == nil && f != "" {
+			out = formats.NewOutput(out, f)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	p.Handler.SetOutput(out)
+	return nil
+}
+
+// Capture is a Input/Output meta command (\capture). Records the executed
+// statements, with their timing and parameters, for replay against another
+// database (see --replay-workload).
+//
+// Descs:
+//
+//	capture	[start FILE|stop]	record executed statements to a file for replay, or show the capture status
+func Capture(p *Params) error {
+	action, err := p.Next(false)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "":
+	case "start":
+		name, err := p.Next(true)
+		switch {
+		case err != nil:
+			return err
+		case name == "":
+			return text.ErrMissingRequiredArgument
+		}
+		if err := p.Handler.CaptureStart(name); err != nil {
+			return err
+		}
+	case "stop":
+		if err := p.Handler.CaptureStop(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf(text.InvalidCaptureAction, action)
+	}
+	if name := p.Handler.Capture(); name != "" {
+		p.Handler.Print(text.CaptureStarted, name)
+	} else {
+		p.Handler.Print(text.CaptureStopped)
+	}
+	return nil
+}
+
+// Copy is a Input/Output meta command (\copy). Copies data between databases.
+//
+// Descs:
+//
+//	copy	SRC DST QUERY TABLE	copy results of query from source database into table on destination database
+//	copy	SRC DST QUERY TABLE(A,...)	copy results of query from source database into table's columns on destination database
+//	copy	-checkpoint FILE KEY ...	as above, but copy in batches ordered by KEY columns (A,...), recording progress in a checkpoint file
+//	copy	-resume FILE	resume an interrupted copy from a checkpoint file
+//	copy	-batch N -workers N ...	as above, inserting N rows per statement with N parallel workers (and -cast COL=TYPE,... to coerce values)
+func Copy(p *Params) error {
+	opts := new(drivers.CopyOptions)
+	var srcstr string
+	for {
+		opt, ok, err := p.NextOpt(true)
+		switch {
+		case err != nil:
+			return err
+		case !ok:
+			srcstr = opt
+		case opt == "resume":
+			name, err := p.Next(true)
+			if err != nil {
+				return err
+			}
+			c, err := drivers.LoadCheckpoint(name)
+			if err != nil {
+				return err
+			}
+			src, err := checkpointURL(p, c.Src)
+			if err != nil {
+				return err
+			}
+			dest, err := checkpointURL(p, c.Dest)
+			if err != nil {
+				return err
+			}
+			return copyCheckpoint(p, name, c, src, dest, opts)
+		case opt == "checkpoint":
+			return copyWithCheckpoint(p, opts)
+		case opt == "batch", opt == "workers":
+			s, err := p.Next(true)
+			if err != nil {
+				return err
+			}
+			i, err := strconv.Atoi(s)
+			if err != nil || i < 1 {
+				return fmt.Errorf(text.InvalidValue, opt, s, "must be a positive integer")
+			}
+			if opt == "batch" {
+				opts.Batch = i
+			} else {
+				opts.Workers = i
+			}
+			continue
+		case opt == "cast":
+			s, err := p.Next(true)
+			if err != nil {
+				return err
+			}
+			if opts.Casts, err = drivers.ParseCopyCasts(s); err != nil {
+				return err
+			}
+			continue
+		default:
+			return fmt.Errorf(text.InvalidOption, opt)
+		}
+		break
+	}
+	src, err := parseCopyURL(srcstr)
+	if err != nil {
+		return err
+	}
+	deststr, err := p.Next(true)
+	if err != nil {
+		return err
+	}
+	dest, err := parseCopyURL(deststr)
+	if err != nil {
+		return err
+	}
+	for _, u := range []*dburl.URL{src, dest} {
+		if err := drivers.CheckTLS(u, env.TLSPolicy()); err != nil {
+			return err
+		}
+	}
+	query, err := p.Next(true)
+	if err != nil {
+		return err
+	}
+	table, err := p.Next(true)
+	if err != nil {
+		return err
+	}
+	if err := checkWrite(p, table, false); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	stdout, stderr := p.Handler.IO().Stdout, p.Handler.IO().Stderr
+	srcDb, err := drivers.Open(ctx, src, stdout, stderr)
+	if err != nil {
+		return err
+	}
+	defer srcDb.Close()
+	destDb, err := drivers.Open(ctx, dest, stdout, stderr)
+	if err != nil {
+		return err
+	}
+	defer destDb.Close()
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt)
+	defer cancel()
+	// get the result set
+	r, err := srcDb.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	ctx, done := copyContext(ctx, p, opts)
+	n, err := drivers.Copy(ctx, dest, stdout, stderr, r, table)
+	done()
+	if err != nil {
+		return err
+	}
+	p.Handler.Print("COPY %d", n)
+	return nil
+}
+
+// checkWrite checks the writes of \copy and \import into table (and its
+// creation, when create is true) against the read-only mode and statement
+// policy (see Handler.Check), as the rows are inserted without going through
+// the query buffer.
+func checkWrite(p *Params, table string, create bool) error {
+	if create {
+		if _, err := p.Handler.Check("CREATE TABLE " + table + " ()"); err != nil {
+			return err
+		}
+	}
+	_, err := p.Handler.Check("INSERT INTO " + table + " VALUES ()")
+	return err
+}
+
+// parseCopyURL parses a \copy source or destination, which is either a
+// database URL, or the name of a named connection (see \cset) set with a URL.
+func parseCopyURL(s string) (*dburl.URL, error) {
+	if v, ok := env.Vars().GetConn(s); ok && len(v) == 1 {
+		s = v[0]
+	}
+	return dburl.Parse(s)
+}
+
+// copyContext returns the context with the copy options, reporting the
+// progress of the copy to standard error (at most once a second) when
+// interactive. The returned func clears the reported progress.
+func copyContext(ctx context.Context, p *Params, opts *drivers.CopyOptions) (context.Context, func()) {
+	if !p.Handler.IO().Interactive() {
+		return drivers.WithCopyOptions(ctx, opts), func() {}
+	}
+	stderr := p.Handler.IO().Stderr()
+	start, last := time.Now(), time.Now()
+	var mu sync.Mutex
+	var reported bool
+	opts.Progress = func(n int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Since(last) < time.Second {
+			return
+		}
+		last, reported = time.Now(), true
+		fmt.Fprintf(stderr, "\r"+text.CopyProgress, n, int64(float64(n)/time.Since(start).Seconds()))
+	}
+	return drivers.WithCopyOptions(ctx, opts), func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if reported {
+			fmt.Fprint(stderr, "\r\x1b[K")
+		}
+	}
+}
+
+// copyBatchSize is the number of rows copied in each batch of a checkpointed
+// copy.
+const copyBatchSize = 10000
+
+// copyWithCheckpoint starts a checkpointed copy, reading the checkpoint file,
+// the comma separated key columns, and the \copy parameters.
+func copyWithCheckpoint(p *Params, opts *drivers.CopyOptions) error {
+	name, err := p.Next(true)
+	if err != nil {
+		return err
+	}
+	key, err := p.Next(true)
+	if err != nil {
+		return err
+	}
+	c := &drivers.Checkpoint{Key: strings.Split(key, ",")}
+	for i := range c.Key {
+		c.Key[i] = strings.TrimSpace(c.Key[i])
+	}
+	for _, v := range []*string{&c.Src, &c.Dest, &c.Query, &c.Table} {
+		if *v, err = p.Next(true); err != nil {
+			return err
+		}
+	}
+	for _, v := range []string{name, key, c.Src, c.Dest, c.Query, c.Table} {
+		if v == "" {
+			return fmt.Errorf(text.MissingRequiredArg, p.Name)
+		}
+	}
+	src, err := checkpointURL(p, c.Src)
+	if err != nil {
+		return err
+	}
+	dest, err := checkpointURL(p, c.Dest)
+	if err != nil {
+		return err
+	}
+	c.Src, c.Dest = checkpointName(c.Src, src), checkpointName(c.Dest, dest)
+	return copyCheckpoint(p, name, c, src, dest, opts)
+}
+
+// checkpointURL parses the source or destination s of a checkpointed copy
+// (see parseCopyURL). As passwords are not recorded in checkpoint files, the
+// password of a database URL without one is read from the PASS file.
+func checkpointURL(p *Params, s string) (*dburl.URL, error) {
+	u, err := parseCopyURL(s)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := u.User.Password(); !ok {
+		user, err := passfile.Match(u, p.Handler.User().HomeDir, text.PassfileName)
+		switch {
+		case err != nil:
+			return nil, err
+		case user != nil:
+			u.User = user
+		}
+	}
+	return u, nil
+}
+
+// checkpointName returns the source or destination s of a checkpointed copy
+// as recorded in the checkpoint file: the name of a named connection, or the
+// database URL u without its password.
+func checkpointName(s string, u *dburl.URL) string {
+	if _, ok := env.Vars().GetConn(s); ok {
+		return s
+	}
+	if _, ok := u.User.Password(); !ok {
+		return s
+	}
+	z := *u
+	z.User = url.User(u.User.Username())
+	return z.String()
+}
+
+// copyCheckpoint runs the checkpointed copy c from the source database src to
+// the destination database dest, recording the checkpoint in the file name.
+func copyCheckpoint(p *Params, name string, c *drivers.Checkpoint, src, dest *dburl.URL, opts *drivers.CopyOptions) error {
+	if err := checkWrite(p, c.Table, false); err != nil {
+		return err
+	}
+	for _, u := range []*dburl.URL{src, dest} {
+		if err := drivers.CheckTLS(u, env.TLSPolicy()); err != nil {
+			return err
+		}
 	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	ctx, done := copyContext(ctx, p, opts)
+	n, err := drivers.CopyCheckpoint(ctx, name, c, src, dest, copyBatchSize, p.Handler.IO().Stdout, p.Handler.IO().Stderr)
+	done()
 	if err != nil {
 		return err
 	}
-	p.Handler.SetOutput(out)
+	p.Handler.Print("COPY %d", n)
 	return nil
 }
 
-// Copy is a Input/Output meta command (\copy). Copies data between databases.
+// Import is a Input/Output meta command (\import). Imports a CSV, TSV, JSON,
+// or Parquet file into a table on the current database.
 //
 // Descs:
 //
-//	copy	SRC DST QUERY TABLE	copy results of query from source database into table on destination database
-//	copy	SRC DST QUERY TABLE(A,...)	copy results of query from source database into table's columns on destination database
-func Copy(p *Params) error {
-	srcstr, err := p.Next(true)
-	if err != nil {
-		return err
-	}
-	src, err := dburl.Parse(srcstr)
-	if err != nil {
-		return err
-	}
-	deststr, err := p.Next(true)
-	if err != nil {
-		return err
-	}
-	dest, err := dburl.Parse(deststr)
-	if err != nil {
-		return err
+//	import	[-OPTIONS] FILE [TABLE]	import a CSV, TSV, JSON, or Parquet file into a table (default the file's name), inferring column types
+//	import	-create ...	as above, but create the table with the inferred column types
+//	import	-format FORMAT ...	as above, but with the file format (csv, tsv, json, or parquet), instead of the file's extension
+//	import	-delimiter C ...	as above, with the CSV field delimiter (sniffed by default), and -noheader when the file has no header row
+//	import	-batch N -workers N ...	as above, inserting N rows per statement with N parallel workers
+func Import(p *Params) error {
+	u := p.Handler.URL()
+	if u == nil {
+		return text.ErrNotConnected
+	}
+	var opts drivers.ImportOptions
+	copyOpts := new(drivers.CopyOptions)
+	var name string
+	for {
+		opt, ok, err := p.NextOpt(true)
+		switch {
+		case err != nil:
+			return err
+		case !ok:
+			name = opt
+		case opt == "create":
+			opts.Create = true
+			continue
+		case opt == "noheader":
+			opts.NoHeader = true
+			continue
+		case opt == "format":
+			if opts.Format, err = p.Next(true); err != nil {
+				return err
+			}
+			if !slices.Contains(drivers.ImportFormats, opts.Format) {
+				return fmt.Errorf(text.InvalidImportFormat, opts.Format, strings.Join(drivers.ImportFormats, ", "))
+			}
+			continue
+		case opt == "delimiter":
+			s, err := p.Next(true)
+			if err != nil {
+				return err
+			}
+			switch s {
+			case "tab", `\t`:
+				s = "\t"
+			}
+			if utf8.RuneCountInString(s) != 1 {
+				return fmt.Errorf(text.InvalidValue, opt, s, "must be a single character")
+			}
+			opts.Delimiter, _ = utf8.DecodeRuneInString(s)
+			continue
+		case opt == "batch", opt == "workers":
+			s, err := p.Next(true)
+			if err != nil {
+				return err
+			}
+			i, err := strconv.Atoi(s)
+			if err != nil || i < 1 {
+				return fmt.Errorf(text.InvalidValue, opt, s, "must be a positive integer")
+			}
+			if opt == "batch" {
+				copyOpts.Batch = i
+			} else {
+				copyOpts.Workers = i
+			}
+			continue
+		default:
+			return fmt.Errorf(text.InvalidOption, opt)
+		}
+		break
 	}
-	query, err := p.Next(true)
-	if err != nil {
-		return err
+	if name == "" {
+		return fmt.Errorf(text.MissingRequiredArg, p.Name)
 	}
 	table, err := p.Next(true)
-	if err != nil {
+	switch {
+	case err != nil:
 		return err
+	case table == "":
+		table = drivers.ImportTable(u, name)
 	}
-	ctx := context.Background()
-	stdout, stderr := p.Handler.IO().Stdout, p.Handler.IO().Stderr
-	srcDb, err := drivers.Open(ctx, src, stdout, stderr)
-	if err != nil {
+	if err := checkWrite(p, table, opts.Create); err != nil {
 		return err
 	}
-	defer srcDb.Close()
-	destDb, err := drivers.Open(ctx, dest, stdout, stderr)
-	if err != nil {
+	if err := drivers.CheckTLS(u, env.TLSPolicy()); err != nil {
 		return err
 	}
-	defer destDb.Close()
-	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
-	// get the result set
-	r, err := srcDb.QueryContext(ctx, query)
-	if err != nil {
-		return err
-	}
-	defer r.Close()
-	n, err := drivers.Copy(ctx, dest, stdout, stderr, r, table)
+	ctx, done := copyContext(ctx, p, copyOpts)
+	n, err := drivers.Import(ctx, u, p.Handler.IO().Stdout, p.Handler.IO().Stderr, name, table, opts)
+	done()
 	if err != nil {
 		return err
 	}
-	p.Handler.Print("COPY %d", n)
+	p.Handler.Print("IMPORT %d", n)
 	return nil
 }
 
@@ -735,12 +1666,34 @@ func Unset(p *Params) error {
 	return env.Vars().Unset(n)
 }
 
+// Push is a Variables meta command (\push). Saves the application and print
+// formatting variables.
+//
+// Descs:
+//
+//	push		save application and print formatting variables
+func Push(p *Params) error {
+	env.Vars().Push()
+	return nil
+}
+
+// Pop is a Variables meta command (\pop). Restores the application and print
+// formatting variables saved by \push.
+//
+// Descs:
+//
+//	pop		restore variables saved by \push
+func Pop(p *Params) error {
+	return env.Vars().Pop()
+}
+
 // SetPrint is a Variables meta command (\pset, \a, \C, \f, \H, \t, \T, \x).
 // Sets, toggles, or displays the application's print formatting variables.
 //
 // Descs:
 //
 //	pset	[NAME [VALUE]]	set table print formatting option, or show all print formatting options if no parameters
+//	pset	--local NAME [VALUE]	set table print formatting option for the next query only
 //	a		toggle between unaligned and aligned output mode	DEPRECATED
 //	C	[TITLE]	set table title, or unset if none	DEPRECATED
 //	f	[SEPARATOR]	show or set field separator for unaligned query output	DEPRECATED
@@ -767,9 +1720,24 @@ func SetPrint(p *Params) error {
 	switch p.Name {
 	case "pset":
 		field = val
+		if field == "--local" {
+			if field, err = p.Next(true); err != nil {
+				return err
+			}
+			env.Vars().SaveLocal(field)
+		}
 		if val, ok, err = p.NextOK(true); err != nil {
 			return err
 		}
+		if (field == "coltype" || field == "colwidth" && val != "auto") && ok && !strings.Contains(val, "=") {
+			// \pset coltype COLUMN [TYPE], \pset colwidth COLUMN [WIDTH]
+			v, err := p.Next(true)
+			if err != nil {
+				return err
+			}
+			cur, _ := env.Vars().GetPrint(field)
+			val = env.SetColumnValue(cur, val, v)
+		}
 	case "a":
 		field = "format"
 	case "C":
@@ -877,6 +1845,161 @@ func Prompt(p *Params) error {
 	return env.Vars().Set(n, v)
 }
 
+// InList is a Variables meta command (\inlist). Reads a list of values from a
+// file, command output, or the input, and sets a application variable to the
+// values formatted as a SQL IN list, quoted for the open database.
+//
+// Descs:
+//
+//	inlist	[-size N] VAR [FILE]	set application variable to an IN list of values read from file, |command, or input
+func InList(p *Params) error {
+	size := -1
+	n, ok, err := p.NextOpt(true)
+	switch {
+	case err != nil:
+		return err
+	case ok && n == "size":
+		s, err := p.Next(true)
+		if err != nil {
+			return err
+		}
+		if size, err = strconv.Atoi(s); err != nil || size < 0 {
+			return fmt.Errorf(text.InvalidInListSize, s)
+		}
+		if n, err = p.Next(true); err != nil {
+			return err
+		}
+	case ok:
+		return fmt.Errorf(text.InvalidOption, "-"+n)
+	}
+	if n == "" {
+		return text.ErrMissingRequiredArgument
+	}
+	if err := env.ValidIdentifier(n); err != nil {
+		return err
+	}
+	params, err := p.All(true)
+	if err != nil {
+		return err
+	}
+	if len(params) != 0 && params[0] == "<" {
+		params = params[1:]
+	}
+	// read values
+	var buf string
+	switch src := strings.Join(params, " "); {
+	case strings.HasPrefix(src, "|"):
+		if buf, err = env.Exec(src[1:]); err != nil {
+			return err
+		}
+	case src != "":
+		b, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		buf = string(b)
+	default:
+		l := p.Handler.IO()
+		for {
+			l.Prompt(text.InListPrompt)
+			line, err := l.Next()
+			if err != nil && err != io.EOF {
+				return err
+			}
+			if strings.TrimSpace(string(line)) == "" || err == io.EOF {
+				break
+			}
+			buf += string(line) + "\n"
+		}
+	}
+	vals := splitInList(buf)
+	if len(vals) == 0 {
+		return text.ErrNoValues
+	}
+	// determine batch size
+	var driver string
+	if u := p.Handler.URL(); u != nil {
+		driver = u.Driver
+		if size == -1 {
+			size = drivers.MaxInList(u)
+		}
+	}
+	lists := formatInList(driver, vals, size)
+	if len(lists) == 1 {
+		if err := env.Vars().Set(n, lists[0]); err != nil {
+			return err
+		}
+		p.Handler.Print(text.InListSet, n, len(vals))
+		return nil
+	}
+	for i, list := range lists {
+		if err := env.Vars().Set(fmt.Sprintf("%s_%d", n, i+1), list); err != nil {
+			return err
+		}
+	}
+	if err := env.Vars().Set(n+"_BATCHES", strconv.Itoa(len(lists))); err != nil {
+		return err
+	}
+	p.Handler.Print(text.InListSetBatches, n, len(vals), len(lists))
+	return nil
+}
+
+// splitInList splits buf on newlines, commas, semicolons, and tabs, returning
+// the unique, trimmed, and unquoted values.
+func splitInList(buf string) []string {
+	var vals []string
+	seen := make(map[string]bool)
+	for _, v := range strings.FieldsFunc(buf, func(r rune) bool {
+		return r == '\n' || r == '\r' || r == ',' || r == ';' || r == '\t'
+	}) {
+		v = strings.TrimSpace(v)
+		if len(v) > 1 && (v[0] == '\'' || v[0] == '"') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		}
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		vals = append(vals, v)
+	}
+	return vals
+}
+
+// numberRE matches SQL numeric literals.
+var numberRE = regexp.MustCompile(`^[-+]?(\d+(\.\d*)?|\.\d+)([eE][-+]?\d+)?$`)
+
+// formatInList formats vals as comma separated SQL literals for the driver,
+// split into lists of at most size values. Values are left unquoted when all
+// values are numeric literals.
+func formatInList(driver string, vals []string, size int) []string {
+	numeric := true
+	for _, v := range vals {
+		if !numberRE.MatchString(v) {
+			numeric = false
+			break
+		}
+	}
+	lits := make([]string, len(vals))
+	for i, v := range vals {
+		switch {
+		case numeric:
+			lits[i] = v
+		case driver == "mysql":
+			lits[i] = "'" + strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(v) + "'"
+		default:
+			lits[i] = "'" + strings.ReplaceAll(v, `'`, `''`) + "'"
+		}
+	}
+	if size <= 0 {
+		size = len(lits)
+	}
+	var lists []string
+	for i := 0; i < len(lits); i += size {
+		lists = append(lists, strings.Join(lits[i:min(i+size, len(lits))], ", "))
+	}
+	return lists
+}
+
 // Describe is a Informational meta command (\d and variants). Queries the open
 // database connection for information about the database schema and writes the
 // information to the output.
@@ -890,7 +2013,7 @@ func Prompt(p *Params) error {
 //	dm[S+]	[PATTERN]	list materialized views
 //	dn[S+]	[PATTERN]	list schemas
 //	dp[S]	[PATTERN]	list table, view, and sequence access privileges
-//	ds[S+]	[PATTERN]	list sequences
+//	ds[S+]	[PATTERN]	list sequences (+ with current values and owning columns)
 //	dt[S+]	[PATTERN]	list tables
 //	dv[S+]	[PATTERN]	list views
 //	l[+]	list databases
@@ -916,7 +2039,18 @@ func Describe(p *Params) error {
 		return m.ListTables(p.Handler.URL(), "tvmsE", pattern, verbose, showSystem)
 	case "df", "da":
 		return m.DescribeFunctions(p.Handler.URL(), name, pattern, verbose, showSystem)
-	case "dt", "dtv", "dtm", "dts", "dv", "dm", "ds":
+	case "ds":
+		// sequences with their current values and owning columns
+		if sqlstr, err := drivers.Sequences(p.Handler.URL(), pattern, showSystem); err == nil && verbose {
+			buf := p.Handler.Buf()
+			buf.Reset(nil)
+			buf.AppendString(sqlstr, "")
+			buf.Prefix = stmt.FindPrefix(sqlstr, true, true, true)
+			p.Option.Exec = ExecOnly
+			return nil
+		}
+		return m.ListTables(p.Handler.URL(), name, pattern, verbose, showSystem)
+	case "dt", "dtv", "dtm", "dts", "dv", "dm":
 		return m.ListTables(p.Handler.URL(), name, pattern, verbose, showSystem)
 	case "dn":
 		return m.ListSchemas(p.Handler.URL(), pattern, verbose, showSystem)
@@ -930,6 +2064,239 @@ func Describe(p *Params) error {
 	return nil
 }
 
+// Partitions is a Informational meta command (\partitions). Lists the
+// partitions of a table, or generates the DDL creating, attaching, or
+// detaching a partition, placing it on the query buffer.
+//
+// Descs:
+//
+//	partitions	TABLE	list partitions of a table with their bounds, row counts, and sizes
+//	partitions	-next TABLE [PERIOD]	generate DDL creating the partition for the next period (day, week, month, quarter, year)
+//	partitions	-OP TABLE NAME ...	generate DDL to -create, -attach (both with FROM TO bounds), or -detach partition NAME
+func Partitions(p *Params) error {
+	action, ok, err := p.NextOpt(true)
+	switch {
+	case err != nil:
+		return err
+	case !ok:
+		if action == "" {
+			return text.ErrMissingRequiredArgument
+		}
+		sqlstr, err := drivers.Partitions(p.Handler.URL(), action)
+		if err != nil {
+			return err
+		}
+		sqlstr = sqlStyle(p.Handler.URL()).Apply(sqlstr)
+		buf := p.Handler.Buf()
+		buf.Reset(nil)
+		buf.AppendString(sqlstr, "")
+		buf.Prefix = stmt.FindPrefix(sqlstr, true, true, true)
+		p.Option.Exec = ExecOnly
+		return nil
+	}
+	params, err := p.All(true)
+	if err != nil {
+		return err
+	}
+	var sqlstr string
+	switch {
+	case len(params) == 0:
+		return text.ErrMissingRequiredArgument
+	case action == "next":
+		if sqlstr, err = nextPartition(p, params); err != nil {
+			return err
+		}
+	default:
+		params = append(params, "", "", "")
+		if params[1] == "" {
+			return text.ErrMissingRequiredArgument
+		}
+		if sqlstr, err = drivers.PartitionDDL(p.Handler.URL(), action, params[0], params[1], params[2], params[3]); err != nil {
+			return err
+		}
+	}
+	sqlstr = sqlStyle(p.Handler.URL()).Apply(sqlstr)
+	buf := p.Handler.Buf()
+	buf.Reset(nil)
+	buf.AppendString(sqlstr, "")
+	buf.Prefix = stmt.FindPrefix(sqlstr, true, true, true)
+	writeSQL(p.Handler, p.Handler.IO().Stdout(), sqlstr)
+	p.Handler.Print(text.DDLNotExecuted)
+	return nil
+}
+
+// nextPartition returns the DDL creating the partition of the table for the
+// next period (default month), from the bounds of the table's partitions.
+func nextPartition(p *Params, params []string) (string, error) {
+	table, period := params[0], "month"
+	if len(params) > 1 {
+		period = params[1]
+	}
+	u, db := p.Handler.URL(), p.Handler.HiddenDB()
+	if db == nil {
+		return "", text.ErrNotConnected
+	}
+	sqlstr, err := drivers.Partitions(u, table)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	rows, err := db.QueryContext(ctx, sqlstr)
+	if err != nil {
+		return "", drivers.WrapErr(u.Driver, err)
+	}
+	defer rows.Close()
+	var bounds []string
+	for rows.Next() {
+		var name, bound, n, size sql.NullString
+		if err := rows.Scan(&name, &bound, &n, &size); err != nil {
+			return "", drivers.WrapErr(u.Driver, err)
+		}
+		bounds = append(bounds, bound.String)
+	}
+	if err := rows.Err(); err != nil {
+		return "", drivers.WrapErr(u.Driver, err)
+	}
+	return drivers.NextPartition(u, table, bounds, period)
+}
+
+// FixSequences is a Informational meta command (\fixseq). Realigns the
+// sequences and identity columns of a table with the maximum values of their
+// columns (ie, after a bulk load or \import).
+//
+// Descs:
+//
+//	fixseq	[-exec] TABLE	show (or -exec execute) the statements realigning a table's sequences and identity columns with their maximum values
+func FixSequences(p *Params) error {
+	table, exec, err := p.NextOpt(true)
+	switch {
+	case err != nil:
+		return err
+	case exec && table != "exec":
+		return fmt.Errorf(text.InvalidOption, table)
+	case exec:
+		if table, err = p.Next(true); err != nil {
+			return err
+		}
+		// the realigning statements are not always classified as writes (ie,
+		// PostgreSQL's SELECT setval(...))
+		if env.Get("READ_ONLY") == "on" {
+			return fmt.Errorf(text.ReadOnlyDenied, `\fixseq -exec`)
+		}
+	}
+	if table == "" {
+		return text.ErrMissingRequiredArgument
+	}
+	u, db := p.Handler.URL(), p.Handler.DB()
+	if db == nil {
+		return text.ErrNotConnected
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	stmts, err := drivers.FixSequences(ctx, u, p.Handler.HiddenDB(), table)
+	if err != nil {
+		return err
+	}
+	stdout, style := p.Handler.IO().Stdout(), sqlStyle(u)
+	for _, s := range stmts {
+		s = style.Apply(s)
+		if exec {
+			if s, err = p.Handler.Check(s); err != nil {
+				return err
+			}
+			if _, err := db.ExecContext(ctx, s); err != nil {
+				return drivers.WrapErr(u.Driver, err)
+			}
+		}
+		writeSQL(p.Handler, stdout, s+";")
+	}
+	if !exec {
+		p.Handler.Print(text.FixSequencesNotExecuted)
+	}
+	return nil
+}
+
+// Check is a Informational meta command (\checkfk, \checknull,
+// \checkunique). Executes a query finding rows violating constraints on a
+// table, including constraints that are not enforced, or were not validated
+// against the table's existing rows.
+//
+// Descs:
+//
+//	checkfk	[-sample N] [TABLE]	count rows violating foreign keys of a table (or of all tables), including NOT VALID or disabled foreign keys, or show N violating rows (-sample)
+//	checknull	TABLE COLUMN ...	show rows with NULL values in any of the columns (-sample N, default 10)
+//	checkunique	TABLE COLUMN ...	show duplicated values of the columns, with their counts (-sample N, default 10)
+func Check(p *Params) error {
+	n, sample := 10, false
+	var args []string
+	for {
+		opt, ok, err := p.NextOpt(true)
+		switch {
+		case err != nil:
+			return err
+		case ok && opt == "sample":
+			s, err := p.Next(true)
+			if err != nil {
+				return err
+			}
+			if n, err = strconv.Atoi(s); err != nil || n < 1 {
+				return fmt.Errorf(text.InvalidValue, opt, s, "must be a positive integer")
+			}
+			sample = true
+			continue
+		case ok:
+			return fmt.Errorf(text.InvalidOption, opt)
+		case opt != "":
+			// columns are separated by spaces or commas
+			for _, s := range strings.Split(opt, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					args = append(args, s)
+				}
+			}
+			continue
+		}
+		break
+	}
+	u, db := p.Handler.URL(), p.Handler.HiddenDB()
+	if db == nil {
+		return text.ErrNotConnected
+	}
+	var sqlstr string
+	switch {
+	case p.Name == "checkfk":
+		if len(args) > 1 || sample && len(args) == 0 {
+			return text.ErrMissingRequiredArgument
+		}
+		var table string
+		if len(args) != 0 {
+			table = args[0]
+		}
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		fks, err := drivers.ForeignKeys(ctx, u, db, table)
+		if err != nil {
+			return err
+		}
+		sqlstr = drivers.OrphansQuery(fks)
+		if sample {
+			sqlstr = drivers.OrphanRowsQuery(u, table, fks, n)
+		}
+	case len(args) < 2:
+		return text.ErrMissingRequiredArgument
+	case p.Name == "checknull":
+		sqlstr = drivers.NullRowsQuery(u, args[0], args[1:], n)
+	default:
+		sqlstr = drivers.DuplicatesQuery(u, args[0], args[1:], n)
+	}
+	buf := p.Handler.Buf()
+	buf.Reset(nil)
+	buf.AppendString(sqlstr, "")
+	buf.Prefix = stmt.FindPrefix(sqlstr, true, true, true)
+	p.Option.Exec = ExecOnly
+	return nil
+}
+
 // Stats is a Informational meta command (\ss and variants). Queries the open
 // database connection for stats and writes it to the output.
 //
@@ -969,10 +2336,31 @@ func Stats(p *Params) error {
 	return m.ShowStats(p.Handler.URL(), name, pattern, verbose, k)
 }
 
+// Refresh is a Informational meta command (\refresh). Clears the on-disk
+// metadata cache of the open database connection, forcing metadata used by
+// completion and the describe commands to be read again.
+//
+// Descs:
+//
+//	refresh	clear cached metadata of the current connection (see SCHEMA_CACHE)
+func Refresh(p *Params) error {
+	if err := p.Handler.RefreshMetadata(); err != nil {
+		return err
+	}
+	p.Handler.Print(text.SchemaCacheRefreshed)
+	return nil
+}
+
 // Conditional is a Control/Conditional meta command (\if, \elif, \else,
 // \endif). Starts, closes, and ends a conditional block within the
 // application.
 //
+// The expression is a boolean value (ie, true, false, on, off, yes, no, 1,
+// 0), usually an interpolated variable. Statements and commands within a
+// skipped branch are discarded, and expressions of branches that cannot be
+// taken are not evaluated. An invalid expression is an error, and is treated
+// as false.
+//
 // Descs:
 //
 //	if	EXPR	begin conditional block
@@ -980,13 +2368,51 @@ func Stats(p *Params) error {
 //	else	final alternative within current conditional block
 //	endif	end conditional block
 func Conditional(p *Params) error {
+	cond := p.Handler.Cond()
+	// evaluate expression
+	var v bool
+	var err error
+	switch {
+	case cond.Eval(p.Name):
+		var expr string
+		var ok bool
+		if expr, ok, err = p.NextOK(true); err == nil && !ok {
+			err = text.ErrMissingRequiredArgument
+		}
+		if err == nil {
+			v, err = condValue(expr, `\`+p.Name)
+		}
+	case p.Name == "if" || p.Name == "elif":
+		// discard expression of a branch that cannot be taken
+		_ = p.Raw()
+	}
+	var cerr error
 	switch p.Name {
 	case "if":
+		cond.If(v)
 	case "elif":
+		cerr = cond.Elif(v)
 	case "else":
+		cerr = cond.Else()
 	case "endif":
+		cerr = cond.Endif()
 	}
-	return nil
+	if cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// condValue returns the boolean value of a conditional block expression.
+func condValue(expr, name string) (bool, error) {
+	switch strings.ToLower(expr) {
+	case "y", "ye", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	v, err := env.ParseBool(expr, name)
+	return v == "on", err
 }
 
 // Shell is a Operating System/Environment meta command (\!). Executes a
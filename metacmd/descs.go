@@ -42,17 +42,24 @@ func init() {
 			{Help, `?`, `[commands]`, `show help on ` + text.CommandName + `'s meta (backslash) commands`, false, false},
 			{Help, `?`, `options`, `show help on ` + text.CommandName + ` command-line options`, false, false},
 			{Help, `?`, `variables`, `show help on special ` + text.CommandName + ` variables`, false, false},
+			{SQLHelp, `h`, `[NAME]`, `help on syntax of SQL commands and functions, or meta commands`, false, false},
+			{SQLHelp, `help`, ``, `alias for \h`, true, false},
 		},
 		// Connection
 		{
 			{Connect, `c`, `DSN or \c NAME`, `connect to dsn or named database connection`, false, false},
 			{Connect, `c`, `DRIVER PARAMS...`, `connect to database with driver and parameters`, false, false},
+			{Connect, `c`, `--wizard`, `connect using the interactive connection wizard`, false, false},
 			{Connect, `connect`, ``, `alias for \c`, true, false},
 			{Disconnect, `Z`, ``, `close (disconnect) database connection`, false, false},
 			{Disconnect, `disconnect`, ``, `alias for \Z`, true, false},
 			{Password, `password`, `[USER]`, `change password for user`, false, false},
 			{Password, `passwd`, ``, `alias for \password`, true, false},
 			{ConnectionInfo, `conninfo`, ``, `display information about the current database connection`, false, false},
+			{Route, `route`, `[primary|replica|auto]`, `route statements to the primary, a replica, or read-only queries to a replica`, false, false},
+			{SetOpt, `setopt`, `[NAME=VALUE ...]`, `set (or list) session options, such as search_path, time_zone, and statement_timeout`, false, false},
+			{Su, `su`, `[NAME|-]`, `impersonate a role or user (SET ROLE, EXECUTE AS, or proxy authentication), or revert with -`, false, false},
+			{Tag, `tag`, `[[KEY=]VALUE ...]`, `tag statements for server-side attribution, or stop tagging statements`, false, false},
 		},
 		// Query Execute
 		{
@@ -65,14 +72,19 @@ func init() {
 			{Execute, `gset`, `[PREFIX]`, `execute query and store results in ` + text.CommandName + ` variables`, false, false},
 			{Bind, `bind`, `[PARAM]...`, `set query parameters`, false, false},
 			{Timing, `timing`, `[on|off]`, `toggle timing of commands`, false, false},
+			{Stash, `stash`, `NAME`, `execute query and store results in the local scratchpad`, false, false},
+			{Scratch, `scratch`, `QUERY`, `execute query on the local scratchpad`, false, false},
+			{TimeSeries, `ts`, `last DURATION TABLE [COLUMN]`, `execute query for the rows of a table in the last duration`, false, false},
+			{Assist, `ai`, `PROMPT`, `generate query from prompt with a language model (see AI_URL), executing once confirmed`, false, false},
+			{NearestNeighbors, `knn`, `TABLE COLUMN VECTOR [K]`, `execute query for the K nearest neighbors of a vector (or JSON file)`, false, false},
 		},
 		// Query View
 		{
 			{Crosstab, `crosstab`, `[(OPTIONS)] [COLUMNS]`, `execute query and display results in crosstab`, false, false},
 			{Crosstab, `crosstabview`, ``, `alias for \crosstab`, true, false},
 			{Crosstab, `xtab`, ``, `alias for \crosstab`, true, false},
-			{Chart, `chart`, `CHART [(OPTIONS)]`, `execute query and display results as a chart`, false, false},
-			{Watch, `watch`, `[(OPTIONS)] [INTERVAL]`, `execute query every specified interval`, false, false},
+			{Chart, `chart`, `[TYPE] [OPTION VALUE ...]`, `execute query and display results as a chart`, false, false},
+			{Watch, `watch`, `[-OPTION D]... [INTERVAL]`, `execute query every specified interval (options: -align, -jitter, -max-runtime)`, false, false},
 		},
 		// Query Buffer
 		{
@@ -86,6 +98,10 @@ func init() {
 			{Write, `write`, ``, `alias for \w`, true, false},
 			{Reset, `r`, ``, `reset (clear) the query buffer`, false, false},
 			{Reset, `reset`, ``, `alias for \r`, true, false},
+			{Recover, `recover`, ``, `restore the query buffer of a previous session that did not exit cleanly`, false, false},
+			{ExplainQuery, `explainq`, ``, `explain the query buffer in plain language with a language model (see AI_URL)`, false, false},
+			{Preview, `preview`, ``, `show the kind and tables of the query buffer, and whether it is read-only or destructive, without executing it`, false, false},
+			{Fix, `fix`, ``, `suggest a correction of the last failed query with a language model (see AI_URL)`, false, false},
 		},
 		// Informational
 		{
@@ -96,17 +112,28 @@ func init() {
 			{Describe, `dm[S+]`, `[PATTERN]`, `list materialized views`, false, false},
 			{Describe, `dn[S+]`, `[PATTERN]`, `list schemas`, false, false},
 			{Describe, `dp[S]`, `[PATTERN]`, `list table, view, and sequence access privileges`, false, false},
-			{Describe, `ds[S+]`, `[PATTERN]`, `list sequences`, false, false},
+			{Describe, `ds[S+]`, `[PATTERN]`, `list sequences (+ with current values and owning columns)`, false, false},
 			{Describe, `dt[S+]`, `[PATTERN]`, `list tables`, false, false},
 			{Describe, `dv[S+]`, `[PATTERN]`, `list views`, false, false},
 			{Describe, `l[+]`, ``, `list databases`, false, false},
+			{Partitions, `partitions`, `TABLE`, `list partitions of a table with their bounds, row counts, and sizes`, false, false},
+			{Partitions, `partitions`, `-next TABLE [PERIOD]`, `generate DDL creating the partition for the next period (day, week, month, quarter, year)`, false, false},
+			{Partitions, `partitions`, `-OP TABLE NAME ...`, `generate DDL to -create, -attach (both with FROM TO bounds), or -detach partition NAME`, false, false},
+			{FixSequences, `fixseq`, `[-exec] TABLE`, `show (or -exec execute) the statements realigning a table's sequences and identity columns with their maximum values`, false, false},
+			{Check, `checkfk`, `[-sample N] [TABLE]`, `count rows violating foreign keys of a table (or of all tables), including NOT VALID or disabled foreign keys, or show N violating rows (-sample)`, false, false},
+			{Check, `checknull`, `TABLE COLUMN ...`, `show rows with NULL values in any of the columns (-sample N, default 10)`, false, false},
+			{Check, `checkunique`, `TABLE COLUMN ...`, `show duplicated values of the columns, with their counts (-sample N, default 10)`, false, false},
 			{Stats, `ss[+]`, `[TABLE|QUERY] [k]`, `show stats for a table or a query`, false, false},
+			{Refresh, `refresh`, ``, `clear cached metadata of the current connection (see SCHEMA_CACHE)`, false, false},
 		},
 		// Variables
 		{
 			{Set, `set`, `[NAME [VALUE]]`, `set ` + text.CommandName + ` application variable, or show all ` + text.CommandName + ` application variables if no parameters`, false, false},
 			{Unset, `unset`, `NAME`, `unset (delete) ` + text.CommandName + ` application variable`, false, false},
+			{Push, `push`, ``, `save application and print formatting variables`, false, false},
+			{Pop, `pop`, ``, `restore variables saved by \push`, false, false},
 			{SetPrint, `pset`, `[NAME [VALUE]]`, `set table print formatting option, or show all print formatting options if no parameters`, false, false},
+			{SetPrint, `pset`, `--local NAME [VALUE]`, `set table print formatting option for the next query only`, false, false},
 			{SetPrint, `a`, ``, `toggle between unaligned and aligned output mode`, false, true},
 			{SetPrint, `C`, `[TITLE]`, `set table title, or unset if none`, false, true},
 			{SetPrint, `f`, `[SEPARATOR]`, `show or set field separator for unaligned query output`, false, true},
@@ -117,16 +144,26 @@ func init() {
 			{SetConn, `cset`, `[NAME [URL]]`, `set named connection, or show all named connections if no parameters`, false, false},
 			{SetConn, `cset`, `NAME DRIVER PARAMS...`, `set named connection for driver and parameters`, false, false},
 			{Prompt, `prompt`, `[-TYPE] VAR [PROMPT]`, `prompt user to set application variable`, false, false},
+			{InList, `inlist`, `[-size N] VAR [FILE]`, `set application variable to an IN list of values read from file, |command, or input`, false, false},
 		},
 		// Input/Output
 		{
 			{Echo, `echo`, `[-n] [MESSAGE]...`, `write message to standard output (-n for no newline)`, false, false},
 			{Echo, `qecho`, `[-n] [MESSAGE]...`, `write message to \o output stream (-n for no newline)`, false, false},
 			{Echo, `warn`, `[-n] [MESSAGE]...`, `write message to standard error (-n for no newline)`, false, false},
-			{Out, `o`, `[FILE]`, `send all query results to file or |pipe`, false, false},
+			{Out, `o`, `[-atomic] [FILE]`, `send all query results to file or |pipe`, false, false},
 			{Out, `out`, ``, `alias for \o`, true, false},
+			{Capture, `capture`, `[start FILE|stop]`, `record executed statements to a file for replay, or show the capture status`, false, false},
 			{Copy, `copy`, `SRC DST QUERY TABLE`, `copy results of query from source database into table on destination database`, false, false},
 			{Copy, `copy`, `SRC DST QUERY TABLE(A,...)`, `copy results of query from source database into table's columns on destination database`, false, false},
+			{Copy, `copy`, `-checkpoint FILE KEY ...`, `as above, but copy in batches ordered by KEY columns (A,...), recording progress in a checkpoint file`, false, false},
+			{Copy, `copy`, `-resume FILE`, `resume an interrupted copy from a checkpoint file`, false, false},
+			{Copy, `copy`, `-batch N -workers N ...`, `as above, inserting N rows per statement with N parallel workers (and -cast COL=TYPE,... to coerce values)`, false, false},
+			{Import, `import`, `[-OPTIONS] FILE [TABLE]`, `import a CSV, TSV, JSON, or Parquet file into a table (default the file's name), inferring column types`, false, false},
+			{Import, `import`, `-create ...`, `as above, but create the table with the inferred column types`, false, false},
+			{Import, `import`, `-format FORMAT ...`, `as above, but with the file format (csv, tsv, json, or parquet), instead of the file's extension`, false, false},
+			{Import, `import`, `-delimiter C ...`, `as above, with the CSV field delimiter (sniffed by default), and -noheader when the file has no header row`, false, false},
+			{Import, `import`, `-batch N -workers N ...`, `as above, inserting N rows per statement with N parallel workers`, false, false},
 		},
 		// Control/Conditional
 		{
@@ -3,11 +3,13 @@
 package metacmd
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"fmt"
 	"io"
 	"os/user"
+	"reflect"
 	"strings"
 	"time"
 
@@ -18,6 +20,7 @@ import (
 	"github.com/xo/usql/env"
 	"github.com/xo/usql/rline"
 	"github.com/xo/usql/stmt"
+	"github.com/xo/usql/stmtclass"
 	"github.com/xo/usql/text"
 )
 
@@ -31,6 +34,9 @@ type Handler interface {
 	URL() *dburl.URL
 	// DB returns the current database connection.
 	DB() drivers.DB
+	// HiddenDB returns the current database connection for the internal
+	// queries of commands, writing the queries when ECHO_HIDDEN is set.
+	HiddenDB() drivers.DB
 	// LastExec returns the last executed query.
 	LastExec() string
 	// LastPrint returns the last executed printable query.
@@ -41,10 +47,22 @@ type Handler interface {
 	Buf() *stmt.Stmt
 	// Reset resets the last and current query buffer.
 	Reset([]rune)
+	// Cond returns the conditional block stack.
+	Cond() *stmt.Cond
 	// Bind binds query parameters.
 	Bind([]interface{})
+	// Execute executes a query with the query parameters, writing the
+	// results.
+	Execute(context.Context, io.Writer, Option, string, string, bool, ...interface{}) error
+	// Check checks a statement against the read-only mode, destructive
+	// statement confirmation, and statement policy, returning the (possibly
+	// modified) statement.
+	Check(string) (string, error)
 	// Open opens a database connection.
 	Open(context.Context, ...string) error
+	// Wizard opens a database connection using the interactive connection
+	// wizard.
+	Wizard(context.Context) error
 	// Close closes the current database connection.
 	Close() error
 	// ChangePassword changes the password for a user.
@@ -71,8 +89,40 @@ type Handler interface {
 	SetOutput(io.WriteCloser)
 	// MetadataWriter retrieves the metadata writer for the handler.
 	MetadataWriter(context.Context) (metadata.Writer, error)
+	// Recovered returns the query buffer recovered from a previous session.
+	Recovered() string
+	// RefreshMetadata clears the cached metadata for the open database.
+	RefreshMetadata() error
+	// HostStatus returns the status of each host tried when opening a
+	// multi-host connection.
+	HostStatus() []string
+	// Route sets the statement routing mode between primary and replica.
+	Route(string) (string, error)
+	// SetOpt sets a session option, tracking it to be set again when
+	// reconnecting.
+	SetOpt(context.Context, string, string) error
+	// Opts returns the tracked session options.
+	Opts() [][2]string
+	// Su impersonates a principal, or reverts the impersonation (-).
+	Su(context.Context, string) error
+	// Impersonating returns the impersonated principal.
+	Impersonating() string
+	// CaptureStart starts recording the executed statements to a file.
+	CaptureStart(string) error
+	// CaptureStop stops recording the executed statements.
+	CaptureStop() error
+	// Capture returns the file the executed statements are recorded to.
+	Capture() string
 	// Print formats according to a format specifier and writes to handler's standard output.
 	Print(string, ...interface{})
+	// Scratch executes a query against the local scratchpad database.
+	Scratch(context.Context, string) error
+	// Assist generates a query from a natural language prompt.
+	Assist(context.Context, string) (string, error)
+	// Explain explains a query in plain language.
+	Explain(context.Context, string) (string, error)
+	// Fix suggests a correction of the last failed query.
+	Fix(context.Context) (string, error)
 }
 
 // Dump writes the command descriptions to w, separated by section.
@@ -99,6 +149,70 @@ func Dump(w io.Writer, hidden bool) error {
 	return nil
 }
 
+// lookupDescs returns the descriptions of the command name (or alias),
+// including the descriptions of the command's other names.
+func lookupDescs(name string) []desc {
+	f, ok := cmds[name]
+	if !ok {
+		return nil
+	}
+	var v []desc
+	for i := range sections {
+		for _, desc := range descs[i] {
+			if reflect.ValueOf(desc.Func).Pointer() == reflect.ValueOf(f).Pointer() && !desc.Hidden && !desc.Deprecated {
+				v = append(v, desc)
+			}
+		}
+	}
+	return v
+}
+
+// dumpDescs writes the command descriptions to w.
+func dumpDescs(w io.Writer, v []desc) {
+	n := 0
+	for _, desc := range v {
+		n = max(n, runewidth.StringWidth(desc.Name)+1+runewidth.StringWidth(desc.Params))
+	}
+	for _, desc := range v {
+		_, _ = fmt.Fprintf(w, "  \\%- *s  %s\n", n, desc.Name+" "+desc.Params, wrap(desc.Desc, 95, n+5))
+	}
+}
+
+// writeColumns writes the names to w in columns, ordered down each column.
+func writeColumns(w io.Writer, names []string) {
+	n := 0
+	for _, name := range names {
+		n = max(n, runewidth.StringWidth(name))
+	}
+	cols := max(1, 93/(n+3))
+	rows := (len(names) + cols - 1) / cols
+	for i := 0; i < rows; i++ {
+		var line string
+		for j := i; j < len(names); j += rows {
+			line += fmt.Sprintf("  %- *s ", n, names[j])
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
+
+// writeSQL writes the statement s to w, syntax highlighted when interactive
+// and SYNTAX_HL is enabled.
+func writeSQL(h Handler, w io.Writer, s string) {
+	if h.IO().Interactive() && env.Get("SYNTAX_HL") == "true" {
+		b := new(bytes.Buffer)
+		if h.Highlight(b, s) == nil {
+			s = b.String()
+		}
+	}
+	fmt.Fprintln(w, s)
+}
+
+// sqlStyle returns the style for the SQL generated by commands for the
+// database URL (see SQL_KEYWORD_CASE and SQL_QUOTE_IDENT).
+func sqlStyle(u *dburl.URL) stmtclass.Style {
+	return drivers.Style(u, env.Get("SQL_KEYWORD_CASE"), env.Get("SQL_QUOTE_IDENT"))
+}
+
 // Decode converts a command name (or alias) into a Runner.
 func Decode(name string, params *stmt.Params) (func(Handler) (Option, error), error) {
 	f, ok := cmds[name]
@@ -173,6 +287,11 @@ func (p *Params) All(exec bool) ([]string, error) {
 	))
 }
 
+// readVar reads a string variable from the user using the prompt.
+func (p *Params) readVar(prompt string) (string, error) {
+	return p.Handler.ReadVar("string", prompt)
+}
+
 // Raw returns the remaining command parameters as a raw string.
 //
 // Note: no other processing is done to interpolate variables or to decode
@@ -193,6 +312,12 @@ type Option struct {
 	Crosstab []string
 	// Watch is the watch duration interval.
 	Watch time.Duration
+	// WatchAlign is the watch alignment.
+	WatchAlign time.Duration
+	// WatchJitter is the maximum random delay added to each watch interval.
+	WatchJitter time.Duration
+	// WatchMaxRuntime is the maximum total time to watch.
+	WatchMaxRuntime time.Duration
 }
 
 func (opt *Option) ParseParams(params []string, defaultKey string) error {
@@ -245,6 +370,9 @@ const (
 	ExecChart
 	// ExecWatch indicates repeated execution with a fixed time interval.
 	ExecWatch
+	// ExecStash indicates execution and storing the results in the local
+	// scratchpad (\stash).
+	ExecStash
 )
 
 // desc wraps a meta command description.
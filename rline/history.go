@@ -0,0 +1,109 @@
+package rline
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/xo/usql/env"
+)
+
+// historyLimit is the number of history lines kept when the history file is
+// compacted. The history file is compacted when it has grown to twice the
+// limit.
+const historyLimit = 500
+
+// history is a history file shared by multiple running instances.
+//
+// Unlike readline's history file, which is opened once and truncated by
+// replacing the file, the history file is only appended to, and is reopened
+// (with the file locked) for every line saved. Lines saved by other running
+// instances are therefore kept, and are merged when the file is compacted.
+type history struct {
+	path string
+	last string
+}
+
+// load reads the lines of the history file, passing each to save.
+func (h *history) load(save func(string) error) error {
+	unlock, err := env.LockFile(h.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	lines, err := h.read()
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if err := save(line); err != nil {
+			return err
+		}
+	}
+	if len(lines) != 0 {
+		h.last = lines[len(lines)-1]
+	}
+	return nil
+}
+
+// append appends line to the history file, compacting the history file when
+// it has grown to twice the history limit.
+func (h *history) append(line string) error {
+	if line = strings.TrimSpace(line); line == "" || line == h.last {
+		return nil
+	}
+	h.last = line
+	unlock, err := env.LockFile(h.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	lines, err := h.read()
+	if err != nil || len(lines) < 2*historyLimit {
+		return err
+	}
+	return h.rewrite(lines[len(lines)-historyLimit:])
+}
+
+// read reads the non-empty lines of the history file. Must be called with the
+// history file locked.
+func (h *history) read() ([]string, error) {
+	f, err := os.Open(h.path)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, s.Err()
+}
+
+// rewrite atomically replaces the history file with lines. Must be called
+// with the history file locked.
+func (h *history) rewrite(lines []string) error {
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
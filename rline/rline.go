@@ -3,6 +3,7 @@ package rline
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 
@@ -168,7 +169,6 @@ func New(interactive, cygwin, forceNonInteractive bool, out, histfile string) (I
 	}
 	// create readline instance
 	l, err := readline.NewEx(&readline.Config{
-		HistoryFile:            histfile,
 		DisableAutoSaveHistory: true,
 		InterruptPrompt:        "^C",
 		HistorySearchFold:      true,
@@ -189,6 +189,21 @@ func New(interactive, cygwin, forceNonInteractive bool, out, histfile string) (I
 		return nil, err
 	}
 	closers = append(closers, l.Close)
+	// history is kept in memory by readline, and saved to the history file
+	// separately, as the history file is shared by all running instances
+	save := l.SaveHistory
+	if histfile != "" {
+		h := &history{path: histfile}
+		if err := h.load(l.SaveHistory); err != nil {
+			fmt.Fprintln(stderr, "warning:", err)
+		}
+		save = func(line string) error {
+			if err := l.SaveHistory(line); err != nil {
+				return err
+			}
+			return h.append(line)
+		}
+	}
 	n := l.Operation.Runes
 	pw := func(prompt string) (string, error) {
 		buf, err := l.ReadPassword(prompt)
@@ -219,7 +234,7 @@ func New(interactive, cygwin, forceNonInteractive bool, out, histfile string) (I
 			cfg.AutoComplete = a
 			l.SetConfig(cfg)
 		},
-		S:  l.SaveHistory,
+		S:  save,
 		Pw: pw,
 	}, nil
 }
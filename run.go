@@ -2,11 +2,15 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"os/user"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/go-git/go-billy/v5"
@@ -20,6 +24,7 @@ import (
 	"github.com/xo/usql/env"
 	"github.com/xo/usql/handler"
 	"github.com/xo/usql/rline"
+	"github.com/xo/usql/stmt"
 	"github.com/xo/usql/text"
 )
 
@@ -130,9 +135,27 @@ func New(cliargs []string) ContextExecutor {
 				return err
 			}
 			// fmt.Fprintf(os.Stderr, "\n\n%v\n\n", args.Charts)
+			if err := localize(v); err != nil {
+				return err
+			}
+			// application name and statement tags
+			for k, name := range map[string]string{"application_name": "APPLICATION_NAME", "query_tag": "QUERY_TAG"} {
+				if s := v.GetString(k); s != "" {
+					if err := env.Vars().Set(name, s); err != nil {
+						return err
+					}
+				}
+			}
 			args.Connections = v.GetStringMap("connections")
 			args.Init = v.GetString("init")
 			args.ConfigFileUsed = v.ConfigFileUsed()
+			if v.IsSet("policy") {
+				args.Policy = &stmt.Policy{
+					Limit:        v.GetInt("policy.limit"),
+					DenyStar:     v.GetStringSlice("policy.deny_select_star"),
+					RequireWhere: v.GetStringSlice("policy.require_where"),
+				}
+			}
 			return Run(cmd.Context(), args)
 		},
 	}
@@ -166,6 +189,11 @@ func New(cliargs []string) ContextExecutor {
 	flags.VarP(filevar{&args.Out}, "out", "o", "output file")
 	flags.BoolVarP(&args.ForcePassword, "password", "W", false, "force password prompt (should happen automatically)")
 	flags.BoolVarP(&args.SingleTransaction, "single-transaction", "1", false, "execute as a single transaction (if non-interactive)")
+	flags.BoolVar(&args.Probe, "probe", false, "probe the database's capabilities, print a report, then exit")
+	flags.BoolVar(&args.Wizard, "wizard", false, "connect using the interactive connection wizard")
+	flags.StringVar(&args.ReplayWorkload, "replay-workload", "", "replay the statements captured in `FILE` (see \\capture command), then exit")
+	flags.StringVar(&args.ReplaySpeed, "replay-speed", "1x", "replay at `SPEED` times the captured rate (ie, 2x)")
+	flags.StringVar(&args.ReplayTarget, "replay-target", "", "replay against `TARGET` database url or connection name instead of DSN")
 
 	// set
 	sf(flags, &args.Vars, "set", "v", `set variable NAME to VALUE (see \set command, aliases: --var --variable)`, "NAME=VALUE")
@@ -235,7 +263,8 @@ func Run(ctx context.Context, args *Args) error {
 	forceNonInteractive := len(args.CommandOrFiles) != 0
 
 	// enable term graphics
-	if !forceNonInteractive && interactive && !cygwin {
+	graphics := !forceNonInteractive && interactive && !cygwin
+	if graphics {
 		// NOTE: this is done here and not in the env.init() package, because
 		// NOTE: we need to determine if it is interactive first, otherwise it
 		// NOTE: could mess up the non-interactive output with control characters
@@ -309,17 +338,55 @@ func Run(ctx context.Context, args *Args) error {
 	defer l.Close()
 	// create handler
 	h := handler.New(l, u, wd, args.Charts, args.NoPassword)
-	// force password
+	h.SetPolicy(args.Policy)
+	// probe
+	if args.Probe {
+		if args.DSN == "" {
+			return text.ErrMissingDSN
+		}
+		_ = env.Vars().Set("QUIET", "on")
+	}
 	dsn := args.DSN
+	// replay
+	var speed float64
+	if args.ReplayWorkload != "" {
+		if args.ReplayTarget != "" {
+			dsn = args.ReplayTarget
+		}
+		if dsn == "" {
+			return text.ErrMissingDSN
+		}
+		if speed, err = handler.ParseSpeed(args.ReplaySpeed); err != nil {
+			return err
+		}
+		_ = env.Vars().Set("QUIET", "on")
+	}
+	// detect term graphics while connecting, after all variables have been
+	// set (stdin readers wait for the detection, see env.WaitTermGraphics)
+	if graphics && !args.Probe && args.ReplayWorkload == "" {
+		env.DetectTermGraphics()
+	}
+	// force password
 	if args.ForcePassword {
 		if dsn, err = h.Password(dsn); err != nil {
 			return err
 		}
 	}
 	// open dsn
-	if err = h.Open(ctx, dsn); err != nil {
+	if args.Wizard {
+		err = h.Wizard(ctx)
+	} else {
+		err = h.Open(ctx, dsn)
+	}
+	if err != nil {
 		return err
 	}
+	if args.Probe {
+		return h.Probe(ctx, os.Stdout)
+	}
+	if args.ReplayWorkload != "" {
+		return h.Replay(ctx, os.Stdout, args.ReplayWorkload, speed)
+	}
 	// start transaction
 	if args.SingleTransaction {
 		if h.IO().Interactive() {
@@ -329,6 +396,8 @@ func Run(ctx context.Context, args *Args) error {
 			return err
 		}
 	}
+	// wait for term graphics detection, as init scripts may read stdin
+	env.WaitTermGraphics()
 	// init script
 	if !args.NoInit {
 		// rc file
@@ -349,7 +418,12 @@ func Run(ctx context.Context, args *Args) error {
 		f = runCommandOrFiles(h, args.CommandOrFiles)
 	}
 	// run
-	if err = f(); err != nil {
+	err = f()
+	// close output, renaming atomic output files into place
+	if closeErr := h.CloseOutput(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
 		return err
 	}
 	// commit
@@ -368,6 +442,11 @@ type Args struct {
 	NoPassword        bool
 	NoInit            bool
 	SingleTransaction bool
+	Probe             bool
+	Wizard            bool
+	ReplayWorkload    string
+	ReplaySpeed       string
+	ReplayTarget      string
 	Vars              []string
 	Cvars             []string
 	Pvars             []string
@@ -375,6 +454,7 @@ type Args struct {
 	Connections       map[string]interface{}
 	Init              string
 	ConfigFileUsed    string
+	Policy            *stmt.Policy
 }
 
 // CommandOrFile is a special type to deal with interspersed -c, -f,
@@ -470,17 +550,77 @@ func (filevar) Type() string {
 	return "FILE"
 }
 
-// chartsFS creates a filesystem for charts.
-func chartsFS(v *viper.Viper) (billy.Filesystem, error) {
-	var configDir string
+// configDir returns the directory of the config file, or the default config
+// directory.
+func configDir(v *viper.Viper) (string, error) {
 	if s := v.ConfigFileUsed(); s != "" {
-		configDir = filepath.Dir(s)
-	} else {
-		var err error
-		if configDir, err = os.UserConfigDir(); err != nil {
-			return nil, err
+		return filepath.Dir(s), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, text.CommandName), nil
+}
+
+// localize loads the locale catalog for the configured language (or the
+// system locale), localizing messages and setting the catalog's print
+// variables.
+//
+// The catalog is read from locale/<language>.json in the config directory,
+// trying the full language tag (ie, de-DE) before the base language (ie,
+// de). A catalog that cannot be read or has unknown names is reported as a
+// warning.
+func localize(v *viper.Viper) error {
+	lang := v.GetString("language")
+	if lang != "" {
+		if _, err := env.Vars().SetPrint("locale", lang); err != nil {
+			return err
+		}
+	} else if lang, _ = env.Vars().GetPrint("locale"); lang == "" {
+		return nil
+	}
+	dir, err := configDir(v)
+	if err != nil {
+		return err
+	}
+	lang = strings.ReplaceAll(strings.SplitN(lang, ".", 2)[0], "_", "-")
+	base, _, _ := strings.Cut(lang, "-")
+	for _, name := range []string{lang, base} {
+		buf, err := os.ReadFile(filepath.Join(dir, "locale", name+".json"))
+		switch {
+		case err != nil && os.IsNotExist(err):
+			continue
+		case err != nil:
+			fmt.Fprintf(os.Stderr, text.LocaleCatalogFailed+"\n", name, err)
+			return nil
 		}
-		configDir = filepath.Join(configDir, text.CommandName)
+		var catalog struct {
+			Messages map[string]string `json:"messages"`
+			Print    map[string]string `json:"pset"`
+		}
+		if err := json.Unmarshal(buf, &catalog); err != nil {
+			fmt.Fprintf(os.Stderr, text.LocaleCatalogFailed+"\n", name, err)
+			return nil
+		}
+		if err := text.Localize(catalog.Messages); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		for _, k := range slices.Sorted(maps.Keys(catalog.Print)) {
+			if _, err := env.Vars().SetPrint(k, catalog.Print[k]); err != nil {
+				fmt.Fprintf(os.Stderr, text.LocaleCatalogFailed+"\n", name, err)
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// chartsFS creates a filesystem for charts.
+func chartsFS(v *viper.Viper) (billy.Filesystem, error) {
+	configDir, err := configDir(v)
+	if err != nil {
+		return nil, err
 	}
 	chartsPath := "charts"
 	if s := v.GetString("charts_path"); s != "" {
@@ -510,11 +650,34 @@ func setConn(name string, value interface{}) error {
 	case []interface{}:
 		return env.Vars().SetConn(name, convSlice(x)...)
 	case map[string]interface{}:
+		// connection options
+		var opts [][2]string
+		if s, ok := x["auth_command"].(string); ok {
+			x = maps.Clone(x)
+			delete(x, "auth_command")
+			opts = append(opts, [2]string{"auth_command", s})
+		}
+		if s, ok := x["banner"].(string); ok {
+			x = maps.Clone(x)
+			delete(x, "banner")
+			opts = append(opts, [2]string{"banner", s})
+		}
+		if b, ok := x["allow_plaintext"].(bool); ok {
+			x = maps.Clone(x)
+			delete(x, "allow_plaintext")
+			opts = append(opts, [2]string{"allow_plaintext", strconv.FormatBool(b)})
+		}
 		urlstr, err := dburl.BuildURL(x)
 		if err != nil {
 			return err
 		}
-		return env.Vars().SetConn(name, urlstr)
+		if err := env.Vars().SetConn(name, urlstr); err != nil {
+			return err
+		}
+		for _, opt := range opts {
+			env.Vars().SetConnOpt(name, opt[0], opt[1])
+		}
+		return nil
 	}
 	return text.ErrInvalidConfig
 }
@@ -0,0 +1,263 @@
+// Package sqlhelp provides the bundled SQL syntax and function help for the
+// SQL dialects of the database drivers (ie, \h SELECT, \h date_trunc).
+//
+// The help for each dialect is a text file (see data/), with an entry for each
+// statement or function, starting with a header line:
+//
+//	== statement NAME
+//	== function NAME [ALIAS ...]
+//
+// followed by a one line description, and the statement's syntax or the
+// function's signatures.
+package sqlhelp
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kind is a help entry kind.
+type Kind int
+
+// Help entry kinds.
+const (
+	// Statement is a SQL statement.
+	Statement Kind = iota
+	// Function is a SQL function.
+	Function
+)
+
+// String satisfies the fmt.Stringer interface.
+func (k Kind) String() string {
+	if k == Function {
+		return "function"
+	}
+	return "statement"
+}
+
+// Entry is a help entry.
+type Entry struct {
+	// Kind is the entry kind.
+	Kind Kind
+	// Name is the statement (ie, ALTER TABLE) or function name.
+	Name string
+	// Aliases are the function's other names, documented by the entry.
+	Aliases []string
+	// Desc is the description.
+	Desc string
+	// Syntax is the statement's syntax, or the function's signatures.
+	Syntax string
+}
+
+// Write writes the entry to w.
+func (e Entry) Write(w io.Writer) error {
+	label := "Command:    "
+	if e.Kind == Function {
+		label = "Function:   "
+	}
+	_, err := fmt.Fprintf(w, "%s %s\nDescription: %s\nSyntax:\n%s\n", label, e.Name, e.Desc, e.Syntax)
+	return err
+}
+
+// dialects are the dialect titles.
+var dialects = map[string]string{
+	"mysql":     "MySQL",
+	"oracle":    "Oracle Database",
+	"postgres":  "PostgreSQL",
+	"sql":       "Standard SQL",
+	"sqlite":    "SQLite",
+	"sqlserver": "Microsoft SQL Server",
+}
+
+// Dialect returns the help dialect for a database driver, or sql (standard
+// SQL) when the driver has no bundled help.
+func Dialect(driver string) string {
+	switch driver {
+	case "postgres", "pgx":
+		return "postgres"
+	case "mysql", "mymysql":
+		return "mysql"
+	case "sqlite3", "moderncsqlite":
+		return "sqlite"
+	case "sqlserver":
+		return "sqlserver"
+	case "godror", "oracle":
+		return "oracle"
+	}
+	return "sql"
+}
+
+// Title returns the title of the dialect.
+func Title(dialect string) string {
+	return dialects[dialect]
+}
+
+// Lookup returns the help entries of the dialect for the name, matching the
+// longest statement name the name starts with (ie, ALTER TABLE for ALTER
+// TABLE foo), and functions named name (ie, date_trunc, or date_trunc()).
+// When nothing matches, the statements starting with the name are returned
+// (ie, ALTER TABLE and ALTER VIEW for ALTER). Names are case insensitive. An
+// asterisk (*) returns all statements.
+func Lookup(dialect, name string) ([]Entry, error) {
+	entries, err := load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	words := strings.Fields(strings.ToUpper(name))
+	if len(words) == 0 {
+		return nil, nil
+	}
+	if len(words) == 1 && words[0] == "*" {
+		var v []Entry
+		for _, e := range entries {
+			if e.Kind == Statement {
+				v = append(v, e)
+			}
+		}
+		return v, nil
+	}
+	fn := strings.TrimRight(words[0], "()")
+	var stmt *Entry
+	var v []Entry
+	for i, e := range entries {
+		switch {
+		case e.Kind == Function && len(words) == 1 && e.named(fn):
+			v = append(v, e)
+		case e.Kind == Statement && hasWords(words, strings.Fields(e.Name)) &&
+			(stmt == nil || len(e.Name) > len(stmt.Name)):
+			stmt = &entries[i]
+		}
+	}
+	if stmt != nil {
+		v = append([]Entry{*stmt}, v...)
+	}
+	if len(v) == 0 {
+		for _, e := range entries {
+			if e.Kind == Statement && hasWords(strings.Fields(e.Name), words) {
+				v = append(v, e)
+			}
+		}
+	}
+	return v, nil
+}
+
+// named returns whether the function entry is named name (upper case).
+func (e Entry) named(name string) bool {
+	if strings.ToUpper(e.Name) == name {
+		return true
+	}
+	for _, alias := range e.Aliases {
+		if strings.ToUpper(alias) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWords returns whether words starts with prefix.
+func hasWords(words, prefix []string) bool {
+	if len(prefix) > len(words) {
+		return false
+	}
+	for i, s := range prefix {
+		if words[i] != s {
+			return false
+		}
+	}
+	return true
+}
+
+// Names returns the sorted statement and function names of the dialect.
+func Names(dialect string) ([]string, []string, error) {
+	entries, err := load(dialect)
+	if err != nil {
+		return nil, nil, err
+	}
+	var stmts, funcs []string
+	for _, e := range entries {
+		if e.Kind == Statement {
+			stmts = append(stmts, e.Name)
+		} else {
+			funcs = append(funcs, e.Name)
+		}
+	}
+	sort.Strings(stmts)
+	sort.Strings(funcs)
+	return stmts, funcs, nil
+}
+
+//go:embed data/*.txt
+var data embed.FS
+
+// cache is the cache of loaded dialect entries.
+var cache sync.Map
+
+// load loads the entries of the dialect.
+func load(dialect string) ([]Entry, error) {
+	if v, ok := cache.Load(dialect); ok {
+		return v.([]Entry), nil
+	}
+	if _, ok := dialects[dialect]; !ok {
+		return nil, fmt.Errorf("unknown dialect %q", dialect)
+	}
+	f, err := data.Open("data/" + dialect + ".txt")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dialect, err)
+	}
+	cache.Store(dialect, entries)
+	return entries, nil
+}
+
+// headerRE matches an entry header.
+var headerRE = regexp.MustCompile(`^== (statement|function) (\S.*)$`)
+
+// parse parses the entries of a help file.
+func parse(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var syntax []string
+	end := func() {
+		if n := len(entries); n != 0 {
+			entries[n-1].Syntax = strings.TrimRight(strings.Join(syntax, "\n"), "\n ")
+		}
+		syntax = nil
+	}
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		switch m, n := headerRE.FindStringSubmatch(s.Text()), len(entries); {
+		case m != nil:
+			end()
+			e := Entry{Name: strings.TrimSpace(m[2])}
+			if m[1] == "function" {
+				names := strings.Fields(e.Name)
+				e.Kind, e.Name, e.Aliases = Function, names[0], names[1:]
+			}
+			entries = append(entries, e)
+		case strings.HasPrefix(s.Text(), "=="):
+			return nil, fmt.Errorf("line %d: invalid header %q", line, s.Text())
+		case n == 0:
+			// comments before the first entry
+		case entries[n-1].Desc == "":
+			if entries[n-1].Desc = strings.TrimSpace(s.Text()); entries[n-1].Desc == "" {
+				return nil, fmt.Errorf("line %d: missing description for %s", line, entries[n-1].Name)
+			}
+		case len(syntax) != 0 || strings.TrimSpace(s.Text()) != "":
+			syntax = append(syntax, s.Text())
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	end()
+	return entries, nil
+}
@@ -0,0 +1,81 @@
+package sqlhelp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	for dialect := range dialects {
+		entries, err := load(dialect)
+		if err != nil {
+			t.Fatalf("dialect %s expected no error, got: %v", dialect, err)
+		}
+		if len(entries) == 0 {
+			t.Fatalf("dialect %s expected entries", dialect)
+		}
+		names := make(map[string]bool)
+		for _, e := range entries {
+			key := e.Kind.String() + " " + strings.ToUpper(e.Name)
+			if names[key] {
+				t.Errorf("dialect %s has duplicate %s", dialect, key)
+			}
+			names[key] = true
+			if e.Desc == "" || e.Syntax == "" {
+				t.Errorf("dialect %s %s expected description and syntax", dialect, key)
+			}
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		dialect string
+		name    string
+		exp     []string
+	}{
+		{"postgres", "select", []string{"statement SELECT"}},
+		{"postgres", "ALTER TABLE foo ADD COLUMN", []string{"statement ALTER TABLE"}},
+		{"postgres", "create table", []string{"statement CREATE TABLE"}},
+		{"postgres", "create table as", []string{"statement CREATE TABLE AS"}},
+		{"postgres", "CREATE", []string{"statement CREATE TABLE", "statement CREATE TABLE AS", "statement CREATE INDEX", "statement CREATE VIEW", "statement CREATE MATERIALIZED VIEW", "statement CREATE SEQUENCE", "statement CREATE FUNCTION"}},
+		{"postgres", "DATE_TRUNC", []string{"function date_trunc"}},
+		{"postgres", "date_trunc()", []string{"function date_trunc"}},
+		{"postgres", "strpos", []string{"function position"}},
+		{"mysql", "replace", []string{"statement REPLACE", "function replace"}},
+		{"mysql", "date_trunc", nil},
+		{"sqlserver", "datetrunc", []string{"function datetrunc"}},
+		{"oracle", "trunc", []string{"function trunc"}},
+		{"sqlite", "strftime", []string{"function strftime"}},
+		{"sql", "unknown", nil},
+	}
+	for _, test := range tests {
+		entries, err := Lookup(test.dialect, test.name)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		var v []string
+		for _, e := range entries {
+			v = append(v, e.Kind.String()+" "+e.Name)
+		}
+		if strings.Join(v, ",") != strings.Join(test.exp, ",") {
+			t.Errorf("%s %q expected %v, got: %v", test.dialect, test.name, test.exp, v)
+		}
+	}
+	if entries, _ := Lookup("sqlite", "*"); len(entries) < 10 {
+		t.Errorf("expected all statements, got: %d", len(entries))
+	}
+}
+
+func TestDialect(t *testing.T) {
+	for driver, exp := range map[string]string{
+		"pgx":           "postgres",
+		"moderncsqlite": "sqlite",
+		"godror":        "oracle",
+		"clickhouse":    "sql",
+	} {
+		if s := Dialect(driver); s != exp {
+			t.Errorf("driver %s expected %s, got: %s", driver, exp, s)
+		}
+	}
+}
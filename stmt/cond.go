@@ -0,0 +1,126 @@
+package stmt
+
+import (
+	"github.com/xo/usql/text"
+)
+
+// CondState is the state of a conditional block (ie, \if ... \endif).
+type CondState int
+
+// Conditional block states.
+const (
+	// CondNone is outside of any conditional block.
+	CondNone CondState = iota
+	// CondTrue is within a true branch.
+	CondTrue
+	// CondFalse is within a false branch, with no true branch taken yet.
+	CondFalse
+	// CondIgnored is within a branch that is skipped, either because a true
+	// branch was already taken, or because the conditional block is nested
+	// within a skipped branch.
+	CondIgnored
+	// CondElseTrue is within a true else branch.
+	CondElseTrue
+	// CondElseFalse is within a skipped else branch.
+	CondElseFalse
+)
+
+// Cond is a stack of nested conditional blocks.
+type Cond struct {
+	stack []CondState
+}
+
+// State returns the state of the innermost conditional block.
+func (c *Cond) State() CondState {
+	if len(c.stack) == 0 {
+		return CondNone
+	}
+	return c.stack[len(c.stack)-1]
+}
+
+// Active returns true when statements and commands should be processed (ie,
+// when not within a skipped branch).
+func (c *Cond) Active() bool {
+	switch c.State() {
+	case CondFalse, CondIgnored, CondElseFalse:
+		return false
+	}
+	return true
+}
+
+// Depth returns the number of open conditional blocks.
+func (c *Cond) Depth() int {
+	return len(c.stack)
+}
+
+// Eval returns true when the expression of a branch starting in the current
+// state needs to be evaluated (ie, a nested \if within a skipped branch is
+// not evaluated).
+func (c *Cond) Eval(name string) bool {
+	switch name {
+	case "if":
+		return c.Active()
+	case "elif":
+		return c.State() == CondFalse
+	}
+	return false
+}
+
+// If begins a conditional block, with the value of its expression.
+func (c *Cond) If(v bool) {
+	state := CondIgnored
+	switch {
+	case c.Active() && v:
+		state = CondTrue
+	case c.Active():
+		state = CondFalse
+	}
+	c.stack = append(c.stack, state)
+}
+
+// Elif begins an alternative branch of the current conditional block, with
+// the value of its expression.
+func (c *Cond) Elif(v bool) error {
+	switch c.State() {
+	case CondNone:
+		return text.ErrCondNoIf
+	case CondElseTrue, CondElseFalse:
+		return text.ErrCondAfterElse
+	case CondTrue:
+		c.set(CondIgnored)
+	case CondFalse:
+		if v {
+			c.set(CondTrue)
+		}
+	}
+	return nil
+}
+
+// Else begins the final branch of the current conditional block.
+func (c *Cond) Else() error {
+	switch c.State() {
+	case CondNone:
+		return text.ErrCondNoIf
+	case CondElseTrue, CondElseFalse:
+		return text.ErrCondAfterElse
+	case CondFalse:
+		c.set(CondElseTrue)
+	default:
+		c.set(CondElseFalse)
+	}
+	return nil
+}
+
+// Endif ends the current conditional block.
+func (c *Cond) Endif() error {
+	if len(c.stack) == 0 {
+		return text.ErrCondNoIf
+	}
+	c.stack = c.stack[:len(c.stack)-1]
+	return nil
+}
+
+// set sets the state of the innermost conditional block.
+func (c *Cond) set(state CondState) {
+	c.stack[len(c.stack)-1] = state
+}
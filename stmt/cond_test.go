@@ -0,0 +1,98 @@
+package stmt
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCond(t *testing.T) {
+	tests := []struct {
+		s      string
+		active []bool
+		err    bool
+	}{
+		{`if:t endif`, []bool{true, true}, false},
+		{`if:f endif`, []bool{false, true}, false},
+		{`if:t else endif`, []bool{true, false, true}, false},
+		{`if:f else endif`, []bool{false, true, true}, false},
+		{`if:f elif:f elif:t else endif`, []bool{false, false, true, false, true}, false},
+		{`if:t elif:t else endif`, []bool{true, false, false, true}, false},
+		{`if:f if:t else endif endif`, []bool{false, false, false, false, true}, false},
+		{`if:t if:f else endif endif`, []bool{true, false, true, true, true}, false},
+		{`if:f if:t endif else endif`, []bool{false, false, false, true, true}, false},
+		{`if:t else elif:t`, []bool{true, false, false}, true},
+		{`if:t else else`, []bool{true, false, false}, true},
+		{`endif`, []bool{true}, true},
+		{`else`, []bool{true}, true},
+		{`elif:t`, []bool{true}, true},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var c Cond
+			var err error
+			for j, s := range strings.Fields(test.s) {
+				name, v, _ := strings.Cut(s, ":")
+				// expressions of branches that cannot be taken are not evaluated
+				if exp, got := (name == "if" && c.Active()) || (name == "elif" && c.State() == CondFalse), c.Eval(name); exp != got {
+					t.Errorf("%d: %s expected eval %t, got: %t", j, s, exp, got)
+				}
+				switch name {
+				case "if":
+					c.If(v == "t")
+				case "elif":
+					err = c.Elif(v == "t")
+				case "else":
+					err = c.Else()
+				case "endif":
+					err = c.Endif()
+				}
+				if exp, got := test.active[j], c.Active(); exp != got {
+					t.Errorf("%d: %s expected active %t, got: %t", j, s, exp, got)
+				}
+			}
+			switch {
+			case test.err && err == nil:
+				t.Errorf("expected error")
+			case !test.err && err != nil:
+				t.Errorf("expected no error, got: %v", err)
+			case !test.err && c.Depth() != 0:
+				t.Errorf("expected depth 0, got: %d", c.Depth())
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	b := New(sp("select 1\n:a from t; drop table t;\nx", "\n"))
+	unquote := func(string, bool) (string, bool, error) {
+		return "", false, nil
+	}
+	if _, _, err := b.Next(unquote); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	n := b.Len
+	if _, _, err := b.Next(unquote); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !b.Ready() || len(b.Vars) != 1 {
+		t.Fatalf("expected ready with 1 var, got: %t %d", b.Ready(), len(b.Vars))
+	}
+	b.Truncate(n)
+	if b.Ready() || len(b.Vars) != 0 {
+		t.Fatalf("expected not ready with 0 vars, got: %t %d", b.Ready(), len(b.Vars))
+	}
+	if exp, got := "select 1", b.String(); exp != got {
+		t.Errorf("expected %q, got: %q", exp, got)
+	}
+	if exp, got := "SELECT", b.Prefix; exp != got {
+		t.Errorf("expected prefix %q, got: %q", exp, got)
+	}
+	b.Truncate(0)
+	if _, _, err := b.Next(unquote); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp, got := "drop table t;", b.String(); exp != got {
+		t.Errorf("expected %q, got: %q", exp, got)
+	}
+}
@@ -0,0 +1,148 @@
+package stmt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/xo/usql/stmtclass"
+	"github.com/xo/usql/text"
+)
+
+// Policy is a statement policy, used to govern the statements that a user
+// (ie, an analyst account) is allowed to execute.
+//
+// A policy is applied to a statement prior to execution. See Policy.Apply.
+type Policy struct {
+	// Limit is the row limit appended to SELECT queries that do not already
+	// have a row limit. Zero disables the limit.
+	Limit int
+	// DenyStar are the tables that cannot be queried using SELECT *.
+	DenyStar []string
+	// RequireWhere are the tables that require a WHERE clause when queried
+	// or modified.
+	RequireWhere []string
+}
+
+// Empty returns true when the policy has no restrictions.
+func (p *Policy) Empty() bool {
+	return p == nil || p.Limit <= 0 && len(p.DenyStar) == 0 && len(p.RequireWhere) == 0
+}
+
+// Apply applies the policy to the statement sqlstr for the driver, returning
+// the (possibly modified) statement, or an error when the statement violates
+// the policy.
+func (p *Policy) Apply(driver, sqlstr string) (string, error) {
+	if p.Empty() {
+		return sqlstr, nil
+	}
+	toks := stmtclass.Tokenize(sqlstr)
+	c := stmtclass.ClassifyTokens(toks)
+	switch {
+	case c.Query, c.Kind == stmtclass.Update, c.Kind == stmtclass.Delete:
+	default:
+		return sqlstr, nil
+	}
+	// check select *
+	if c.Star {
+		for _, table := range c.Tables {
+			if matchTable(p.DenyStar, table) {
+				return "", fmt.Errorf(text.PolicySelectStarDenied, table)
+			}
+		}
+	}
+	// check where
+	if !c.Where {
+		for _, table := range c.Tables {
+			if matchTable(p.RequireWhere, table) {
+				return "", fmt.Errorf(text.PolicyWhereRequired, table)
+			}
+		}
+	}
+	// append limit
+	if p.Limit > 0 && c.Kind == stmtclass.Select && !c.Explain && !c.Limit {
+		s, ok := appendLimit(driver, sqlstr, toks, p.Limit)
+		if !ok {
+			return "", fmt.Errorf(text.PolicyLimitUnsupported, p.Limit)
+		}
+		sqlstr = s
+	}
+	return sqlstr, nil
+}
+
+// appendLimit adds a row limit to the query sqlstr (with tokens toks) using
+// the syntax for the driver. For SQL Server, TOP is added to the SELECT of the
+// main query (following any common table expressions). Otherwise, the limit
+// is appended to the query, before any row locking clause (FOR UPDATE, FOR
+// SHARE, ...). Returns false when the limit cannot be added.
+func appendLimit(driver, sqlstr string, toks []stmtclass.Token, limit int) (string, bool) {
+	r := []rune(strings.TrimRightFunc(sqlstr, func(r rune) bool {
+		return r == ';' || unicode.IsSpace(r)
+	}))
+	n := strconv.Itoa(limit)
+	switch driver {
+	case "sqlserver":
+		i := mainSelect(toks)
+		if i == -1 {
+			return "", false
+		}
+		if i+1 < len(toks) && (toks[i+1].Is("DISTINCT") || toks[i+1].Is("ALL")) {
+			i++
+		}
+		return string(r[:toks[i].End]) + " TOP " + n + string(r[toks[i].End:]), true
+	case "oracle", "godror":
+		if lockClause(toks) != -1 {
+			// FETCH FIRST cannot be used with FOR UPDATE
+			return "", false
+		}
+		return string(r) + "\nFETCH FIRST " + n + " ROWS ONLY", true
+	}
+	if i := lockClause(toks); i != -1 {
+		pos := toks[i].Pos
+		return strings.TrimRightFunc(string(r[:pos]), unicode.IsSpace) + "\nLIMIT " + n + "\n" + string(r[pos:]), true
+	}
+	return string(r) + "\nLIMIT " + n, true
+}
+
+// mainSelect returns the position in toks of the SELECT of the main query,
+// following any common table expressions, or -1 when there is none.
+func mainSelect(toks []stmtclass.Token) int {
+	for i, tok := range toks {
+		if tok.Depth == 0 && tok.Is("SELECT") {
+			return i
+		}
+	}
+	return -1
+}
+
+// lockClause returns the position in toks of the row locking clause (FOR
+// UPDATE, FOR SHARE, ...) of the main query, or -1 when there is none.
+func lockClause(toks []stmtclass.Token) int {
+	for i := 0; i+1 < len(toks); i++ {
+		if toks[i].Depth != 0 || !toks[i].Is("FOR") {
+			continue
+		}
+		switch next := toks[i+1]; {
+		case next.Is("UPDATE"), next.Is("SHARE"), next.Is("NO"), next.Is("KEY"):
+			return i
+		}
+	}
+	return -1
+}
+
+// matchTable returns true when table matches one of the names. Matches are
+// case insensitive, and unqualified names match the last component of a
+// qualified table name.
+func matchTable(names []string, table string) bool {
+	last := table
+	if i := strings.LastIndexByte(table, '.'); i != -1 {
+		last = table[i+1:]
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, table) || strings.EqualFold(name, last) {
+			return true
+		}
+	}
+	return false
+}
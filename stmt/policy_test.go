@@ -0,0 +1,63 @@
+package stmt
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestPolicyApply(t *testing.T) {
+	p := &Policy{
+		Limit:        10,
+		DenyStar:     []string{"users"},
+		RequireWhere: []string{"events"},
+	}
+	tests := []struct {
+		driver string
+		s      string
+		exp    string
+		err    bool
+	}{
+		{"postgres", `select a from b`, "select a from b\nLIMIT 10", false},
+		{"postgres", `select a from b;`, "select a from b\nLIMIT 10", false},
+		{"postgres", `select a from b limit 5`, `select a from b limit 5`, false},
+		{"postgres", `select a from (select a from b limit 5) c`, "select a from (select a from b limit 5) c\nLIMIT 10", false},
+		{"postgres", `select * from b`, "select * from b\nLIMIT 10", false},
+		{"postgres", `select * from users`, ``, true},
+		{"postgres", `select u.* from public.users u where id = 1`, ``, true},
+		{"postgres", `select "*" from users where id = 1`, "select \"*\" from users where id = 1\nLIMIT 10", false},
+		{"postgres", `select id from b, events`, ``, true},
+		{"postgres", `select id from b join events e on e.id = b.id`, ``, true},
+		{"postgres", `select id from events where id = 1`, "select id from events where id = 1\nLIMIT 10", false},
+		{"postgres", `delete from events`, ``, true},
+		{"postgres", `update events set a = 1 where id = 1`, `update events set a = 1 where id = 1`, false},
+		{"postgres", `insert into b values (1)`, `insert into b values (1)`, false},
+		{"postgres", `with x as (select id from events) select id from x`, ``, true},
+		{"postgres", `with x as (select 1) delete from events`, ``, true},
+		{"postgres", `explain select a from b`, `explain select a from b`, false},
+		{"postgres", `insert into b select * from users`, ``, true},
+		{"postgres", `create table x as select * from users`, ``, true},
+		{"postgres", `insert into b select id from events`, ``, true},
+		{"postgres", `insert into b select id from events where id = 1`, `insert into b select id from events where id = 1`, false},
+		{"postgres", `select a from b for update;`, "select a from b\nLIMIT 10\nfor update", false},
+		{"mysql", `select a from b where id = 1 for share`, "select a from b where id = 1\nLIMIT 10\nfor share", false},
+		{"sqlserver", `select distinct a from b`, `select distinct TOP 10 a from b`, false},
+		{"sqlserver", `with x as (select a from b) select a from x;`, `with x as (select a from b) select TOP 10 a from x`, false},
+		{"sqlserver", `(select a from b)`, ``, true},
+		{"oracle", `select a from b for update`, ``, true},
+		{"oracle", `select a from b`, "select a from b\nFETCH FIRST 10 ROWS ONLY", false},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			s, err := p.Apply(test.driver, test.s)
+			switch {
+			case test.err && err == nil:
+				t.Fatalf("expected error, got: nil")
+			case !test.err && err != nil:
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if s != test.exp {
+				t.Errorf("expected %q, got: %q", test.exp, s)
+			}
+		})
+	}
+}
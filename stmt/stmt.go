@@ -3,6 +3,7 @@ package stmt
 
 import (
 	"bytes"
+	"strings"
 	"unicode"
 )
 
@@ -135,6 +136,33 @@ func (b *Stmt) Reset(r []rune) {
 	}
 }
 
+// Truncate discards the statement collected after the first n runes of
+// b.Buf, along with any encountered variables, keeping the parsing state
+// (ie, quoted strings) of any unprocessed runes.
+//
+// Used to discard the statement collected within a skipped conditional block
+// branch (see Cond).
+func (b *Stmt) Truncate(n int) {
+	if n >= b.Len {
+		b.ready = false
+		return
+	}
+	if n <= 0 {
+		b.Buf, b.Len = nil, 0
+	} else {
+		b.Buf, b.Len = b.Buf[:n], n
+	}
+	vars := b.Vars[:0]
+	for _, v := range b.Vars {
+		if v.I < n {
+			vars = append(vars, v)
+		}
+	}
+	b.Vars = vars
+	b.Prefix = findPrefix(b.Buf, prefixCount, b.allowCComments, b.allowHashComments, b.allowMultilineComments)
+	b.ready = false
+}
+
 // Next reads the next statement from the rune source, returning when either
 // the statement has been terminated, or a meta command has been read from the
 // rune source. After a call to Next, the collected statement is available in
@@ -391,12 +419,16 @@ func (v *Var) String() string {
 }
 
 // Substitute substitutes part of r, with s.
+//
+// Quoted variables (ie, :'name' and :"name") are substituted as a SQL string
+// literal or identifier, with any embedded quotes doubled.
 func (v *Var) Substitute(r []rune, s string, ok bool) ([]rune, int) {
 	switch v.Quote {
 	case '?':
 		s = trueFalse(ok)
 	case '\'', '"':
-		s = string(v.Quote) + s + string(v.Quote)
+		q := string(v.Quote)
+		s = q + strings.ReplaceAll(s, q, q+q) + q
 	}
 	// fmt.Fprintf(os.Stderr, "orig: %q repl: %q\n", string(r), s)
 	sr, rcap := []rune(s), cap(r)
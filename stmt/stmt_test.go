@@ -335,6 +335,10 @@ func TestVarSubstitute(t *testing.T) {
 		{` :a `, v(1, `a`), ``, `  `},
 		{` :'a' `, v(1, `a`, `'`), ``, ` '' `},
 		{` :"a" `, v(1, "a", `"`), "", ` "" `},
+		{` :'a' `, v(1, `a`, `'`), `it's`, ` 'it''s' `},
+		{` :'a' `, v(1, `a`, `'`), `'; drop table x; --`, ` '''; drop table x; --' `},
+		{` :"a" `, v(1, "a", `"`), `my "table"`, ` "my ""table""" `},
+		{` :'a' `, v(1, `a`, `'`), `"x"`, ` '"x"' `},
 		{` :aaa `, v(1, "aaa"), "", "  "},
 		{` :aaa `, v(1, "aaa"), a512, " " + a512 + " "},
 		{` :` + a512 + ` `, v(1, a512), "", "  "},
@@ -0,0 +1,509 @@
+// Package stmtclass provides a multi-dialect SQL statement classifier.
+//
+// Statements are tokenized (see Tokenize) and then classified by their
+// effective verb, looking through common table expressions (WITH ...),
+// EXPLAIN wrappers, and parenthesized queries, so that callers (ie, the
+// statement policy and the read-only mode) can reliably determine what a
+// statement does.
+package stmtclass
+
+import (
+	"strings"
+)
+
+// Kind is a statement kind.
+type Kind int
+
+// Statement kinds.
+const (
+	// Unknown is an unknown statement.
+	Unknown Kind = iota
+	// Select is a query (SELECT, VALUES, TABLE).
+	Select
+	// Insert is an INSERT, REPLACE, or UPSERT statement.
+	Insert
+	// Update is an UPDATE statement.
+	Update
+	// Delete is a DELETE statement.
+	Delete
+	// Merge is a MERGE statement.
+	Merge
+	// DDL is a schema definition statement (CREATE, ALTER, DROP, TRUNCATE,
+	// ...).
+	DDL
+	// DCL is an access control statement (GRANT, REVOKE).
+	DCL
+	// Transaction is a transaction control statement (BEGIN, COMMIT, ...).
+	Transaction
+	// Session is a session statement (SET, USE, SHOW, DESCRIBE, PRAGMA, ...).
+	Session
+	// Call is a procedure call (CALL, EXEC, EXECUTE, DO).
+	Call
+	// Copy is a bulk load or unload statement (COPY, LOAD).
+	Copy
+)
+
+// String satisfies the fmt.Stringer interface.
+func (k Kind) String() string {
+	switch k {
+	case Select:
+		return "SELECT"
+	case Insert:
+		return "INSERT"
+	case Update:
+		return "UPDATE"
+	case Delete:
+		return "DELETE"
+	case Merge:
+		return "MERGE"
+	case DDL:
+		return "DDL"
+	case DCL:
+		return "DCL"
+	case Transaction:
+		return "TRANSACTION"
+	case Session:
+		return "SESSION"
+	case Call:
+		return "CALL"
+	case Copy:
+		return "COPY"
+	}
+	return "UNKNOWN"
+}
+
+// Class is a statement classification.
+type Class struct {
+	// Kind is the kind of the effective statement.
+	Kind Kind
+	// Verb is the upper cased leading keyword of the effective statement (ie,
+	// DELETE for "WITH x AS (...) DELETE FROM ...").
+	Verb string
+	// Explain is whether or not the statement is wrapped in EXPLAIN.
+	Explain bool
+	// Analyze is whether or not the EXPLAIN wrapper executes the statement
+	// (ie, EXPLAIN ANALYZE).
+	Analyze bool
+	// CTE is whether or not the statement has common table expressions.
+	CTE bool
+	// CTEWrites is whether or not a common table expression modifies data
+	// (ie, PostgreSQL's "WITH d AS (DELETE ... RETURNING *) SELECT ...").
+	CTEWrites bool
+	// Into is whether or not the query writes its results (SELECT ... INTO).
+	Into bool
+	// Lock is whether or not the query locks rows (SELECT ... FOR UPDATE).
+	Lock bool
+	// Writes is whether or not a statement following the effective statement
+	// modifies data or schema (ie, SQL Server's "SELECT 1 DELETE FROM t"
+	// batches, or the statements of a BEGIN ... END block).
+	Writes bool
+	// Query is whether or not the statement contains a query (ie, INSERT ...
+	// SELECT, or CREATE TABLE ... AS SELECT).
+	Query bool
+	// Tables are the tables referenced by the statement.
+	Tables []string
+	// Star is whether or not the statement selects * (or table.*).
+	Star bool
+	// Where is whether or not the effective statement has a WHERE clause.
+	Where bool
+	// Limit is whether or not the effective statement has a row limiting
+	// clause (LIMIT, FETCH, TOP, ROWNUM).
+	Limit bool
+}
+
+// ReadOnly returns true when the statement does not modify data or schema.
+func (c Class) ReadOnly() bool {
+	if c.CTEWrites || c.Into || c.Lock || c.Writes {
+		return false
+	}
+	if c.Explain && !c.Analyze {
+		return true
+	}
+	switch c.Kind {
+	case Select, Transaction, Session:
+		return true
+	}
+	return false
+}
+
+// Destructive returns true when the statement removes data or schema without
+// restriction, ie, DROP, TRUNCATE, or an UPDATE or DELETE without a WHERE
+// clause.
+func (c Class) Destructive() bool {
+	if c.Explain && !c.Analyze {
+		return false
+	}
+	switch c.Verb {
+	case "DROP", "TRUNCATE":
+		return true
+	case "UPDATE", "DELETE":
+		return !c.Where
+	}
+	return false
+}
+
+// Classify classifies the statement sqlstr.
+func Classify(sqlstr string) Class {
+	return ClassifyTokens(Tokenize(sqlstr))
+}
+
+// ClassifyTokens classifies the statement tokens.
+func ClassifyTokens(toks []Token) Class {
+	var c Class
+	i, depth := skipParens(toks, 0)
+	// explain wrapper
+	if i < len(toks) && isExplain(toks, i) {
+		c.Explain = true
+		i, c.Analyze = skipExplain(toks, i+1)
+		i, depth = skipParens(toks, i)
+	}
+	// common table expressions
+	if i < len(toks) && toks[i].Is("WITH") {
+		c.CTE = true
+		i, c.CTEWrites = skipCTEs(toks, i+1, depth)
+	}
+	if i >= len(toks) || toks[i].Type != Word {
+		c.Tables = tables(toks)
+		return c
+	}
+	c.Verb = strings.ToUpper(toks[i].Val)
+	c.Kind = kindOf(c.Verb)
+	if isBlock(toks, i) {
+		// anonymous PL/SQL or T-SQL block
+		c.Kind = Call
+	}
+	body := toks[i:]
+	c.Tables = tables(toks)
+	c.Query = c.Kind == Select || hasSelect(toks)
+	c.Star = hasStar(toks)
+	c.Where = hasKeyword(body, depth, "WHERE")
+	c.Limit = hasKeyword(body, depth, "LIMIT", "FETCH", "TOP", "ROWNUM")
+	if c.Kind == Select {
+		c.Into = hasKeyword(body, depth, "INTO")
+		c.Lock = hasLock(body, depth)
+	}
+	c.Writes = hasWrite(body)
+	return c
+}
+
+// isBlock returns true when the BEGIN or DECLARE verb at i starts an
+// anonymous block, instead of a transaction (BEGIN [TRAN | TRANSACTION |
+// WORK | ...]) or a cursor (DECLARE name ... CURSOR).
+func isBlock(toks []Token, i int) bool {
+	switch {
+	case toks[i].Is("BEGIN"):
+		if i+1 >= len(toks) || toks[i+1].Type == Punct && toks[i+1].Val == ";" {
+			return false
+		}
+		switch next := toks[i+1]; {
+		case next.Is("TRAN"), next.Is("TRANSACTION"), next.Is("WORK"),
+			next.Is("ISOLATION"), next.Is("READ"), next.Is("NOT"),
+			next.Is("DEFERRED"), next.Is("IMMEDIATE"), next.Is("EXCLUSIVE"),
+			next.Is("DISTRIBUTED"):
+			return false
+		}
+		return true
+	case toks[i].Is("DECLARE"):
+		for _, tok := range toks[i+1:] {
+			switch {
+			case tok.Is("CURSOR"):
+				return false
+			case tok.Type == Punct && tok.Val == ";":
+				return true
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// hasWrite returns true when toks contains, after the leading verb, a verb of
+// a statement modifying data or schema, outside of the clauses where the verbs
+// are keywords (ie, FOR UPDATE, ON DELETE, SHOW CREATE).
+func hasWrite(toks []Token) bool {
+	for i := 1; i < len(toks); i++ {
+		tok, prev := toks[i], toks[i-1]
+		if tok.Type != Word {
+			continue
+		}
+		switch strings.ToUpper(tok.Val) {
+		case "UPDATE", "DELETE":
+			if prev.Is("FOR") || prev.Is("KEY") || prev.Is("ON") || prev.Is("THEN") {
+				continue
+			}
+		case "CREATE":
+			if prev.Is("SHOW") {
+				continue
+			}
+		case "INSERT":
+			if prev.Is("THEN") {
+				continue
+			}
+		case "MERGE", "UPSERT", "TRUNCATE", "DROP", "ALTER", "GRANT", "REVOKE",
+			"CALL", "EXEC", "EXECUTE":
+		default:
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// kindOf returns the kind for the verb.
+func kindOf(verb string) Kind {
+	switch verb {
+	case "SELECT", "VALUES", "TABLE":
+		return Select
+	case "INSERT", "REPLACE", "UPSERT":
+		return Insert
+	case "UPDATE":
+		return Update
+	case "DELETE":
+		return Delete
+	case "MERGE":
+		return Merge
+	case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME", "COMMENT", "REINDEX",
+		"CLUSTER", "VACUUM", "ANALYZE", "OPTIMIZE", "REFRESH", "IMPORT":
+		return DDL
+	case "GRANT", "REVOKE", "DENY":
+		return DCL
+	case "BEGIN", "START", "COMMIT", "ROLLBACK", "SAVEPOINT", "RELEASE", "END",
+		"ABORT":
+		return Transaction
+	case "SET", "RESET", "USE", "SHOW", "DESCRIBE", "DESC", "PRAGMA", "LIST",
+		"HELP", "DISCARD", "LISTEN", "UNLISTEN", "DECLARE", "FETCH", "CLOSE",
+		"PREPARE", "DEALLOCATE", "LOCK", "UNLOCK":
+		return Session
+	case "CALL", "EXEC", "EXECUTE", "DO":
+		return Call
+	case "COPY", "LOAD", "UNLOAD", "PUT", "GET":
+		return Copy
+	}
+	return Unknown
+}
+
+// skipParens skips leading open parens, returning the position of the first
+// non-paren token and its depth.
+func skipParens(toks []Token, i int) (int, int) {
+	depth := 0
+	for i < len(toks) && toks[i].Type == Punct && toks[i].Val == "(" {
+		depth, i = toks[i].Depth, i+1
+	}
+	return i, depth
+}
+
+// isExplain returns true when the token at i starts an EXPLAIN wrapper. The
+// MySQL DESCRIBE/DESC synonyms are only treated as a wrapper when followed by
+// a statement.
+func isExplain(toks []Token, i int) bool {
+	switch {
+	case toks[i].Is("EXPLAIN"), toks[i].Is("PROFILE"):
+		return true
+	case toks[i].Is("DESCRIBE"), toks[i].Is("DESC"):
+		if i+1 < len(toks) {
+			return kindOf(strings.ToUpper(toks[i+1].Val)) != Unknown || toks[i+1].Is("WITH")
+		}
+	}
+	return false
+}
+
+// skipExplain skips the options of an EXPLAIN wrapper, returning the position
+// of the wrapped statement and whether or not the wrapped statement is
+// executed.
+func skipExplain(toks []Token, i int) (int, bool) {
+	analyze := false
+	for i < len(toks) {
+		tok := toks[i]
+		switch {
+		case tok.Type == Punct && tok.Val == "(" && i+1 < len(toks) && (!isStatement(toks[i+1]) || toks[i+1].Is("ANALYZE")):
+			// postgres style options: EXPLAIN (ANALYZE, FORMAT JSON) ...
+			for i++; i < len(toks) && toks[i].Depth >= tok.Depth; i++ {
+				if toks[i].Is("ANALYZE") || toks[i].Is("ANALYSE") {
+					analyze = !(i+1 < len(toks) && (toks[i+1].Is("FALSE") || toks[i+1].Is("OFF")))
+				}
+			}
+			continue
+		case tok.Is("ANALYZE"), tok.Is("ANALYSE"):
+			analyze = true
+		case tok.Is("VERBOSE"), tok.Is("EXTENDED"), tok.Is("PARTITIONS"),
+			tok.Is("QUERY"), tok.Is("PLAN"), tok.Is("FOR"), tok.Is("FORMAT"),
+			tok.Is("USING"), tok.Is("SET"), tok.Is("STATEMENT_ID"), tok.Is("AST"),
+			tok.Is("SYNTAX"), tok.Is("PIPELINE"), tok.Is("ESTIMATE"),
+			tok.Is("LOGICAL"), tok.Is("DISTRIBUTED"), tok.Is("IO"), tok.Is("TYPE"):
+		case tok.Type == Punct && tok.Val == "=", tok.Type == String,
+			tok.Type == Word && i > 0 && toks[i-1].Type == Punct && toks[i-1].Val == "=",
+			tok.Type == Word && i > 0 && toks[i-1].Is("FORMAT"):
+		default:
+			return i, analyze
+		}
+		i++
+	}
+	return i, analyze
+}
+
+// isStatement returns true when tok starts a statement.
+func isStatement(tok Token) bool {
+	return tok.Type == Word && (kindOf(strings.ToUpper(tok.Val)) != Unknown || tok.Is("WITH"))
+}
+
+// skipCTEs skips the common table expressions following WITH, returning the
+// position of the main statement and whether or not any expression modifies
+// data.
+func skipCTEs(toks []Token, i, depth int) (int, bool) {
+	writes := false
+	if i < len(toks) && toks[i].Is("RECURSIVE") {
+		i++
+	}
+	for i < len(toks) {
+		// name [(cols)] AS [NOT] [MATERIALIZED] ( ... )
+		for i < len(toks) && !(toks[i].Type == Punct && toks[i].Val == "(" && toks[i].Depth == depth+1 && i > 0 && isCTEBodyStart(toks, i)) {
+			i++
+		}
+		if i >= len(toks) {
+			return i, writes
+		}
+		d := toks[i].Depth
+		if i+1 < len(toks) {
+			switch kindOf(strings.ToUpper(toks[i+1].Val)) {
+			case Insert, Update, Delete, Merge:
+				writes = writes || toks[i+1].Type == Word
+			}
+		}
+		for i++; i < len(toks) && !(toks[i].Type == Punct && toks[i].Val == ")" && toks[i].Depth == d); i++ {
+		}
+		// skip ) and ,
+		if i++; i < len(toks) && toks[i].Type == Punct && toks[i].Val == "," {
+			i++
+			continue
+		}
+		return i, writes
+	}
+	return i, writes
+}
+
+// isCTEBodyStart returns true when the open paren at i starts the body of a
+// common table expression (ie, is preceded by AS [NOT] [MATERIALIZED]).
+func isCTEBodyStart(toks []Token, i int) bool {
+	for j := i - 1; j >= 0; j-- {
+		switch {
+		case toks[j].Is("MATERIALIZED"), toks[j].Is("NOT"):
+		case toks[j].Is("AS"):
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// tables returns the tables referenced in toks, ie, the identifiers
+// following FROM, JOIN, UPDATE, INTO, and TABLE.
+func tables(toks []Token) []string {
+	var tables []string
+	for i := 0; i < len(toks); i++ {
+		switch {
+		case toks[i].Is("UPDATE") && i > 0 && (toks[i-1].Is("FOR") || toks[i-1].Is("KEY")):
+			// SELECT ... FOR UPDATE, or MySQL's ON DUPLICATE KEY UPDATE
+			continue
+		case toks[i].Is("FROM"), toks[i].Is("JOIN"), toks[i].Is("UPDATE"), toks[i].Is("INTO"):
+		case toks[i].Is("TABLE") && i > 0 && (toks[i-1].Is("TRUNCATE") || toks[i-1].Is("DROP") || toks[i-1].Is("ALTER")):
+		default:
+			continue
+		}
+		// skip modifiers
+		for i+1 < len(toks) && (toks[i+1].Is("ONLY") || toks[i+1].Is("LATERAL") || toks[i+1].Is("IF") || toks[i+1].Is("EXISTS")) {
+			i++
+		}
+		for i++; i < len(toks) && toks[i].Ident() && !(toks[i].Type == Word && isClauseKeyword(toks[i].Val)); {
+			// read dotted name
+			name := toks[i].Val
+			for i+2 < len(toks) && toks[i+1].Type == Punct && toks[i+1].Val == "." && toks[i+2].Ident() {
+				name, i = name+"."+toks[i+2].Val, i+2
+			}
+			tables = append(tables, name)
+			// skip alias
+			if i++; i < len(toks) && toks[i].Is("AS") {
+				i++
+			}
+			if i < len(toks) && toks[i].Ident() && !(toks[i].Type == Word && isClauseKeyword(toks[i].Val)) {
+				i++
+			}
+			// comma separated list of tables
+			if i >= len(toks) || toks[i].Type != Punct || toks[i].Val != "," {
+				break
+			}
+			i++
+		}
+		i--
+	}
+	return tables
+}
+
+// hasSelect returns true when toks contains a SELECT.
+func hasSelect(toks []Token) bool {
+	for _, tok := range toks {
+		if tok.Is("SELECT") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasStar returns true when toks selects * (or table.*).
+func hasStar(toks []Token) bool {
+	for i := 1; i < len(toks); i++ {
+		if toks[i].Type != Punct || toks[i].Val != "*" {
+			continue
+		}
+		switch prev := toks[i-1]; {
+		case prev.Is("SELECT"), prev.Is("DISTINCT"), prev.Is("ALL"),
+			prev.Type == Punct && (prev.Val == "," || prev.Val == "."):
+			return true
+		}
+	}
+	return false
+}
+
+// hasKeyword returns true when toks contains one of the keywords at depth.
+func hasKeyword(toks []Token, depth int, keywords ...string) bool {
+	for _, tok := range toks {
+		if tok.Type != Word || tok.Depth != depth {
+			continue
+		}
+		for _, keyword := range keywords {
+			if strings.EqualFold(tok.Val, keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasLock returns true when toks contains a row locking clause (FOR UPDATE,
+// FOR SHARE, ...) at depth.
+func hasLock(toks []Token, depth int) bool {
+	for i := 0; i+1 < len(toks); i++ {
+		if toks[i].Depth != depth || !toks[i].Is("FOR") {
+			continue
+		}
+		switch next := toks[i+1]; {
+		case next.Is("UPDATE"), next.Is("SHARE"), next.Is("NO"), next.Is("KEY"):
+			return true
+		}
+	}
+	return false
+}
+
+// isClauseKeyword returns true when s is a keyword that can follow a table
+// name.
+func isClauseKeyword(s string) bool {
+	switch strings.ToUpper(s) {
+	case "WHERE", "JOIN", "INNER", "LEFT", "RIGHT", "FULL", "OUTER", "CROSS",
+		"NATURAL", "ON", "USING", "GROUP", "ORDER", "HAVING", "LIMIT", "OFFSET",
+		"FETCH", "UNION", "INTERSECT", "EXCEPT", "SET", "WINDOW", "FOR",
+		"VALUES", "RETURNING", "SELECT", "DEFAULT", "OUTPUT", "WITH", "FROM",
+		"INTO", "WHEN":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package stmtclass
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		s           string
+		kind        Kind
+		verb        string
+		readOnly    bool
+		destructive bool
+		tables      []string
+	}{
+		{`select a from b`, Select, "SELECT", true, false, []string{"b"}},
+		{"  -- comment\n/* multi /* nested */ */ SELECT 1", Select, "SELECT", true, false, nil},
+		{`(select a from b) union (select a from c)`, Select, "SELECT", true, false, []string{"b", "c"}},
+		{`values (1), (2)`, Select, "VALUES", true, false, nil},
+		{`select a into c from b`, Select, "SELECT", false, false, []string{"c", "b"}},
+		{`select a from b for update`, Select, "SELECT", false, false, []string{"b"}},
+		{`with x as (select a from b) select * from x`, Select, "SELECT", true, false, []string{"b", "x"}},
+		{`with recursive x(n) as (select 1 union all select n+1 from x) select n from x`, Select, "SELECT", true, false, []string{"x", "x"}},
+		{`with x as (select a from b) delete from c where a in (select a from x)`, Delete, "DELETE", false, false, []string{"b", "c", "x"}},
+		{`with d as (delete from b returning *) select * from d`, Select, "SELECT", false, false, []string{"b", "d"}},
+		{`with x as not materialized (select 1) update c set a = 1`, Update, "UPDATE", false, true, []string{"c"}},
+		{`explain select * from b`, Select, "SELECT", true, false, []string{"b"}},
+		{`explain analyze delete from b`, Delete, "DELETE", false, true, []string{"b"}},
+		{`explain (analyze, format json) update b set a = 1 where id = 2`, Update, "UPDATE", false, false, []string{"b"}},
+		{`explain (analyze false) update b set a = 1`, Update, "UPDATE", true, false, []string{"b"}},
+		{`explain query plan select 1`, Select, "SELECT", true, false, nil},
+		{`explain plan for delete from b`, Delete, "DELETE", true, false, []string{"b"}},
+		{`describe select a from b`, Select, "SELECT", true, false, []string{"b"}},
+		{`describe b`, Session, "DESCRIBE", true, false, nil},
+		{`insert into b (a) values (1)`, Insert, "INSERT", false, false, []string{"b"}},
+		{`insert into b select * from c on duplicate key update a = 1`, Insert, "INSERT", false, false, []string{"b", "c"}},
+		{`delete from "Public"."My Table"`, Delete, "DELETE", false, true, []string{"Public.My Table"}},
+		{"delete from `b` where id = 1", Delete, "DELETE", false, false, []string{"b"}},
+		{`delete from [dbo].[b] where id = 1`, Delete, "DELETE", false, false, []string{"dbo.b"}},
+		{`update b set a = 'where' -- where`, Update, "UPDATE", false, true, []string{"b"}},
+		{`drop table if exists b`, DDL, "DROP", false, true, []string{"b"}},
+		{`truncate table b`, DDL, "TRUNCATE", false, true, []string{"b"}},
+		{`create function f() returns int as $$ delete from b $$ language sql`, DDL, "CREATE", false, false, nil},
+		{`begin`, Transaction, "BEGIN", true, false, nil},
+		{`begin transaction isolation level serializable`, Transaction, "BEGIN", true, false, nil},
+		{`BEGIN DELETE FROM t; END;`, Call, "BEGIN", false, false, []string{"t"}},
+		{`DECLARE x int; BEGIN DELETE FROM t; END;`, Call, "DECLARE", false, false, []string{"t"}},
+		{`declare c cursor for select a from b`, Session, "DECLARE", true, false, []string{"b"}},
+		{`SELECT 1 DELETE FROM t`, Select, "SELECT", false, false, []string{"t"}},
+		{`show create table b`, Session, "SHOW", true, false, nil},
+		{`set search_path = x`, Session, "SET", true, false, nil},
+		{`grant select on b to c`, DCL, "GRANT", false, false, nil},
+		{`call p()`, Call, "CALL", false, false, nil},
+		{`merge into b using c on b.id = c.id when matched then delete`, Merge, "MERGE", false, false, []string{"b"}},
+		{``, Unknown, "", false, false, nil},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c := Classify(test.s)
+			if c.Kind != test.kind {
+				t.Errorf("expected kind %v, got: %v", test.kind, c.Kind)
+			}
+			if c.Verb != test.verb {
+				t.Errorf("expected verb %q, got: %q", test.verb, c.Verb)
+			}
+			if b := c.ReadOnly(); b != test.readOnly {
+				t.Errorf("expected read only %t, got: %t", test.readOnly, b)
+			}
+			if b := c.Destructive(); b != test.destructive {
+				t.Errorf("expected destructive %t, got: %t", test.destructive, b)
+			}
+			if !reflect.DeepEqual(c.Tables, test.tables) {
+				t.Errorf("expected tables %q, got: %q", test.tables, c.Tables)
+			}
+		})
+	}
+}
+
+func TestClassifyClauses(t *testing.T) {
+	tests := []struct {
+		s     string
+		star  bool
+		where bool
+		limit bool
+	}{
+		{`select * from b`, true, false, false},
+		{`select b.* from b where a = 1`, true, true, false},
+		{`select "*" from b`, false, false, false},
+		{`select count(*) from b`, false, false, false},
+		{`select a from b where a in (select a from c limit 1)`, false, true, false},
+		{`select a from (select a from c where a = 1) x`, false, false, false},
+		{`select top 10 a from b`, false, false, true},
+		{`select a from b fetch first 10 rows only`, false, false, true},
+		{`with x as (select a from b limit 1) select a from x`, false, false, false},
+		{`with x as (select a from b) select a from x where a = 1 limit 1`, false, true, true},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c := Classify(test.s)
+			if c.Star != test.star {
+				t.Errorf("expected star %t, got: %t", test.star, c.Star)
+			}
+			if c.Where != test.where {
+				t.Errorf("expected where %t, got: %t", test.where, c.Where)
+			}
+			if c.Limit != test.limit {
+				t.Errorf("expected limit %t, got: %t", test.limit, c.Limit)
+			}
+		})
+	}
+}
+
+func TestStyle(t *testing.T) {
+	tests := []struct {
+		s     string
+		style Style
+		exp   string
+	}{
+		{`select a from b`, Style{}, `select a from b`},
+		{`select a, 'from' from b -- where`, Style{KeywordCase: "upper"}, `SELECT a, 'from' FROM b -- where`},
+		{`SELECT t.user FROM "T" t WHERE x IS NOT NULL`, Style{KeywordCase: "lower"}, `select t.user from "T" t where x is not null`},
+		{`SELECT setval('s', MAX(id)) FROM public.t`, Style{QuoteIdent: "always", Fold: "lower"}, `SELECT setval('s', MAX("id")) FROM "public"."t"`},
+		{`select $1::text::regclass from Foo`, Style{QuoteIdent: "always", Fold: "lower"}, `select $1::text::regclass from "foo"`},
+		{`ALTER TABLE t AUTO_INCREMENT = 1`, Style{QuoteIdent: "always", Quote: '`'}, "ALTER TABLE `t` AUTO_INCREMENT = 1"},
+		{`select "a", "B", "select", "c d" from "t"`, Style{QuoteIdent: "minimal", Fold: "lower"}, `select a, "B", "select", "c d" from t`},
+		{`select "A", "b" from t`, Style{QuoteIdent: "minimal", Fold: "upper"}, `select A, "b" from t`},
+		{`select [A], [b c] from [select]`, Style{QuoteIdent: "minimal"}, `select A, [b c] from [select]`},
+	}
+	for i, test := range tests {
+		if s := test.style.Apply(test.s); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package stmtclass
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Style is a SQL style, applied to generated SQL (ie, catalog queries, or
+// DDL) so that it matches the conventions of the user.
+type Style struct {
+	// KeywordCase is the case of keywords (preserve, upper, or lower).
+	KeywordCase string
+	// QuoteIdent is the identifier quoting (preserve, minimal, or always).
+	// Minimal removes the quotes of identifiers not needing them, and always
+	// quotes all identifiers.
+	QuoteIdent string
+	// Quote is the identifier quote (ie, " or `).
+	Quote rune
+	// Fold is the case that unquoted identifiers are folded to by the
+	// database (upper or lower), or empty when unquoted identifiers keep their
+	// case.
+	Fold string
+}
+
+// Apply applies the style to the keywords and identifiers of sqlstr, leaving
+// everything else (ie, whitespace, comments, and strings) unchanged.
+func (s Style) Apply(sqlstr string) string {
+	if (s.KeywordCase == "" || s.KeywordCase == "preserve") && (s.QuoteIdent == "" || s.QuoteIdent == "preserve") {
+		return sqlstr
+	}
+	r, toks := []rune(sqlstr), Tokenize(sqlstr)
+	var b strings.Builder
+	last := 0
+	for i, tok := range toks {
+		var v string
+		switch {
+		case tok.Type == Word && isKeyword(tok.Val) && !qualified(toks, i):
+			v = s.keyword(tok.Val)
+		case tok.Type == Word:
+			v = s.ident(toks, i)
+		case tok.Type == QuotedIdent && s.QuoteIdent == "minimal" && s.plain(tok.Val):
+			v = tok.Val
+		default:
+			continue
+		}
+		b.WriteString(string(r[last:tok.Pos]))
+		b.WriteString(v)
+		last = tok.End
+	}
+	b.WriteString(string(r[last:]))
+	return b.String()
+}
+
+// keyword returns the keyword in the style's case.
+func (s Style) keyword(keyword string) string {
+	switch s.KeywordCase {
+	case "upper":
+		return strings.ToUpper(keyword)
+	case "lower":
+		return strings.ToLower(keyword)
+	}
+	return keyword
+}
+
+// ident returns the unquoted identifier of the i'th token quoted when the
+// style always quotes identifiers. Function names and type names (ie, after
+// ::) are not quoted.
+func (s Style) ident(toks []Token, i int) string {
+	name := toks[i].Val
+	if s.QuoteIdent != "always" ||
+		(i+1 < len(toks) && toks[i+1].Type == Punct && toks[i+1].Val == "(") ||
+		(i > 1 && toks[i-1].Val == ":" && toks[i-2].Val == ":") {
+		return name
+	}
+	switch s.Fold {
+	case "upper":
+		name = strings.ToUpper(name)
+	case "lower":
+		name = strings.ToLower(name)
+	}
+	quote := s.Quote
+	if quote == 0 {
+		quote = '"'
+	}
+	q := string(quote)
+	return q + strings.ReplaceAll(name, q, q+q) + q
+}
+
+// plain returns true when the identifier does not need to be quoted.
+func (s Style) plain(name string) bool {
+	switch {
+	case !plainIdentRE.MatchString(name), isKeyword(name):
+		return false
+	case s.Fold == "upper":
+		return name == strings.ToUpper(name)
+	case s.Fold == "lower":
+		return name == strings.ToLower(name)
+	}
+	return true
+}
+
+// plainIdentRE matches identifiers that do not need quoting.
+var plainIdentRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// qualified returns true when the i'th token is part of a qualified name
+// (ie, the column of t.column).
+func qualified(toks []Token, i int) bool {
+	return (i > 0 && toks[i-1].Val == "." && toks[i-1].Type == Punct) ||
+		(i+1 < len(toks) && toks[i+1].Val == "." && toks[i+1].Type == Punct)
+}
+
+// isKeyword returns true when s is a SQL keyword.
+func isKeyword(s string) bool {
+	_, ok := keywords[strings.ToUpper(s)]
+	return ok
+}
+
+// keywords are the SQL keywords common to the dialects, including the
+// keywords of the statements generated by commands (ie, \fixseq) and the
+// standard type names.
+var keywords = make(map[string]struct{})
+
+func init() {
+	for _, s := range strings.Fields(`
+		ADD ALL ALTER ANALYZE AND ANY AS ASC ATTACH AUTO_INCREMENT BEGIN BETWEEN
+		BIGINT BLOB BOOLEAN BY CALL CASCADE CASE CAST CHAR CHARACTER CHECK
+		CHECKIDENT COLLATE COLUMN COMMIT CONFLICT CONSTRAINT CREATE CROSS
+		CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER DATE DBCC
+		DECIMAL DEFAULT DELETE DESC DETACH DISTINCT DO DOUBLE DROP ELSE END
+		ESCAPE EXCEPT EXEC EXECUTE EXISTS EXPLAIN FALSE FETCH FIRST FLOAT FOR
+		FOREIGN FROM FULL GENERATED GLOBAL GRANT GROUP HAVING IDENTITY IF ILIKE
+		IN INDEX INNER INSERT INT INTEGER INTERSECT INTERVAL INTO IS JOIN KEY
+		LAST LATERAL LEFT LIKE LIMIT LOCAL LOCALTIME LOCALTIMESTAMP MATERIALIZED
+		MAXVALUE MINVALUE MODIFY NATURAL NEXT NOT NOTHING NULL NULLS NUMERIC OF
+		OFFSET ON ONLY OR ORDER OUTER OVER PARTITION PRECISION PRIMARY REAL
+		RECURSIVE REFERENCES REPLACE RESEED RESTRICT RETURNING REVOKE RIGHT
+		ROLLBACK ROW ROWNUM ROWS SCHEMA SELECT SEQUENCE SESSION_USER SET SMALLINT
+		SOME START SYSDATE SYSTIMESTAMP TABLE TEMP TEMPORARY TEXT THEN TIME
+		TIMESTAMP TO TOP TRUE TRUNCATE UNION UNIQUE UPDATE USER USING VALUE
+		VALUES VARCHAR VARYING VIEW WHEN WHERE WINDOW WITH ZONE
+	`) {
+		keywords[s] = struct{}{}
+	}
+}
@@ -0,0 +1,233 @@
+package stmtclass
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TokenType is a token type.
+type TokenType int
+
+// Token types.
+const (
+	// Word is a keyword or unquoted identifier.
+	Word TokenType = iota
+	// QuotedIdent is a quoted identifier (ie, "ident", `ident`, or [ident]).
+	QuotedIdent
+	// String is a string literal (ie, 'str', E'str', or $tag$str$tag$).
+	String
+	// Number is a numeric literal.
+	Number
+	// Param is a placeholder or variable (ie, $1, ?, :name, or @name).
+	Param
+	// Punct is punctuation or an operator.
+	Punct
+)
+
+// Token is a SQL token.
+type Token struct {
+	// Type is the token type.
+	Type TokenType
+	// Val is the token value. Quoted identifiers and strings are unquoted.
+	Val string
+	// Depth is the parenthesis depth of the token.
+	Depth int
+	// Pos and End are the rune offsets of the start and end of the token in
+	// the statement.
+	Pos, End int
+}
+
+// Is returns true when the token is a word equal (case insensitive) to s.
+func (tok Token) Is(s string) bool {
+	return tok.Type == Word && strings.EqualFold(tok.Val, s)
+}
+
+// Ident returns true when the token is a word or a quoted identifier.
+func (tok Token) Ident() bool {
+	return tok.Type == Word || tok.Type == QuotedIdent
+}
+
+// Tokenize splits a SQL statement into tokens, skipping whitespace and
+// comments. Recognizes the quoting and comment styles of the common SQL
+// dialects, including PostgreSQL dollar strings, MySQL backticks and hash
+// comments, and SQL Server bracketed identifiers.
+func Tokenize(sqlstr string) []Token {
+	r := []rune(sqlstr)
+	var toks []Token
+	depth := 0
+	for i, end := 0, len(r); i < end; i++ {
+		c, next, start, n := r[i], grab(r, i+1, end), i, len(toks)
+		switch {
+		case unicode.IsSpace(c) || unicode.IsControl(c):
+		case c == '-' && next == '-', c == '#' && (next == 0 || unicode.IsSpace(next)):
+			i = find(r, i, end, '\n')
+		case c == '/' && next == '*':
+			i = readComment(r, i+2, end)
+		case c == '\'':
+			j := readString(r, i+1, end, '\'')
+			toks = append(toks, Token{Type: String, Val: unquote(r, i, j, '\''), Depth: depth})
+			i = j
+		case (c == 'E' || c == 'e' || c == 'N' || c == 'n' || c == 'X' || c == 'x' || c == 'B' || c == 'b') && next == '\'':
+			j := readString(r, i+2, end, '\'')
+			toks = append(toks, Token{Type: String, Val: unquote(r, i+1, j, '\''), Depth: depth})
+			i = j
+		case c == '$' && unicode.IsDigit(next):
+			j := i + 1
+			for j < end && unicode.IsDigit(r[j]) {
+				j++
+			}
+			toks = append(toks, Token{Type: Param, Val: string(r[i:j]), Depth: depth})
+			i = j - 1
+		case c == '$' && (next == '$' || isIdentStart(next)):
+			if tag, j, ok := readDollarTag(r, i, end); ok {
+				k := readDollarString(r, j+1, end, tag)
+				toks = append(toks, Token{Type: String, Val: string(r[j+1 : max(j+1, k-len(tag)-1)]), Depth: depth})
+				i = k
+				break
+			}
+			j := readWord(r, i+1, end)
+			toks = append(toks, Token{Type: Param, Val: string(r[i:j]), Depth: depth})
+			i = j - 1
+		case c == '"' || c == '`':
+			j := readString(r, i+1, end, c)
+			toks = append(toks, Token{Type: QuotedIdent, Val: unquote(r, i, j, c), Depth: depth})
+			i = j
+		case c == '[':
+			j := find(r, i+1, end, ']')
+			toks = append(toks, Token{Type: QuotedIdent, Val: string(r[i+1 : min(j, end)]), Depth: depth})
+			i = j
+		case c == '(':
+			depth++
+			toks = append(toks, Token{Type: Punct, Val: "(", Depth: depth})
+		case c == ')':
+			toks = append(toks, Token{Type: Punct, Val: ")", Depth: depth})
+			depth = max(depth-1, 0)
+		case c == '?':
+			toks = append(toks, Token{Type: Param, Val: "?", Depth: depth})
+		case (c == ':' || c == '@') && isIdentStart(next):
+			j := readWord(r, i+1, end)
+			toks = append(toks, Token{Type: Param, Val: string(r[i:j]), Depth: depth})
+			i = j - 1
+		case unicode.IsDigit(c):
+			j := i
+			for j < end && (unicode.IsDigit(r[j]) || r[j] == '.' || r[j] == 'e' || r[j] == 'E') {
+				j++
+			}
+			toks = append(toks, Token{Type: Number, Val: string(r[i:j]), Depth: depth})
+			i = j - 1
+		case isIdentStart(c):
+			j := readWord(r, i, end)
+			toks = append(toks, Token{Type: Word, Val: string(r[i:j]), Depth: depth})
+			i = j - 1
+		default:
+			toks = append(toks, Token{Type: Punct, Val: string(c), Depth: depth})
+		}
+		if len(toks) != n {
+			toks[n].Pos, toks[n].End = start, min(i+1, end)
+		}
+	}
+	return toks
+}
+
+// grab returns the i'th rune from r when i < end, otherwise 0.
+func grab(r []rune, i, end int) rune {
+	if i < end {
+		return r[i]
+	}
+	return 0
+}
+
+// find finds the next rune c in r, returning end if not found.
+func find(r []rune, i, end int, c rune) int {
+	for ; i < end; i++ {
+		if r[i] == c {
+			return i
+		}
+	}
+	return end
+}
+
+// readComment finds the end of a (possibly nested) multiline comment.
+func readComment(r []rune, i, end int) int {
+	for n := 1; i < end; i++ {
+		switch c, next := r[i], grab(r, i+1, end); {
+		case c == '/' && next == '*':
+			n, i = n+1, i+1
+		case c == '*' && next == '/':
+			if n, i = n-1, i+1; n == 0 {
+				return i
+			}
+		}
+	}
+	return end
+}
+
+// readString finds the end of a string quoted with quote, handling doubled
+// and backslash escaped quotes.
+func readString(r []rune, i, end int, quote rune) int {
+	for ; i < end; i++ {
+		switch c, next := r[i], grab(r, i+1, end); {
+		case c == '\\' && quote == '\'':
+			i++
+		case c == quote && next == quote:
+			i++
+		case c == quote:
+			return i
+		}
+	}
+	return end
+}
+
+// unquote returns the unquoted contents of the string between start and end.
+func unquote(r []rune, start, end int, quote rune) string {
+	s := string(r[start+1 : min(end, len(r))])
+	q := string(quote)
+	return strings.ReplaceAll(s, q+q, q)
+}
+
+// readDollarTag reads a dollar tag ($tag$) in r starting at i, returning the
+// tag and the position of its closing $.
+func readDollarTag(r []rune, i, end int) (string, int, bool) {
+	j := i + 1
+	for j < end && r[j] != '$' {
+		if !isIdentPart(r[j]) {
+			return "", i, false
+		}
+		j++
+	}
+	if j >= end {
+		return "", i, false
+	}
+	return string(r[i+1 : j]), j, true
+}
+
+// readDollarString finds the end of a dollar string with tag, returning the
+// position of the final $ of the closing tag.
+func readDollarString(r []rune, i, end int, tag string) int {
+	closing := []rune("$" + tag + "$")
+	for ; i+len(closing) <= end; i++ {
+		if string(r[i:i+len(closing)]) == string(closing) {
+			return i + len(closing) - 1
+		}
+	}
+	return end
+}
+
+// readWord reads a word from r starting at i, returning the position after
+// the word.
+func readWord(r []rune, i, end int) int {
+	for i < end && isIdentPart(r[i]) {
+		i++
+	}
+	return i
+}
+
+// isIdentStart returns true when c can start an identifier.
+func isIdentStart(c rune) bool {
+	return c == '_' || unicode.IsLetter(c)
+}
+
+// isIdentPart returns true when c can be part of an identifier.
+func isIdentPart(c rune) bool {
+	return c == '_' || c == '$' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
@@ -35,6 +35,16 @@ var (
 	ErrUnterminatedQuotedString = errors.New(`unterminated quoted string`)
 	// ErrNoShellAvailable is the no SHELL available error.
 	ErrNoShellAvailable = errors.New(`no SHELL available`)
+	// ErrInvalidOutputExists is the invalid OUTPUT_EXISTS value error.
+	ErrInvalidOutputExists = errors.New(`OUTPUT_EXISTS: allowed values are overwrite, append, backup, error, prompt`)
+	// ErrInvalidOutputLineage is the invalid OUTPUT_LINEAGE value error.
+	ErrInvalidOutputLineage = errors.New(`OUTPUT_LINEAGE: allowed values are off, comment, sidecar`)
+	// ErrInvalidKeywordCase is the invalid SQL_KEYWORD_CASE value error.
+	ErrInvalidKeywordCase = errors.New(`SQL_KEYWORD_CASE: allowed values are preserve, upper, lower`)
+	// ErrInvalidQuoteIdent is the invalid SQL_QUOTE_IDENT value error.
+	ErrInvalidQuoteIdent = errors.New(`SQL_QUOTE_IDENT: allowed values are preserve, minimal, always`)
+	// ErrNoValues is the no values error.
+	ErrNoValues = errors.New(`no values`)
 	// ErrNotInteractive is the not interactive error.
 	ErrNotInteractive = errors.New(`not interactive`)
 	// ErrInvalidType is the invalid type error.
@@ -45,8 +55,21 @@ var (
 	ErrInvalidValue = errors.New(`invalid value`)
 	// ErrTooManyRows is the too many rows error.
 	ErrTooManyRows = errors.New(`too many rows`)
-	// ErrInvalidFormatType is the invalid format type error.
-	ErrInvalidFormatType = errors.New(`\pset: allowed formats are unaligned, aligned, wrapped, html, asciidoc, latex, latex-longtable, troff-ms, json, csv`)
+	// ErrHiddenNotExecuted is the internal query not executed error.
+	ErrHiddenNotExecuted = errors.New(`query not executed (ECHO_HIDDEN is noexec)`)
+	// ErrCrosstabTooManyColumns is the too many crosstab columns error.
+	ErrCrosstabTooManyColumns = errors.New(`\crosstab: at most 4 columns (vertical, horizontal, data, and sort) can be specified`)
+	// ErrCondNoIf is the conditional branch without a matching \if error.
+	ErrCondNoIf = errors.New(`no matching \if`)
+	// ErrCondAfterElse is the conditional branch after \else error.
+	ErrCondAfterElse = errors.New(`cannot occur after \else`)
+	// ErrCondUnterminated is the end of input within a conditional block
+	// error.
+	ErrCondUnterminated = errors.New(`reached end of input without finding closing \endif`)
+	// ErrGsetNoRows is the no rows returned for \gset error.
+	ErrGsetNoRows = errors.New(`no rows returned for \gset`)
+	// ErrGsetTooManyRows is the more than one row returned for \gset error.
+	ErrGsetTooManyRows = errors.New(`more than one row returned for \gset`)
 	// ErrInvalidFormatPagerType is the invalid format pager error.
 	ErrInvalidFormatPagerType = errors.New(`\pset: allowed pager values are on, off, always`)
 	// ErrInvalidFormatExpandedType is the invalid format expanded error.
@@ -81,8 +104,36 @@ var (
 	ErrNamedConnectionIsNotAURL = errors.New(`named connection is not a url`)
 	// ErrInvalidConfig is the invalid config error.
 	ErrInvalidConfig = errors.New(`invalid config`)
-	// ErrIfEscaped is the if escaped error.
-	ErrIfEscaped = errors.New(`\if escaped`)
-	// ErrEndIfNoMatchingIf is the endif no matching if error.
-	ErrEndIfNoMatchingIf = errors.New(`\endif: no matching \if`)
+	// ErrAINotConfigured is the AI not configured error.
+	ErrAINotConfigured = errors.New(`no language model endpoint configured (set AI_URL)`)
+	// ErrAINoQuery is the AI no query error.
+	ErrAINoQuery = errors.New(`no query was generated`)
+	// ErrAINoResponse is the AI no response error.
+	ErrAINoResponse = errors.New(`no response from language model endpoint`)
+	// ErrNoFailedQuery is the no failed query error.
+	ErrNoFailedQuery = errors.New(`no failed query`)
+	// ErrInvalidVector is the invalid vector error.
+	ErrInvalidVector = errors.New(`invalid vector, expected a JSON array of numbers`)
+	// ErrInvalidSchemaCacheTTL is the invalid SCHEMA_CACHE_TTL value error.
+	ErrInvalidSchemaCacheTTL = errors.New(`SCHEMA_CACHE_TTL: must be a duration (ie, 24h)`)
+	// ErrNothingToRecover is the nothing to recover error.
+	ErrNothingToRecover = errors.New(`no query buffer to recover`)
+	// ErrNoScratchDriver is the no scratch driver error.
+	ErrNoScratchDriver = errors.New(`no driver available for local scratchpad`)
+	// ErrAuthNoCredentials is the auth command no credentials error.
+	ErrAuthNoCredentials = errors.New(`no credentials written to standard output`)
+	// ErrInvalidTLSPolicy is the invalid TLS_POLICY value error.
+	ErrInvalidTLSPolicy = errors.New(`TLS_POLICY: allowed values are off, require, fips`)
+	// ErrTLSPolicyFIPSRequired is the TLS_POLICY must be fips error.
+	ErrTLSPolicyFIPSRequired = errors.New(`TLS_POLICY: must be fips in FIPS 140-3 mode`)
+	// ErrFIPSNotEnabled is the FIPS 140-3 mode not enabled error.
+	ErrFIPSNotEnabled = errors.New(`TLS_POLICY fips requires FIPS 140-3 mode (build with GOFIPS140, or run with GODEBUG=fips140=on)`)
+	// ErrNoReplica is the no replica error.
+	ErrNoReplica = errors.New(`no read-only replica host available`)
+	// ErrNoPushedVariables is the no pushed variables error.
+	ErrNoPushedVariables = errors.New(`\pop: no variables saved by \push`)
+	// ErrInvalidNotifyDuration is the invalid notify duration error.
+	ErrInvalidNotifyDuration = errors.New(`\pset: notify must be a duration (ie, 30s) or off`)
+	// ErrNoImportColumns is the no import columns error.
+	ErrNoImportColumns = errors.New(`no columns to import`)
 )
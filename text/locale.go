@@ -0,0 +1,92 @@
+package text
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Messages are the localizable messages, by name.
+var Messages = map[string]*string{
+	"AIExecutePrompt":        &AIExecutePrompt,
+	"AIQueryNotExecuted":     &AIQueryNotExecuted,
+	"AutoPagerHint":          &AutoPagerHint,
+	"AvailableDrivers":       &AvailableDrivers,
+	"ConfirmPassword":        &ConfirmPassword,
+	"ConnInfo":               &ConnInfo,
+	"CouldNotSetVariable":    &CouldNotSetVariable,
+	"EnterPassword":          &EnterPassword,
+	"EnterPreviousPassword":  &EnterPreviousPassword,
+	"ExtraArgumentIgnored":   &ExtraArgumentIgnored,
+	"FormatFieldInvalid":     &FormatFieldInvalid,
+	"HelpBanner":             &HelpBanner,
+	"HelpCommandPrefix":      &HelpCommandPrefix,
+	"HelpDescShort":          &HelpDescShort,
+	"InListPrompt":           &InListPrompt,
+	"InListSet":              &InListSet,
+	"InvalidCommand":         &InvalidCommand,
+	"MissingRequiredArg":     &MissingRequiredArg,
+	"NewPassword":            &NewPassword,
+	"NotConnected":           &NotConnected,
+	"NotifyFailed":           &NotifyFailed,
+	"NotifySucceeded":        &NotifySucceeded,
+	"OverwriteFilePrompt":    &OverwriteFilePrompt,
+	"PasswordChangeFailed":   &PasswordChangeFailed,
+	"PasswordsDoNotMatch":    &PasswordsDoNotMatch,
+	"QueryBufferEmpty":       &QueryBufferEmpty,
+	"QueryBufferReset":       &QueryBufferReset,
+	"QuitDesc":               &QuitDesc,
+	"RecoverAvailable":       &RecoverAvailable,
+	"RelationNotFound":       &RelationNotFound,
+	"TimingDesc":             &TimingDesc,
+	"TimingSet":              &TimingSet,
+	"UnknownFormatFieldName": &UnknownFormatFieldName,
+	"WelcomeDesc":            &WelcomeDesc,
+}
+
+// Errors are the localizable errors, by name.
+var Errors = map[string]*error{
+	"ErrCannotIncludeDirectories":  &ErrCannotIncludeDirectories,
+	"ErrDriverNotAvailable":        &ErrDriverNotAvailable,
+	"ErrMissingDSN":                &ErrMissingDSN,
+	"ErrMissingRequiredArgument":   &ErrMissingRequiredArgument,
+	"ErrNoEditorDefined":           &ErrNoEditorDefined,
+	"ErrNoSuchFileOrDirectory":     &ErrNoSuchFileOrDirectory,
+	"ErrNotConnected":              &ErrNotConnected,
+	"ErrNotInteractive":            &ErrNotInteractive,
+	"ErrPasswordAttemptsExhausted": &ErrPasswordAttemptsExhausted,
+	"ErrUnknownCommand":            &ErrUnknownCommand,
+}
+
+// Localize replaces the messages, errors, and \pset messages (as
+// "pset.<name>") with the translations in catalog. Returns an error listing
+// the names in catalog that are not localizable.
+//
+// Localize must be called before any message is used, as errors are replaced
+// with new values, and are compared by identity.
+func Localize(catalog map[string]string) error {
+	var unknown []string
+	for name, s := range catalog {
+		if p, ok := Messages[name]; ok {
+			*p = s
+			continue
+		}
+		if p, ok := Errors[name]; ok {
+			*p = errors.New(s)
+			continue
+		}
+		if k, ok := strings.CutPrefix(name, "pset."); ok {
+			if _, ok := FormatFieldNameSetMap[k]; ok {
+				FormatFieldNameSetMap[k] = s
+				continue
+			}
+		}
+		unknown = append(unknown, name)
+	}
+	if len(unknown) != 0 {
+		slices.Sort(unknown)
+		return fmt.Errorf(UnknownMessages, strings.Join(unknown, ", "))
+	}
+	return nil
+}
@@ -43,8 +43,38 @@ var (
 	PasswordChangeFailed     = `\password for %q failed: %v`
 	CouldNotSetVariable      = `could not set variable %q`
 	ChartParseFailed         = `\chart: invalid argument for %q: %v`
-	CommandIgnoredUseEndIf   = `%s command ignored; use \endif or %s to exit current \if block`
 	UnrecognizedValueForCond = `unrecognized value %q for "\%s expression": Boolean expected`
+	PolicySelectStarDenied   = `policy: SELECT * on %q is not allowed`
+	PolicyWhereRequired      = `policy: query on %q requires a WHERE clause`
+	PolicyLimitUnsupported   = `policy: cannot add a row limit of %d to the query, add one explicitly`
+	ReadOnlyDenied           = `read-only: %s statements are not allowed`
+	DestructivePrompt        = `Execute destructive %s statement? [y/N] `
+	DestructiveNotConfirmed  = `destructive %s statement was not confirmed (see CONFIRM_DESTRUCTIVE)`
+	PreviewStatement         = "Statement: %s\nTables: %s\nRead-only: %s\nDestructive: %s"
+	InvalidFormatType        = `\pset: allowed formats are %s`
+	UnknownFormat            = `unknown format %q`
+	InListPrompt             = `Enter values, end with an empty line: `
+	InListSet                = `Set :%s to %d value(s).`
+	InListSetBatches         = `Set :%[1]s_1 .. :%[1]s_%[3]d to %[2]d value(s) in %[3]d batches (see :%[1]s_BATCHES).`
+	InvalidInListSize        = `\inlist: invalid size %q`
+	OutputFileExists         = `output file %q already exists`
+	OverwriteFilePrompt      = `Overwrite %q? [y/N] `
+	AutoPagerHint            = `(%d rows; use \pset pager on to page large results)`
+	InvalidTSShorthand       = `\ts: unknown shorthand %q (expected last)`
+	AIExecutePrompt          = `Execute query? [y/N] `
+	AIQueryNotExecuted       = `Query not executed (use \e to edit, or \g to execute).`
+	AIRequestFailed          = `language model request failed: %s: %s`
+	AIExplainPrompt          = "You explain SQL queries for a %s database. Briefly explain in plain language what the query does.\n\nThe database has the following tables and columns:\n\n%s"
+	AIFixPrompt              = "You fix SQL queries for a %s database. Respond with the corrected SQL query only, without explanation, keeping placeholders such as :_1 as is.\n\nThe database has the following tables and columns:\n\n%s"
+	AIFixRequest             = "Query:\n\n%s\n\nError:\n\n%s"
+	AISystemPrompt           = "You write SQL queries for a %s database. Respond with a single SQL query only, without explanation.\n\nThe database has the following tables and columns:\n\n%s"
+	FileLocked               = `%s is locked by another instance (remove %s if no other instance is running)`
+	InvalidKnnCount          = `\knn: invalid count %q`
+	InvalidTSDuration        = `\ts: invalid duration %q`
+	RecoverAvailable         = `An unsaved query buffer from a previous session is available, use \recover to restore it.`
+	SchemaCacheStale         = `(using stale metadata cached at %s; use \refresh to read it again)`
+	SchemaCacheRefreshed     = `Metadata cache cleared.`
+	StrictVarDenied          = `strict: variable :%[1]s cannot be interpolated into statements, use :'%[1]s' or :"%[1]s" (or add it to STRICT_VARS_ALLOW)`
 	// PasswordChangeSucceeded = `\password succeeded for %q`
 	HelpDesc          string
 	HelpDescShort     = `Use \? for help or press control-C to clear the input buffer.`
@@ -62,7 +92,13 @@ var (
 	FormatFieldInvalid      = `unrecognized value %q for "%s"`
 	FormatFieldInvalidValue = `unrecognized value %q for "%s": %s expected`
 	FormatFieldNameSetMap   = map[string]string{
+		`auto_expanded`:            `Automatic expanded display is %s.`,
+		`auto_json`:                `Automatic JSON display is %s.`,
+		`auto_pager`:               `Pager will be suggested for %d or more row(s).`,
+		`auto_value`:               `Automatic value display is %s.`,
 		`border`:                   `Border style is %d.`,
+		`coltype`:                  `Column types are %q.`,
+		`colwidth`:                 `Column widths are %q.`,
 		`columns`:                  `Target width is %d.`,
 		`expanded`:                 `Expanded display is %s.`,
 		`expanded_auto`:            `Expanded display is used automatically.`,
@@ -70,8 +106,11 @@ var (
 		`fieldsep_zero`:            `Field separator is zero byte.`,
 		`footer`:                   `Default footer is %s.`,
 		`format`:                   `Output format is %s.`,
+		`hyperlinks`:               `Terminal hyperlinks are %s.`,
+		`image_preview`:            `Image preview is %s.`,
 		`linestyle`:                `Line style is %s.`,
 		`locale`:                   `Locale is %q.`,
+		`notify`:                   `Notifying when statements run for %s or longer.`,
 		`null`:                     `Null display is %q.`,
 		`numericlocale`:            `Locale-adjusted numeric output is %s.`,
 		`pager`:                    `Pager usage is %s.`,
@@ -85,8 +124,12 @@ var (
 		`unicode_border_linestyle`: `Unicode border line style is %q.`,
 		`unicode_column_linestyle`: `Unicode column line style is %q.`,
 		`unicode_header_linestyle`: `Unicode header line style is %q.`,
+		`vector_preview`:           `Vector preview is %d element(s).`,
 	}
 	FormatFieldNameUnsetMap = map[string]string{
+		`coltype`:   `Column types unset.`,
+		`colwidth`:  `Column widths unset.`,
+		`notify`:    `Notifications are off.`,
 		`tableattr`: `Table attributes unset.`,
 		`title`:     `Title is unset.`,
 	}
@@ -96,12 +139,85 @@ var (
 	NotSupportedByDriver      = `%s not supported by %s driver`
 	RelationNotFound          = `Did not find any relation named "%s".`
 	InvalidOption             = `invalid option %q`
+	InvalidCheckpoint         = `invalid checkpoint %s: %v`
+	CheckpointNullKey         = `checkpoint key %s must not contain NULL values`
+	InvalidCheckpointValue    = `invalid checkpoint value type %q`
+	AuthCommandFailed         = `auth command failed: %v`
+	TLSPolicyRefused          = `%s connection refused by TLS_POLICY %s: %s (require TLS with a URL parameter, ie ?sslmode=verify-full)`
+	TLSPolicyInfo             = `TLS policy: %s, FIPS 140-3 mode: %s, TLS: %s`
+	InvalidSessionAttrs       = `invalid target_session_attrs %q`
+	SessionAttrsMismatch      = `session is not %s`
+	HostConnected             = `%s: connected`
+	HostFailed                = `%s: %v`
+	AllHostsFailed            = `unable to connect to any host: %s`
+	ConnHosts                 = `Hosts:`
+	NoSRVRecords              = `no SRV records found for %s`
+	InvalidRoute              = `invalid route %q (allowed values are primary, replica, auto)`
+	RouteIs                   = `Routing statements to %s.`
+	WizardDrivers             = `Available drivers: %s`
+	WizardDriver              = `Driver`
+	WizardFile                = `Database file`
+	WizardHost                = `Host`
+	WizardPort                = `Port (blank for the driver default)`
+	WizardDatabase            = `Database`
+	WizardUser                = `User`
+	WizardAuth                = `Authentication`
+	WizardAuthExamples        = "The command writes a password, token, or {\"user\", \"password\"} JSON object to its standard output, for example:\n  security find-generic-password -s mydb -w\n  vault kv get -field=password secret/mydb\n  aws rds generate-db-auth-token --hostname $USQL_AUTH_HOST --port $USQL_AUTH_PORT --username $USQL_AUTH_USER"
+	WizardAuthCommand         = `Command`
+	WizardTLS                 = `TLS`
+	WizardTLSUnknown          = `warning: TLS parameters for driver %s are not known, using the driver default`
+	WizardSave                = `Save as named connection (blank to skip)`
+	WizardSaved               = `Saved named connection %q for this session. To save it permanently, add to config.yaml:`
+	InvalidWizardChoice       = `invalid choice %q (allowed values are %s)`
+	DSNDetails                = `connection details:`
+	DSNExample                = `example`
+	DSNParams                 = `options`
+	NotifySucceeded           = `statement finished in %v`
+	NotifyFailed              = `statement failed after %v`
 	NotificationReceived      = `Asynchronous notification %q %sreceived from server process with PID %d.`
 	NotificationPayload       = `with payload %q `
 	UnknownShortAlias         = `(unk)`
 	InvalidNamedConnection    = `warning: named connection %q was not defined: %v`
 	ChartsPathDoesNotExist    = `warning: charts_path %q does not exist`
 	ChartsPathIsNotADirectory = `warning: charts_path %q is not a directory`
+	SetOptFailed              = `warning: could not set session option %s: %v`
+	InvalidStatementTimeout   = `invalid statement_timeout %q (expected a duration, ie 30s, or milliseconds)`
+	InvalidOptionValue        = `invalid value %q for session option %s`
+	UnknownMessages           = `warning: unknown messages in locale catalog: %s`
+	LocaleCatalogFailed       = `warning: could not load locale catalog %s: %v`
+	CaptureStarted            = `Capturing statements to %s.`
+	CaptureStopped            = `Not capturing statements.`
+	CaptureFailed             = `warning: could not capture statement to %s, capture stopped: %v`
+	InvalidCaptureAction      = `invalid capture action %q (allowed values are start, stop)`
+	CaptureEmpty              = `no statements captured in %s`
+	InvalidCaptureEntry       = `%s:%d: invalid capture entry: %v`
+	InvalidCaptureParam       = `invalid capture parameter type %q`
+	InvalidReplaySpeed        = `invalid replay speed %q (expected a multiplier, ie 2x)`
+	ReplayFailed              = `replay: session %s statement at %s failed: %v`
+	InvalidCopyCast           = `invalid copy cast %q (expected COLUMN=TYPE, with TYPE one of %s)`
+	CopyProgress              = `COPY %d (%d rows/s)`
+	ImpersonateRequiresUser   = `\su: %s driver requires a user name for proxy authentication`
+	Impersonating             = `Impersonating %s.`
+	NotImpersonating          = `Not impersonating.`
+	InvalidPartitionAction    = `\partitions: invalid action %q (allowed values are create, attach, detach, next)`
+	InvalidPartitionPeriod    = `\partitions: invalid period %q (allowed values are %s)`
+	NoPartitionBound          = `\partitions: could not determine the upper bound of the last partition of %s`
+	DDLNotExecuted            = `DDL not executed (use \e to edit, or \g to execute).`
+	UnknownImportFormat       = `could not determine the format of %s (use -format)`
+	InvalidImportFormat       = `invalid import format %q (allowed values are %s)`
+	InvalidImportObject       = `expected a JSON object, got: %s`
+	ImportValueMismatch       = `row %d: column %s value %q is not %s (types are inferred from the first %d rows, create the table with the correct types before importing)`
+	NoSequences               = `\fixseq: no sequences or identity columns found for %s`
+	FixSequencesNotExecuted   = `Statements not executed (use \fixseq -exec to execute).`
+	NoForeignKeys             = `\checkfk: no foreign keys found for %s`
+	FormatColumnsMismatch     = `%s output cannot contain result sets with different columns`
+	FormatInvalidValue        = `%s output: cannot write value %q of column %q as %s`
+	NoHelpAvailable           = "No help available for %q.\nTry \\h with no arguments to see available help."
+	HiddenQueryHeader         = `********* QUERY **********`
+	HiddenQueryFooter         = `**************************`
+	HiddenQueryArg            = `-- arg %d: %v`
+	GexecFailed               = `\gexec: %d of %d statement(s) failed`
+	ReplaySummary             = `Replayed %d statement(s) from %d session(s), %d failed, in %v (captured over %v).`
 	UsageTemplate             = `Usage:
   {{.UseLine}}
 
@@ -117,13 +233,21 @@ usage: \chart [opts]
 available options:
 
 help
-title    [title]     chart title
-subtitle [subtitle]  chart subtitle
-size     NxN         chart size (width x height)
-bg       [color]     chart background color
-type     [bar|line]  chart type
-prec     [num]       data decimal precision
-file     [path]      write chart to file (svg)`
+title    [title]                  chart title
+subtitle [subtitle]               chart subtitle
+size     NxN                      chart size in pixels (width x height)
+cells    NxN                      chart size in terminal cells (columns x rows)
+bg       [color]                  chart background color
+type     [bar|line|scatter|pie]   chart type
+x        [column]                 x axis (or pie label) column
+y        [column,...]             y axis (or pie value) columns
+prec     [num]                    data decimal precision
+bucket   [duration]               group rows into time buckets on the x axis
+agg      [func]                   bucket aggregate (avg, sum, min, max, count)
+file     [path]                   write chart to file (svg, or png)
+
+The chart type can also be passed first (ie, \chart pie). When terminal
+graphics are not available, the chart is written as text.`
 )
 
 func init() {